@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/stack"
+	"github.com/KulkarniKaustubh/ezstack/internal/ui"
+	"github.com/spf13/pflag"
+)
+
+// Absorb routes staged fixups to the stack branches that introduced the lines
+func Absorb(args []string) error {
+	fs := pflag.NewFlagSet("absorb", pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `%sAbsorb staged changes into the commits they fix%s
+
+%sUSAGE%s
+    ezs absorb [options]
+
+%sOPTIONS%s
+    -n, --dry-run    Show where each hunk would go without changing anything
+    -h, --help       Show this help message
+
+%sDESCRIPTION%s
+    Blames every staged hunk against the current branch and its ancestors in
+    the stack to find the commit that last touched those lines. Each group
+    of hunks is committed as a fixup in that commit's branch (in a temporary
+    worktree if the branch has none) and squashed into it, and every branch
+    below is restacked.
+
+    Hunks that touch lines from several commits, or from outside the stack,
+    stay in your working tree. New, deleted and binary files are never
+    absorbed. Your other changes are stashed during the absorb and restored
+    afterwards (unstaged).
+
+%sEXAMPLES%s
+    git add -p && ezs absorb     Absorb selected hunks
+    ezs absorb --dry-run         Preview the routing
+`, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
+	}
+
+	dryRun := fs.BoolP("dry-run", "n", false, "Show the plan only")
+	helpFlag := fs.BoolP("help", "h", false, "Show help")
+
+	if err := fs.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if *helpFlag {
+		fs.Usage()
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	mgr, err := stack.NewManager(cwd)
+	if err != nil {
+		return err
+	}
+
+	if pending, err := mgr.PendingSync(); err != nil {
+		return err
+	} else if pending != nil {
+		return fmt.Errorf("a sync is in progress. Finish it with 'ezs sync --continue' or 'ezs sync --abort' first")
+	}
+
+	_, branch, err := mgr.GetCurrentStack()
+	if err != nil {
+		return ui.NewExitError(ui.ExitNotInStack, "%v", err)
+	}
+
+	plan, err := mgr.PlanAbsorb(branch.Name)
+	if err != nil {
+		return err
+	}
+	printAbsorbPlan(plan)
+
+	if len(plan.Fixups) == 0 {
+		ui.Warn("Nothing to absorb")
+		return nil
+	}
+	if *dryRun {
+		return nil
+	}
+
+	if !ui.ConfirmTUI("Absorb these changes?") {
+		ui.Warn("Cancelled")
+		return nil
+	}
+
+	result, err := mgr.Absorb(plan)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range result.Absorbed {
+		ui.Success(fmt.Sprintf("Absorbed into %s (%.7s %s)", f.Branch, f.Commit, f.Subject))
+	}
+	for _, f := range result.Failed {
+		ui.Warn(fmt.Sprintf("Could not apply fixup for %s; left in your working tree", f))
+	}
+
+	var rewritten []string
+	for _, r := range result.Restacked {
+		if r.Success {
+			rewritten = append(rewritten, r.Branch)
+		}
+	}
+
+	if c := result.Conflict; c != nil {
+		fmt.Fprintln(os.Stderr)
+		if c.HasConflict {
+			ui.Warn(fmt.Sprintf("Conflict while rewriting %s", c.Branch))
+			ui.Warn(fmt.Sprintf("Resolve conflicts in: %s", c.WorktreePath))
+			ui.Info("Then run: git rebase --continue")
+		} else {
+			ui.Error(fmt.Sprintf("Failed to rewrite %s: %v", c.Branch, c.Error))
+		}
+		ui.Info("Branches below it were not restacked. Run 'ezs sync' once it is resolved.")
+		if result.Stashed {
+			ui.Warn("Your other changes are stashed. Run 'git stash pop' after the rebase finishes.")
+		}
+	} else {
+		ui.Success(fmt.Sprintf("Rewrote %d branch(es)", len(rewritten)))
+	}
+
+	var withPRs []string
+	for _, name := range rewritten {
+		if b := mgr.GetBranch(name); b != nil && b.PRNumber > 0 {
+			withPRs = append(withPRs, name)
+		}
+	}
+	OfferForcePushMultiple(withPRs, func(branchName string) string {
+		b := mgr.GetBranch(branchName)
+		if b == nil {
+			return ""
+		}
+		return b.WorktreePath
+	})
+
+	return nil
+}
+
+// printAbsorbPlan shows where each group of staged hunks will go
+func printAbsorbPlan(plan *stack.AbsorbPlan) {
+	for _, f := range plan.Fixups {
+		fmt.Fprintf(os.Stderr, "  %s%s%s %s %s%.7s%s %s\n", ui.Bold, f.Branch, ui.Reset, ui.IconArrow, ui.Yellow, f.Commit, ui.Reset, f.Subject)
+		for _, file := range f.Files {
+			fmt.Fprintf(os.Stderr, "      %s\n", file)
+		}
+	}
+	if len(plan.Unabsorbed) > 0 {
+		fmt.Fprintf(os.Stderr, "%sStaying in %s:%s\n", ui.Gray, plan.Branch, ui.Reset)
+		for _, u := range plan.Unabsorbed {
+			fmt.Fprintf(os.Stderr, "  %s%s%s\n", ui.Gray, u, ui.Reset)
+		}
+	}
+}
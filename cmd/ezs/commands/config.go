@@ -21,6 +21,8 @@ func printConfigUsage() {
 %sSUBCOMMANDS%s
     set <key> <value>    Set a configuration value
     show                 Show current configuration
+    relocate <old-path>  Move a repo's settings and stacks from the path it
+                         used to be checked out at to this repo
 
 %sKEYS FOR 'set'%s
     worktree_base_dir     Base directory for worktrees (per-repo)
@@ -28,12 +30,26 @@ func printConfigUsage() {
     github_token          GitHub token for API access
     cd_after_new          Auto-cd to new worktree (true/false, per-repo)
     use_worktrees         Use git worktrees for new branches (true/false, per-repo)
+    provider              PR provider: github or gitlab (per-repo, default: from remote URL)
+    gitlab_url            GitLab instance URL, if not https://<remote host> (per-repo)
+    github_api_url        GitHub API URL, e.g. https://ghe.example.com/api/v3 (per-repo)
+    stack_template        Go text/template file for the PR stack section, relative
+                          to the repo or absolute; empty for the default (per-repo)
+    stack_comment         Keep the PR stack section in a comment instead of the
+                          PR description (true/false, per-repo, default: false)
+    in_memory_rebase      Rebase during sync without checking branches out,
+                          falling back to the worktree on conflicts
+                          (true/false, per-repo, default: false; needs git 2.38+)
+    gitlab_token          GitLab token for API access (or set GITLAB_TOKEN)
 
 %sOPTIONS%s
     -h, --help    Show this help message
 
 %sNOTES%s
     If no subcommand is provided, runs interactive configuration.
+    Stacks are stored in the repo's git directory and move with it. Run
+    'ezs config relocate' after moving a checkout whose stacks predate that,
+    or whose settings still point at the old path.
 `, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
 }
 
@@ -59,6 +75,11 @@ func Config(args []string) error {
 		return configSet(args[1], args[2])
 	case "show":
 		return configShow()
+	case "relocate":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: ezs config relocate <old-path>")
+		}
+		return configRelocate(args[1])
 	default:
 		return fmt.Errorf("unknown config command: %s", args[0])
 	}
@@ -86,6 +107,28 @@ func getCurrentRepoPath() (string, error) {
 	return mainWorktree, nil
 }
 
+// configRelocate re-keys everything recorded for the repo at oldPath to the current repo
+func configRelocate(oldPath string) error {
+	repoPath, err := getCurrentRepoPath()
+	if err != nil {
+		return err
+	}
+	oldPath, err = filepath.Abs(helpers.ExpandPath(oldPath))
+	if err != nil {
+		return err
+	}
+	if oldPath == repoPath {
+		return fmt.Errorf("%s is already the current repository path", oldPath)
+	}
+
+	if err := config.RelocateRepo(oldPath, repoPath); err != nil {
+		return err
+	}
+	ui.Success(fmt.Sprintf("Moved ezstack data from %s to %s", oldPath, repoPath))
+	ui.Info("If the repo's worktrees moved too, run 'git worktree repair' in each of them")
+	return nil
+}
+
 func configSet(key, value string) error {
 	cfg, err := config.Load()
 	if err != nil {
@@ -126,6 +169,90 @@ func configSet(key, value string) error {
 		cfg.DefaultBaseBranch = value
 	case "github_token":
 		cfg.GitHubToken = value
+	case "gitlab_token":
+		cfg.GitLabToken = value
+	case "provider":
+		if value != "github" && value != "gitlab" {
+			return fmt.Errorf("invalid provider: %s. Must be one of: github, gitlab", value)
+		}
+		repoPath, err := getCurrentRepoPath()
+		if err != nil {
+			return fmt.Errorf("provider is a per-repo setting: %w", err)
+		}
+		repoCfg := cfg.GetRepoConfig(repoPath)
+		if repoCfg == nil {
+			repoCfg = &config.RepoConfig{}
+		}
+		repoCfg.Provider = value
+		cfg.SetRepoConfig(repoPath, repoCfg)
+		ui.Info(fmt.Sprintf("Setting provider for repo: %s", repoPath))
+	case "github_api_url":
+		repoPath, err := getCurrentRepoPath()
+		if err != nil {
+			return fmt.Errorf("github_api_url is a per-repo setting: %w", err)
+		}
+		repoCfg := cfg.GetRepoConfig(repoPath)
+		if repoCfg == nil {
+			repoCfg = &config.RepoConfig{}
+		}
+		repoCfg.GitHubAPIURL = strings.TrimRight(value, "/")
+		cfg.SetRepoConfig(repoPath, repoCfg)
+		ui.Info(fmt.Sprintf("Setting github_api_url for repo: %s", repoPath))
+	case "stack_template":
+		repoPath, err := getCurrentRepoPath()
+		if err != nil {
+			return fmt.Errorf("stack_template is a per-repo setting: %w", err)
+		}
+		repoCfg := cfg.GetRepoConfig(repoPath)
+		if repoCfg == nil {
+			repoCfg = &config.RepoConfig{}
+		}
+		repoCfg.StackTemplate = value
+		cfg.SetRepoConfig(repoPath, repoCfg)
+		if path := cfg.GetStackTemplate(repoPath); path != "" {
+			if _, err := loadStackTemplate(path); err != nil {
+				return err
+			}
+		}
+		ui.Info(fmt.Sprintf("Setting stack_template for repo: %s", repoPath))
+	case "stack_comment":
+		repoPath, err := getCurrentRepoPath()
+		if err != nil {
+			return fmt.Errorf("stack_comment is a per-repo setting: %w", err)
+		}
+		repoCfg := cfg.GetRepoConfig(repoPath)
+		if repoCfg == nil {
+			repoCfg = &config.RepoConfig{}
+		}
+		boolVal := value == "true" || value == "1" || value == "yes"
+		repoCfg.StackComment = &boolVal
+		cfg.SetRepoConfig(repoPath, repoCfg)
+		ui.Info(fmt.Sprintf("Setting stack_comment for repo: %s", repoPath))
+	case "in_memory_rebase":
+		repoPath, err := getCurrentRepoPath()
+		if err != nil {
+			return fmt.Errorf("in_memory_rebase is a per-repo setting: %w", err)
+		}
+		repoCfg := cfg.GetRepoConfig(repoPath)
+		if repoCfg == nil {
+			repoCfg = &config.RepoConfig{}
+		}
+		boolVal := value == "true" || value == "1" || value == "yes"
+		repoCfg.InMemoryRebase = &boolVal
+		cfg.SetRepoConfig(repoPath, repoCfg)
+		ui.Info(fmt.Sprintf("Setting in_memory_rebase for repo: %s", repoPath))
+	case "gitlab_url":
+		repoPath, err := getCurrentRepoPath()
+		if err != nil {
+			return fmt.Errorf("gitlab_url is a per-repo setting: %w", err)
+		}
+		repoCfg := cfg.GetRepoConfig(repoPath)
+		if repoCfg == nil {
+			repoCfg = &config.RepoConfig{}
+		}
+		repoCfg.GitLabURL = strings.TrimRight(value, "/")
+		cfg.SetRepoConfig(repoPath, repoCfg)
+		ui.Info(fmt.Sprintf("Setting gitlab_url for repo: %s", repoPath))
 	case "cd_after_new":
 		repoPath, err := getCurrentRepoPath()
 		if err != nil {
@@ -153,7 +280,7 @@ func configSet(key, value string) error {
 		cfg.SetRepoConfig(repoPath, repoCfg)
 		ui.Info(fmt.Sprintf("Setting use_worktrees for repo: %s", repoPath))
 	default:
-		return fmt.Errorf("unknown config key: %s\nValid keys: worktree_base_dir, default_base_branch, github_token, cd_after_new, use_worktrees", key)
+		return fmt.Errorf("unknown config key: %s\nValid keys: worktree_base_dir, default_base_branch, github_token, gitlab_token, cd_after_new, use_worktrees, provider, gitlab_url, github_api_url, stack_template, stack_comment, in_memory_rebase", key)
 	}
 
 	if err := cfg.Save(); err != nil {
@@ -161,7 +288,7 @@ func configSet(key, value string) error {
 	}
 
 	displayValue := value
-	if key == "github_token" {
+	if key == "github_token" || key == "gitlab_token" {
 		displayValue = "****** (set)"
 	}
 	ui.Success(fmt.Sprintf("Set %s = %s", key, displayValue))
@@ -191,7 +318,10 @@ func configShow() error {
 	if cfg.GitHubToken != "" {
 		fmt.Printf("  github_token:        %s\n", "****** (set)")
 	} else {
-		fmt.Printf("  github_token:        %s\n", "(not set - using gh cli)")
+		fmt.Printf("  github_token:        %s\n", "(not set - using GITHUB_TOKEN or gh cli)")
+	}
+	if cfg.GitLabToken != "" {
+		fmt.Printf("  gitlab_token:        %s\n", "****** (set)")
 	}
 
 	repoPath, err := getCurrentRepoPath()
@@ -214,6 +344,24 @@ func configShow() error {
 			} else {
 				fmt.Printf("  use_worktrees: true (default)\n")
 			}
+			if repoCfg.Provider != "" {
+				fmt.Printf("  provider: %s\n", repoCfg.Provider)
+			}
+			if repoCfg.GitHubAPIURL != "" {
+				fmt.Printf("  github_api_url: %s\n", repoCfg.GitHubAPIURL)
+			}
+			if repoCfg.GitLabURL != "" {
+				fmt.Printf("  gitlab_url: %s\n", repoCfg.GitLabURL)
+			}
+			if repoCfg.StackTemplate != "" {
+				fmt.Printf("  stack_template: %s\n", repoCfg.StackTemplate)
+			}
+			if repoCfg.StackComment != nil {
+				fmt.Printf("  stack_comment: %v\n", *repoCfg.StackComment)
+			}
+			if repoCfg.InMemoryRebase != nil {
+				fmt.Printf("  in_memory_rebase: %v\n", *repoCfg.InMemoryRebase)
+			}
 		} else {
 			fmt.Printf("  worktree_base_dir: %s(not configured for this repo)%s\n", ui.Yellow, ui.Reset)
 			fmt.Printf("  Run: ezs config set worktree_base_dir <path>\n")
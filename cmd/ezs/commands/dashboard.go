@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
+	"github.com/KulkarniKaustubh/ezstack/internal/stack"
+	"github.com/KulkarniKaustubh/ezstack/internal/ui"
+	"github.com/spf13/pflag"
+)
+
+// Dashboard opens the full-screen stack dashboard
+func Dashboard(args []string) error {
+	fs := pflag.NewFlagSet("ui", pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `%sFull-screen dashboard of all stacks%s
+
+%sUSAGE%s
+    ezs ui [options]
+
+%sDESCRIPTION%s
+    Shows every stack as a tree with PR and CI state. Move between branches
+    with ↑/↓ (or j/k) and press a key to act on the highlighted one; the
+    output of each action appears in the log pane.
+
+%sKEYS%s
+    g         Go to the branch's worktree and leave the dashboard
+    s         Sync the branch's stack
+    m         Move (reparent) the branch: move the cursor to the new
+              parent, or the stack header for the root, and press Enter
+    p         Create a PR for the branch
+    d         Toggle the PR between draft and ready for review
+    M         Merge the branch's PR (asks first)
+    x         Delete the branch and its worktree (asks first)
+    r         Refresh
+    q, Esc    Quit
+
+%sOPTIONS%s
+    -h, --help    Show this help message
+`, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
+	}
+	helpFlag := fs.BoolP("help", "h", false, "Show help")
+
+	if err := fs.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if *helpFlag {
+		fs.Usage()
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	g := git.New(cwd)
+	repoDir, err := g.GetMainWorktree()
+	if err != nil {
+		return err
+	}
+	authErr := checkProviderAuth(g)
+
+	warned := false
+	load := func(log io.Writer) (*ui.DashboardData, error) {
+		mgr, err := stack.NewManager(cwd)
+		if err != nil {
+			return nil, err
+		}
+		data := &ui.DashboardData{Stacks: mgr.ListStacks()}
+		data.CurrentBranch, _ = g.CurrentBranch()
+		if authErr == nil {
+			data.Status = fetchBranchStatuses(g, data.Stacks, false)
+		} else if !warned {
+			fmt.Fprintf(log, "%s%s %v (needed for PR/CI status)%s\n", ui.Yellow, ui.IconWarning, authErr, ui.Reset)
+			warned = true
+		}
+		return data, nil
+	}
+
+	// Actions run ezs itself so they behave exactly like the commands, with
+	// their output captured in the log pane
+	var gotoBranch string
+	actions := []ui.DashboardAction{
+		{Key: 'g', Label: "goto", Run: func(log io.Writer, s *config.Stack, b *config.Branch, _ string) (bool, error) {
+			gotoBranch = b.Name
+			return true, nil
+		}},
+		{Key: 's', Label: "sync", Run: func(log io.Writer, s *config.Stack, b *config.Branch, _ string) (bool, error) {
+			return false, runDashboardOperation(log, repoDir, "sync", s.Hash)
+		}},
+		{Key: 'm', Label: "move", Move: true, Run: func(log io.Writer, s *config.Stack, b *config.Branch, parent string) (bool, error) {
+			return false, runDashboardOperation(log, repoDir, "reparent", b.Name, parent)
+		}},
+		{Key: 'p', Label: "create PR", Run: func(log io.Writer, s *config.Stack, b *config.Branch, _ string) (bool, error) {
+			dir, err := branchWorktree(b)
+			if err != nil {
+				return false, err
+			}
+			// Without -y the description editor isn't opened; the PR type
+			// defaults to draft for WIP commits and ready otherwise
+			return false, runDashboardCommand(log, dir, "pr", "create", "--title", formatBranchTitle(b.Name))
+		}},
+		{Key: 'd', Label: "draft", Run: func(log io.Writer, s *config.Stack, b *config.Branch, _ string) (bool, error) {
+			dir, err := branchWorktree(b)
+			if err != nil {
+				return false, err
+			}
+			return false, runDashboardCommand(log, dir, "pr", "draft")
+		}},
+		{Key: 'M', Label: "merge", Confirm: true, Run: func(log io.Writer, s *config.Stack, b *config.Branch, _ string) (bool, error) {
+			dir, err := branchWorktree(b)
+			if err != nil {
+				return false, err
+			}
+			return false, runDashboardOperation(log, dir, "pr", "merge")
+		}},
+		{Key: 'x', Label: "delete", Confirm: true, Run: func(log io.Writer, s *config.Stack, b *config.Branch, _ string) (bool, error) {
+			return false, runDashboardOperation(log, repoDir, "delete", b.Name)
+		}},
+	}
+
+	d := &ui.Dashboard{
+		Title:   "ezstack · " + filepath.Base(repoDir),
+		Load:    load,
+		Actions: actions,
+	}
+	if err := d.Run(); err != nil {
+		return err
+	}
+	if gotoBranch != "" {
+		return Goto([]string{gotoBranch})
+	}
+	return nil
+}
+
+// branchWorktree returns the worktree commands acting on b have to run in
+func branchWorktree(b *config.Branch) (string, error) {
+	if b.WorktreePath == "" {
+		return "", fmt.Errorf("branch '%s' has no worktree", b.Name)
+	}
+	return b.WorktreePath, nil
+}
+
+// runDashboardOperation runs a stack-mutating ezs command with -y, like
+// runDashboardCommand, and records it in the operation log so it can be undone
+func runDashboardOperation(log io.Writer, dir, command string, args ...string) error {
+	rec := beginOperation(command, args)
+	err := runDashboardCommand(log, dir, append([]string{"-y", command}, args...)...)
+	if rec != nil {
+		rec.finish()
+	}
+	return err
+}
+
+// runDashboardCommand runs ezs with args in dir, writing its output to log.
+// It gets no stdin, so prompts fall back to their defaults. The command
+// doesn't record itself in the operation log; runDashboardOperation does.
+func runDashboardCommand(log io.Writer, dir string, args ...string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(log, "%s$ ezs %s%s\n", ui.Gray, strings.Join(args, " "), ui.Reset)
+	cmd := exec.Command(exe, args...)
+	cmd.Dir = dir
+	cmd.Stdout = log
+	cmd.Stderr = log
+	cmd.Env = append(os.Environ(), "EZS_SHELL_WRAPPER=", noOpLogEnv+"=1")
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("'ezs %s' failed: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
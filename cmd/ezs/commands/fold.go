@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
+	"github.com/KulkarniKaustubh/ezstack/internal/stack"
+	"github.com/KulkarniKaustubh/ezstack/internal/ui"
+	"github.com/spf13/pflag"
+)
+
+// Fold folds a branch into its parent and moves its children up
+func Fold(args []string) error {
+	fs := pflag.NewFlagSet("fold", pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `%sFold a branch into its parent%s
+
+%sUSAGE%s
+    ezs fold [branch] [options]
+
+%sOPTIONS%s
+    -b, --branch <name>     Branch to fold (default: current branch)
+    --close-pr              Close the folded branch's PR without asking
+    -h, --help              Show this help message
+
+%sDESCRIPTION%s
+    Moves the branch's commits into its parent, so two adjacent PRs can be
+    reviewed together. The parent is fast-forwarded to the branch, the
+    branch's children are moved under the parent, and the branch and its
+    worktree are deleted.
+
+    The branch must be based on the parent's current HEAD; run 'ezs sync'
+    first if it is not. Afterwards the parent can be pushed, the children's
+    PRs are retargeted onto the parent and the folded branch's PR can be
+    closed.
+
+%sEXAMPLES%s
+    ezs fold                    Fold the current branch into its parent
+    ezs fold feature-b          Fold feature-b into its parent
+    ezs fold --close-pr         Fold and close the PR without prompting
+`, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
+	}
+
+	branchFlag := fs.StringP("branch", "b", "", "Branch to fold")
+	closePR := fs.Bool("close-pr", false, "Close the folded branch's PR")
+	helpFlag := fs.BoolP("help", "h", false, "Show help")
+
+	if err := fs.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if *helpFlag {
+		fs.Usage()
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	g := git.New(cwd)
+	mgr, err := stack.NewManager(cwd)
+	if err != nil {
+		return err
+	}
+
+	currentBranch, _ := g.CurrentBranch()
+	branchName := *branchFlag
+	if branchName == "" && fs.NArg() >= 1 {
+		branchName = fs.Arg(0)
+	}
+	if branchName == "" {
+		branchName = currentBranch
+	}
+
+	branch := mgr.GetBranch(branchName)
+	if branch == nil {
+		return ui.NewExitError(ui.ExitNotInStack, "branch '%s' is not in a stack", branchName)
+	}
+
+	children := mgr.GetChildren(branchName)
+	ui.Info(fmt.Sprintf("Folding '%s' into '%s'", branchName, branch.Parent))
+	for _, c := range children {
+		ui.Info(fmt.Sprintf("'%s' will move under '%s'", c.Name, branch.Parent))
+	}
+	ui.Warn(fmt.Sprintf("Branch '%s' and its worktree will be deleted", branchName))
+
+	if !ui.ConfirmTUI(fmt.Sprintf("Fold '%s' into '%s'?", branchName, branch.Parent)) {
+		ui.Warn("Cancelled")
+		return nil
+	}
+
+	// The folded worktree is about to be removed; don't run git from inside it
+	repoRoot := mgr.GetRepoDir()
+	if err := os.Chdir(repoRoot); err != nil {
+		return fmt.Errorf("failed to change to repo root: %w", err)
+	}
+
+	result, err := mgr.FoldBranch(branchName)
+	if err != nil {
+		return err
+	}
+	parent := result.Parent
+	ui.Success(fmt.Sprintf("Folded '%s' into '%s'", branchName, parent.Name))
+
+	parentDir := repoRoot
+	if parent.WorktreePath != "" {
+		parentDir = parent.WorktreePath
+	}
+
+	// PRs are only retargeted once origin has the folded commits
+	pushSucceeded := false
+	if parent.PRNumber > 0 || git.New(parentDir).RemoteBranchExists(parent.Name) {
+		pushSucceeded = OfferForcePush(parent.Name, parentDir)
+	}
+
+	gh, ghErr := newGitHubClient(git.New(repoRoot))
+	if ghErr == nil {
+		if result.PRNumber > 0 {
+			if *closePR || ui.ConfirmTUI(fmt.Sprintf("Close PR #%d?", result.PRNumber)) {
+				comment := fmt.Sprintf("Folded into %s.", parent.Name)
+				if parent.PRNumber > 0 {
+					comment = fmt.Sprintf("Folded into #%d.", parent.PRNumber)
+				}
+				if err := gh.ClosePR(result.PRNumber, comment); err != nil {
+					ui.Warn(fmt.Sprintf("Failed to close PR #%d: %v", result.PRNumber, err))
+				} else {
+					ui.Success(fmt.Sprintf("Closed PR #%d", result.PRNumber))
+				}
+			}
+		}
+
+		// Retarget the children's PRs and refresh descriptions
+		if s := mgr.GetStackForBranch(parent.Name); s != nil {
+			if pushSucceeded {
+				updatePRMetadata(gh, mgr, s, parent)
+			} else {
+				ui.Warn(fmt.Sprintf("Skipped retargeting PRs since '%s' isn't pushed; push it and run 'ezs pr update'", parent.Name))
+			}
+		}
+	}
+
+	if s := mgr.GetStackForBranch(parent.Name); s != nil {
+		ui.PrintStack(s, parent.Name, false, nil)
+	}
+
+	if branchName == currentBranch {
+		EmitCd(parentDir)
+	}
+	return nil
+}
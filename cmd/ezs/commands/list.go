@@ -4,11 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"sync"
 
 	"github.com/KulkarniKaustubh/ezstack/internal/config"
 	"github.com/KulkarniKaustubh/ezstack/internal/git"
-	"github.com/KulkarniKaustubh/ezstack/internal/github"
 	"github.com/KulkarniKaustubh/ezstack/internal/stack"
 	"github.com/KulkarniKaustubh/ezstack/internal/ui"
 	"github.com/spf13/pflag"
@@ -160,12 +158,21 @@ func Status(args []string) error {
 
 %sOPTIONS%s
     -a, --all     Show all stacks
+    --json        Output as JSON, including ahead/behind counts, worktree
+                  state and whether each branch needs a sync (schema_version 1)
+    -w, --watch [interval]
+                  Redraw the status in place, refreshing PR/CI state every
+                  interval (default 30s, backing off while nothing changes)
+                  and listing what changed. Press r to refresh, q to quit
     -d, --debug   Show debug output
     -h, --help    Show this help message
 `, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
 	}
 	helpFlag := fs.BoolP("help", "h", false, "Show help")
 	all := fs.BoolP("all", "a", false, "Show all stacks")
+	jsonFlag := fs.Bool("json", false, "Output as JSON")
+	watch := fs.StringP("watch", "w", "", "Redraw the status every interval")
+	fs.Lookup("watch").NoOptDefVal = defaultWatchInterval.String()
 	debug := fs.BoolP("debug", "d", false, "Show debug output")
 	if err := fs.Parse(args); err != nil {
 		if err == pflag.ErrHelp {
@@ -177,14 +184,30 @@ func Status(args []string) error {
 		fs.Usage()
 		return nil
 	}
-	ghAvailable := github.CheckAuth() == nil
-
 	cwd, err := os.Getwd()
 	if err != nil {
 		return err
 	}
 
+	if fs.Changed("watch") {
+		if *jsonFlag {
+			return fmt.Errorf("--watch and --json cannot be used together")
+		}
+		// "--watch 10s" leaves the interval as an argument
+		spec := *watch
+		if fs.NArg() > 0 {
+			spec = fs.Arg(0)
+		}
+		interval, err := parseWatchInterval(spec)
+		if err != nil {
+			return err
+		}
+		return watchStatus(cwd, *all, interval, *debug)
+	}
+
 	g := git.New(cwd)
+	authErr := checkProviderAuth(g)
+	ghAvailable := authErr == nil
 	currentBranch, err := g.CurrentBranch()
 	if err != nil {
 		return err
@@ -196,6 +219,9 @@ func Status(args []string) error {
 	}
 
 	stacks := mgr.ListStacks()
+	if *jsonFlag {
+		return statusAsJSON(g, mgr, stacks, currentBranch, *all, authErr, *debug)
+	}
 	if len(stacks) == 0 {
 		ui.Info("No stacks found. Create one with: ezs new <branch-name>")
 		return nil
@@ -206,28 +232,17 @@ func Status(args []string) error {
 		if ghAvailable {
 			spinner := ui.NewDelayedSpinner("Fetching PR and CI status...")
 			spinner.Start()
-			statusMaps := make([]map[string]*ui.BranchStatus, len(stacks))
-			var wg sync.WaitGroup
-
-			for i, s := range stacks {
-				wg.Add(1)
-				go func(idx int, stack *config.Stack) {
-					defer wg.Done()
-					statusMaps[idx] = fetchBranchStatuses(g, stack, *debug)
-				}(i, s)
-			}
-
-			wg.Wait()
+			statusMap := fetchBranchStatuses(g, stacks, *debug)
 			spinner.Stop()
 
-			for i, s := range stacks {
-				ui.PrintStack(s, currentBranch, true, statusMaps[i])
+			for _, s := range stacks {
+				ui.PrintStack(s, currentBranch, true, statusMap)
 			}
 		} else {
 			for _, s := range stacks {
 				ui.PrintStack(s, currentBranch, false, nil)
 			}
-			ui.Warn("GitHub CLI not authenticated. Run 'gh auth login' for PR/CI status.")
+			ui.Warn(fmt.Sprintf("%v (needed for PR/CI status)", authErr))
 		}
 	}
 
@@ -244,7 +259,7 @@ func Status(args []string) error {
 	if ghAvailable {
 		spinner := ui.NewDelayedSpinner("Fetching PR and CI status...")
 		spinner.Start()
-		statusMap = fetchBranchStatuses(g, currentStack, *debug)
+		statusMap = fetchBranchStatuses(g, []*config.Stack{currentStack}, *debug)
 		spinner.Stop()
 	}
 
@@ -273,12 +288,31 @@ func Status(args []string) error {
 	}
 
 	if !ghAvailable {
-		ui.Warn("GitHub CLI not authenticated. Run 'gh auth login' for PR/CI status.")
+		ui.Warn(fmt.Sprintf("%v (needed for PR/CI status)", authErr))
 	}
 
 	return nil
 }
 
+// statusAsJSON prints 'ezs status --json': the current stack, or every stack
+// with all or outside a stack, without prompting for anything
+func statusAsJSON(g *git.Git, mgr *stack.Manager, stacks []*config.Stack, currentBranch string, all bool, authErr error, debug bool) error {
+	currentStack, _, err := mgr.GetCurrentStack()
+	if err != nil {
+		currentStack = nil
+	}
+	toShow := stacks
+	if !all && currentStack != nil {
+		toShow = []*config.Stack{currentStack}
+	}
+
+	var statusMap map[string]*ui.BranchStatus
+	if authErr == nil && len(toShow) > 0 {
+		statusMap = fetchBranchStatuses(g, toShow, debug)
+	}
+	return printStatusJSON(mgr, toShow, currentStack, currentBranch, statusMap, authErr)
+}
+
 // offerFullyMergedStackCleanup checks each stack whose branches are all marked merged
 // (updated in-memory by fetchBranchStatuses) and offers to delete them.
 func offerFullyMergedStackCleanup(mgr *stack.Manager, stacks []*config.Stack) {
@@ -29,11 +29,18 @@ func New(args []string) error {
     -C, --no-cd               Don't change to the new worktree (overrides config)
     -f, --from-worktree       Register an existing worktree as a stack root
     -r, --from-remote         Create a stack from a remote branch
+    --insert                  Insert between the parent and its current children
+    --insert-below            Insert between the parent and the parent's own parent
     -h, --help                Show this help message
 
 %sNOTES%s
     If no arguments are provided, interactive mode will prompt for options.
 
+    With --insert, the parent's children move under the new branch and are
+    rebased onto it. With --insert-below, the new branch is created on the
+    parent's parent and the parent (with its subtree) moves on top of it.
+    PRs of moved branches are retargeted onto the new branch.
+
     For cd to work, add this to your ~/.bashrc or ~/.zshrc:
         eval "$(ezs --shell-init)"
 `, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
@@ -44,6 +51,8 @@ func New(args []string) error {
 	noCdFlag := fs.BoolP("no-cd", "C", false, "Don't change to worktree")
 	fromWorktree := fs.BoolP("from-worktree", "f", false, "Register an existing worktree as a stack root")
 	fromRemote := fs.BoolP("from-remote", "r", false, "Create stack from remote branch")
+	insert := fs.Bool("insert", false, "Insert between the parent and its children")
+	insertBelow := fs.Bool("insert-below", false, "Insert between the parent and its parent")
 	helpFlag := fs.BoolP("help", "h", false, "Show help")
 
 	if err := fs.Parse(args); err != nil {
@@ -64,12 +73,20 @@ func New(args []string) error {
 
 	g := git.New(cwd)
 
+	inserting := *insert || *insertBelow
+	if *insert && *insertBelow {
+		return ui.NewExitError(ui.ExitUsage, "--insert and --insert-below cannot be used together")
+	}
+	if inserting && (*fromWorktree || *fromRemote) {
+		return ui.NewExitError(ui.ExitUsage, "--insert and --insert-below cannot be combined with --from-worktree or --from-remote")
+	}
+
 	var parentBranch string
 	useFromWorktree := *fromWorktree
 	useFromRemote := *fromRemote
 	chooseParent := false
 
-	if fs.NArg() == 0 && !useFromWorktree && !useFromRemote && *parent == "" {
+	if fs.NArg() == 0 && !useFromWorktree && !useFromRemote && *parent == "" && !inserting {
 		choice, err := ui.SelectOptionWithBack([]string{
 			"Create a new branch (use current branch as parent)",
 			"Create a new branch (choose parent branch)",
@@ -279,6 +296,17 @@ func New(args []string) error {
 		return err
 	}
 
+	// When inserting, the branch lands in the target's stack and takes over
+	// some of its branches once created
+	var moved []string
+	insertStack := ""
+	if inserting {
+		parentBranch, moved, insertStack, err = resolveInsert(mgr, parentBranch, *insertBelow)
+		if err != nil {
+			return err
+		}
+	}
+
 	repoDir := mgr.GetRepoDir()
 	useWorktrees := cfg.GetUseWorktrees(repoDir)
 
@@ -305,9 +333,12 @@ func New(args []string) error {
 		ui.Info(fmt.Sprintf("Creating branch '%s' from '%s'", branchName, parentBranch))
 		ui.Info(fmt.Sprintf("Worktree path: %s", worktreePath))
 
-		targetStack, isNewStack, skip, err := resolveStackIntent(mgr, parentBranch, branchName, worktreePath)
-		if err != nil {
-			return err
+		targetStack, isNewStack, skip := insertStack, false, false
+		if !inserting {
+			targetStack, isNewStack, skip, err = resolveStackIntent(mgr, parentBranch, branchName, worktreePath)
+			if err != nil {
+				return err
+			}
 		}
 		if skip {
 			if err := mgr.CreateWorktreeOnly(branchName, parentBranch, worktreePath); err != nil {
@@ -329,6 +360,12 @@ func New(args []string) error {
 
 		ui.Success(fmt.Sprintf("Created branch '%s' with worktree at '%s'", branch.Name, branch.WorktreePath))
 
+		if len(moved) > 0 {
+			if err := insertMoveBranches(mgr, g, branch.Name, moved); err != nil {
+				return err
+			}
+		}
+
 		if isNewStack {
 			promptStackName(mgr, branch.Name)
 		}
@@ -342,9 +379,12 @@ func New(args []string) error {
 		// No worktrees mode: create a git branch and track it
 		ui.Info(fmt.Sprintf("Creating branch '%s' from '%s' (no worktree)", branchName, parentBranch))
 
-		targetStack, isNewStack, _, err := resolveStackIntent(mgr, parentBranch, branchName, "")
-		if err != nil {
-			return err
+		targetStack, isNewStack := insertStack, false
+		if !inserting {
+			targetStack, isNewStack, _, err = resolveStackIntent(mgr, parentBranch, branchName, "")
+			if err != nil {
+				return err
+			}
 		}
 
 		branch, err := mgr.CreateBranchNoWorktree(branchName, parentBranch, targetStack)
@@ -354,6 +394,12 @@ func New(args []string) error {
 
 		ui.Success(fmt.Sprintf("Created branch '%s'", branch.Name))
 
+		if len(moved) > 0 {
+			if err := insertMoveBranches(mgr, g, branch.Name, moved); err != nil {
+				return err
+			}
+		}
+
 		if isNewStack {
 			promptStackName(mgr, branch.Name)
 		}
@@ -392,6 +438,96 @@ func resolveStackIntent(mgr *stack.Manager, parentBranch, branchName, worktreePa
 	return "new", true, false, nil
 }
 
+// resolveInsert works out where an inserted branch goes. For --insert the new
+// branch is created on target and takes over target's children; for
+// --insert-below it is created on target's parent and takes over target.
+// Returns the parent to create the branch on, the branches to move under it,
+// and the hash of the stack it belongs to.
+func resolveInsert(mgr *stack.Manager, target string, below bool) (string, []string, string, error) {
+	b := mgr.GetBranch(target)
+	if b == nil {
+		return "", nil, "", fmt.Errorf("'%s' is not in a stack; --insert and --insert-below need a stacked branch", target)
+	}
+	s := mgr.GetStackForBranch(target)
+
+	if below {
+		ui.Info(fmt.Sprintf("Inserting between '%s' and '%s'", b.Parent, target))
+		return b.Parent, []string{target}, s.Hash, nil
+	}
+
+	var moved []string
+	for _, c := range mgr.GetChildren(target) {
+		moved = append(moved, c.Name)
+	}
+	if len(moved) == 0 {
+		ui.Warn(fmt.Sprintf("'%s' has no children; the new branch will be a leaf", target))
+	} else {
+		ui.Info(fmt.Sprintf("Inserting between '%s' and %d child branch(es)", target, len(moved)))
+	}
+	return target, moved, s.Hash, nil
+}
+
+// insertMoveBranches moves branches under a freshly inserted branch, rebases
+// them onto it and retargets their PRs
+func insertMoveBranches(mgr *stack.Manager, g *git.Git, branchName string, moved []string) error {
+	results, err := mgr.MoveChildren(branchName, moved)
+	for _, r := range results {
+		if r.HasConflict {
+			ui.Warn(fmt.Sprintf("Moved '%s' under '%s', but rebase has conflicts", r.Branch.Name, branchName))
+			ui.Warn(fmt.Sprintf("Resolve conflicts in: %s", r.ConflictDir))
+			ui.Info("Then run: git rebase --continue")
+		} else {
+			ui.Success(fmt.Sprintf("Moved '%s' under '%s'", r.Branch.Name, branchName))
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	var withPRs []*config.Branch
+	for _, name := range moved {
+		if b := mgr.GetBranch(name); b != nil && b.PRNumber > 0 {
+			withPRs = append(withPRs, b)
+		}
+	}
+	if len(withPRs) == 0 {
+		return nil
+	}
+
+	gh, err := newGitHubClient(g)
+	if err != nil {
+		ui.Warn(fmt.Sprintf("Could not update PR base branches: %v", err))
+		return nil
+	}
+
+	// A PR can only target a branch that exists on the remote
+	if !g.RemoteBranchExists(branchName) {
+		if !ui.ConfirmTUIWithDefault(fmt.Sprintf("Push '%s' so %d PR(s) can target it?", branchName, len(withPRs)), true) {
+			ui.Warn(fmt.Sprintf("PR base branches not updated. Push '%s' and run 'ezs pr update' to retarget them", branchName))
+			return nil
+		}
+		if err := g.RunInteractive("push", "-u", "origin", branchName); err != nil {
+			ui.Warn(fmt.Sprintf("Push failed: %v. PR base branches not updated", err))
+			return nil
+		}
+	}
+
+	for _, b := range withPRs {
+		if err := gh.UpdatePRBase(b.PRNumber, branchName); err != nil {
+			ui.Warn(fmt.Sprintf("Failed to update PR #%d base branch: %v", b.PRNumber, err))
+		} else {
+			ui.Success(fmt.Sprintf("Updated PR #%d base branch to '%s'", b.PRNumber, branchName))
+		}
+	}
+
+	if s := mgr.GetStackForBranch(branchName); s != nil {
+		if err := updateStackDescriptions(gh, s, branchName); err != nil {
+			ui.Warn(fmt.Sprintf("Failed to update stack descriptions: %v", err))
+		}
+	}
+	return nil
+}
+
 // getCdAfterNew determines if we should cd after creating a new worktree
 func getCdAfterNew(cfg *config.Config, repoDir string, cdFlag, noCdFlag bool) bool {
 	if noCdFlag {
@@ -0,0 +1,451 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
+	"github.com/KulkarniKaustubh/ezstack/internal/ui"
+	"github.com/spf13/pflag"
+)
+
+// opRecorder captures repo state before a command runs so the change can be logged
+type opRecorder struct {
+	g         *git.Git
+	repoDir   string
+	op        *config.Operation
+	commits   map[string]string // branch -> commit before the command
+	worktrees map[string]string // branch -> worktree path before the command
+}
+
+// recordedCommands are the commands recorded in the operation log, as listed
+// by 'ezs oplog --help'. Only these are recorded, so the help can't miss one.
+// The dashboard records its sync, move, merge and delete actions under the
+// names of the commands it runs for them.
+var recordedCommands = []string{"new", "sync", "reparent", "split", "fold", "absorb", "delete", "stack", "unstack", "commit", "amend", "submit", "pr merge"}
+
+// isRecordedCommand reports whether command, or command with its subcommand
+// (e.g. "pr merge"), is one of recordedCommands
+func isRecordedCommand(command string, args []string) bool {
+	for _, c := range recordedCommands {
+		if c == command || (len(args) > 0 && c == command+" "+args[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordedCommandsHelp returns recordedCommands as an indented, wrapped list
+// for the help text
+func recordedCommandsHelp() string {
+	var b strings.Builder
+	line := "   "
+	for i, c := range recordedCommands {
+		word := " " + c
+		if i < len(recordedCommands)-1 {
+			word += ","
+		}
+		if len(line)+len(word) > 76 {
+			b.WriteString(line + "\n")
+			line = "   "
+		}
+		line += word
+	}
+	return b.String() + line
+}
+
+// noOpLogEnv is set for ezs commands run on behalf of another ezs process
+// that records them itself, e.g. the dashboard
+const noOpLogEnv = "EZS_NO_OPLOG"
+
+// RecordOperation runs a stack-mutating command and appends an entry to the
+// operation log if the command moved any branch or changed stack metadata.
+// Recording is best effort: failures are reported as warnings and never
+// affect the command itself.
+func RecordOperation(command string, args []string, run func([]string) error) error {
+	if os.Getenv(noOpLogEnv) != "" {
+		return run(args)
+	}
+	rec := beginOperation(command, args)
+	err := run(args)
+	if rec != nil {
+		rec.finish()
+	}
+	return err
+}
+
+func beginOperation(command string, args []string) *opRecorder {
+	if !isRecordedCommand(command, args) {
+		return nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	g := git.New(getMainWorktreePath(git.New(cwd)))
+
+	snapshot, err := config.SnapshotRepo(g.RepoDir)
+	if err != nil {
+		return nil
+	}
+	commits, err := g.ListBranchCommits()
+	if err != nil {
+		return nil
+	}
+	worktrees := make(map[string]string)
+	if wts, err := g.ListWorktrees(); err == nil {
+		for _, wt := range wts {
+			if wt.Branch != "" {
+				worktrees[wt.Branch] = wt.Path
+			}
+		}
+	}
+
+	return &opRecorder{
+		g:       g,
+		repoDir: g.RepoDir,
+		op: &config.Operation{
+			Command:  command,
+			Args:     args,
+			Time:     time.Now(),
+			Snapshot: snapshot,
+		},
+		commits:   commits,
+		worktrees: worktrees,
+	}
+}
+
+func (r *opRecorder) finish() {
+	after, err := r.g.ListBranchCommits()
+	if err != nil {
+		return
+	}
+	snapshot, err := config.SnapshotRepo(r.repoDir)
+	if err != nil {
+		return
+	}
+
+	refs := make(map[string]config.RefChange)
+	for name, before := range r.commits {
+		if after[name] != before {
+			refs[name] = config.RefChange{Before: before, After: after[name], Worktree: r.worktrees[name]}
+		}
+	}
+	for name, commit := range after {
+		if _, existed := r.commits[name]; !existed {
+			refs[name] = config.RefChange{After: commit}
+		}
+	}
+
+	if len(refs) == 0 && bytes.Equal(snapshot, r.op.Snapshot) {
+		return
+	}
+	if len(refs) > 0 {
+		r.op.Refs = refs
+	}
+
+	if err := config.AppendOperation(r.repoDir, r.op); err != nil {
+		ui.Warn(fmt.Sprintf("Failed to record operation: %v", err))
+	}
+}
+
+// shortSHA abbreviates a commit hash for display
+func shortSHA(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// printOperation prints one operation log entry with its branch changes
+func printOperation(op *config.Operation) {
+	cmdline := strings.TrimSpace("ezs " + op.Command + " " + strings.Join(op.Args, " "))
+	fmt.Fprintf(os.Stderr, "%s#%d%s  %s%s%s  %s%s%s\n",
+		ui.Yellow, op.ID, ui.Reset, ui.Bold, cmdline, ui.Reset, ui.Gray, op.Time.Local().Format("2006-01-02 15:04:05"), ui.Reset)
+
+	names := make([]string, 0, len(op.Refs))
+	for name := range op.Refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		fmt.Fprintf(os.Stderr, "    %s(stack metadata only)%s\n", ui.Gray, ui.Reset)
+	}
+	for _, name := range names {
+		rc := op.Refs[name]
+		switch {
+		case rc.Before == "":
+			fmt.Fprintf(os.Stderr, "    %s %s  %screated at %s%s\n", ui.IconBullet, name, ui.Gray, shortSHA(rc.After), ui.Reset)
+		case rc.After == "":
+			fmt.Fprintf(os.Stderr, "    %s %s  %sdeleted (was %s)%s\n", ui.IconBullet, name, ui.Gray, shortSHA(rc.Before), ui.Reset)
+		default:
+			fmt.Fprintf(os.Stderr, "    %s %s  %s %s %s\n", ui.IconBullet, name, shortSHA(rc.Before), ui.IconArrow, shortSHA(rc.After))
+		}
+	}
+}
+
+// Oplog shows the operation log for the current repo
+func Oplog(args []string) error {
+	fs := pflag.NewFlagSet("oplog", pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `%sShow the operation log%s
+
+%sUSAGE%s
+    ezs oplog [options]
+
+%sOPTIONS%s
+    -n, --limit <n>    Number of operations to show (default: 20)
+    --json             Output as JSON (machine-readable)
+    -h, --help         Show this help message
+
+%sDESCRIPTION%s
+    Lists the stack-mutating commands recorded for this repository, newest
+    first, with the branches each one moved. Use 'ezs undo' to revert them.
+    These commands are recorded:
+
+%s
+
+    The dashboard's sync, move, merge and delete actions are recorded too,
+    as sync, reparent, pr merge and delete.
+`, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, recordedCommandsHelp())
+	}
+
+	helpFlag := fs.BoolP("help", "h", false, "Show help")
+	limitFlag := fs.IntP("limit", "n", 20, "Number of operations to show")
+	jsonFlag := fs.Bool("json", false, "Output as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if *helpFlag {
+		fs.Usage()
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	repoDir := getMainWorktreePath(git.New(cwd))
+
+	ops, err := config.LoadOpLog(repoDir)
+	if err != nil {
+		return fmt.Errorf("failed to load operation log: %w", err)
+	}
+
+	// Newest first
+	var shown []*config.Operation
+	for i := len(ops) - 1; i >= 0 && len(shown) < *limitFlag; i-- {
+		shown = append(shown, ops[i])
+	}
+
+	if *jsonFlag {
+		if shown == nil {
+			shown = []*config.Operation{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(shown)
+	}
+
+	if len(shown) == 0 {
+		ui.Info("No operations recorded yet.")
+		return nil
+	}
+	for _, op := range shown {
+		printOperation(op)
+	}
+	return nil
+}
+
+// Undo reverts the most recent recorded operations
+func Undo(args []string) error {
+	fs := pflag.NewFlagSet("undo", pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `%sUndo recent stack operations%s
+
+%sUSAGE%s
+    ezs undo [n]
+
+%sOPTIONS%s
+    -h, --help     Show this help message
+
+%sDESCRIPTION%s
+    Reverts the last n recorded operations (default: 1). Every branch the
+    operations moved is reset to the commit it had before, deleted branches
+    are recreated (with their worktrees), and the stack metadata is restored
+    to its earlier snapshot. Branches created by the undone operations are
+    left in place. Undone operations are removed from the log.
+
+    Only local state is reverted: branches pushed and PRs created or
+    edited, e.g. by 'ezs submit', stay as they are on the remote.
+
+    If a branch has moved since the operation (e.g. new commits), you are
+    asked before it is reset. Worktrees with uncommitted changes are never
+    reset. Run 'ezs oplog' to see what would be undone.
+
+%sEXAMPLES%s
+    ezs undo       Undo the last operation
+    ezs undo 3     Undo the last three operations
+`, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
+	}
+
+	helpFlag := fs.BoolP("help", "h", false, "Show help")
+
+	if err := fs.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if *helpFlag {
+		fs.Usage()
+		return nil
+	}
+
+	n := 1
+	if fs.NArg() > 0 {
+		parsed, err := strconv.Atoi(fs.Arg(0))
+		if err != nil || parsed < 1 {
+			return ui.NewExitError(ui.ExitUsage, "invalid count: %s", fs.Arg(0))
+		}
+		n = parsed
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	g := git.New(getMainWorktreePath(git.New(cwd)))
+	repoDir := g.RepoDir
+
+	if pending, _ := config.LoadSyncState(repoDir); pending != nil {
+		return fmt.Errorf("a sync is in progress: run 'ezs sync --abort' to roll it back instead")
+	}
+
+	ops, err := config.LoadOpLog(repoDir)
+	if err != nil {
+		return fmt.Errorf("failed to load operation log: %w", err)
+	}
+	if len(ops) == 0 {
+		ui.Info("Nothing to undo.")
+		return nil
+	}
+	if n > len(ops) {
+		return fmt.Errorf("only %d operation(s) recorded", len(ops))
+	}
+	undone := ops[len(ops)-n:]
+
+	// Each branch goes back to where it was before the oldest undone operation
+	// that touched it; its expected current commit is the newest After.
+	targets := make(map[string]config.RefChange)
+	for _, op := range undone {
+		for name, rc := range op.Refs {
+			if prev, ok := targets[name]; ok {
+				prev.After = rc.After
+				targets[name] = prev
+			} else {
+				targets[name] = rc
+			}
+		}
+	}
+
+	ui.Info(fmt.Sprintf("Undoing %d operation(s):", n))
+	for i := len(undone) - 1; i >= 0; i-- {
+		printOperation(undone[i])
+	}
+	fmt.Fprintln(os.Stderr)
+
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	current, err := g.ListBranchCommits()
+	if err != nil {
+		return err
+	}
+	worktrees := make(map[string]string)
+	if wts, err := g.ListWorktrees(); err == nil {
+		for _, wt := range wts {
+			if wt.Branch != "" {
+				worktrees[wt.Branch] = wt.Path
+			}
+		}
+	}
+
+	var moved []string
+	for _, name := range names {
+		rc := targets[name]
+		if rc.Before == "" {
+			continue
+		}
+		if current[name] != rc.After {
+			moved = append(moved, name)
+		}
+		if path, ok := worktrees[name]; ok && current[name] != rc.Before {
+			if hasChanges, _ := git.New(path).HasChanges(); hasChanges {
+				return fmt.Errorf("cannot reset %s: worktree %s has uncommitted changes", name, path)
+			}
+		}
+	}
+	if len(moved) > 0 {
+		ui.Warn(fmt.Sprintf("These branches changed after the operation and will lose the newer commits: %s", strings.Join(moved, ", ")))
+	}
+
+	if !ui.ConfirmTUI(fmt.Sprintf("Undo %d operation(s)", n)) {
+		ui.Warn("Cancelled")
+		return nil
+	}
+
+	for _, name := range names {
+		rc := targets[name]
+		if rc.Before == "" {
+			ui.Info(fmt.Sprintf("Leaving %s in place (created by an undone operation)", name))
+			continue
+		}
+		if current[name] == rc.Before {
+			continue
+		}
+
+		if path, ok := worktrees[name]; ok {
+			if err := git.New(path).ResetHard(rc.Before); err != nil {
+				return fmt.Errorf("failed to reset %s: %w", name, err)
+			}
+		} else {
+			if err := g.SetBranchRef(name, rc.Before); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", name, err)
+			}
+			if rc.Worktree != "" {
+				if _, err := os.Stat(rc.Worktree); os.IsNotExist(err) {
+					if err := g.CreateWorktree(name, rc.Worktree, rc.Before); err != nil {
+						ui.Warn(fmt.Sprintf("Restored %s but could not recreate its worktree: %v", name, err))
+					}
+				}
+			}
+		}
+		ui.Success(fmt.Sprintf("Restored %s to %s", name, shortSHA(rc.Before)))
+	}
+
+	if err := config.RestoreRepo(repoDir, undone[0].Snapshot); err != nil {
+		return fmt.Errorf("failed to restore stack metadata: %w", err)
+	}
+	if err := config.TruncateOpLog(repoDir, n); err != nil {
+		ui.Warn(fmt.Sprintf("Failed to update operation log: %v", err))
+	}
+
+	ui.Success(fmt.Sprintf("Undid %d operation(s)", n))
+	return nil
+}
@@ -0,0 +1,23 @@
+package commands
+
+import "testing"
+
+func TestIsRecordedCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		args    []string
+		want    bool
+	}{
+		{"new", nil, true},
+		{"submit", []string{"--draft"}, true},
+		{"pr", []string{"merge", "--stack"}, true},
+		{"pr", []string{"create"}, false},
+		{"pr", nil, false},
+		{"status", nil, false},
+	}
+	for _, tt := range tests {
+		if got := isRecordedCommand(tt.command, tt.args); got != tt.want {
+			t.Errorf("isRecordedCommand(%q, %q) = %v, want %v", tt.command, tt.args, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/KulkarniKaustubh/ezstack/internal/config"
 	"github.com/KulkarniKaustubh/ezstack/internal/git"
@@ -42,8 +43,10 @@ func PR(args []string) error {
 		return nil
 	}
 
-	if err := github.CheckAuth(); err != nil {
-		return ui.NewExitError(ui.ExitAuthRequired, "%v", err)
+	if cwd, err := os.Getwd(); err == nil {
+		if err := checkProviderAuth(git.New(cwd)); err != nil {
+			return ui.NewExitError(ui.ExitAuthRequired, "%v", err)
+		}
 	}
 
 	if len(args) < 1 {
@@ -57,7 +60,7 @@ func PR(args []string) error {
 	case "update":
 		return prUpdate(args[1:])
 	case "merge":
-		return prMerge(args[1:])
+		return RecordOperation("pr", args, func(a []string) error { return prMerge(a[1:]) })
 	case "draft":
 		return prDraft(args[1:])
 	case "stack":
@@ -609,15 +612,34 @@ func prMerge(args []string) error {
 
 %sUSAGE%s
     ezs pr merge [options]
+    ezs pr merge --stack [--to <branch>] [options]
 
 %sOPTIONS%s
-    -m, --method <method>  Merge method: merge, squash, rebase (default: squash)
-    --no-delete-branch     Don't delete the remote branch after merge
-    -h, --help             Show this help message
-`, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
+    -m, --method <method>      Merge method: merge, squash, rebase (default: squash)
+    --no-delete-branch         Don't delete the remote branch after merge
+    -s, --stack                Merge the whole stack bottom-up (merge train)
+    --to <branch>              With --stack, stop after merging this branch
+    --checks-timeout <dur>     With --stack, how long to wait for each PR's checks (default: 30m)
+    --continue                 Resume a merge train that stopped
+    --abort                    Forget a stopped merge train
+    -h, --help                 Show this help message
+
+%sMERGE TRAIN%s
+    With --stack, the PRs from the bottom of the stack up to --to (or the top
+    of a linear stack) are landed one at a time: the PR is retargeted to the
+    stack root, the branch is rebased onto the freshly merged root and
+    force-pushed, its checks are awaited, and the PR is merged. The train
+    stops at the first conflict, failing check or timeout; fix the problem
+    and run 'ezs pr merge --continue'.
+`, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
 	}
 	method := fs.StringP("method", "m", "", "Merge method (merge, squash, rebase)")
 	noDeleteBranch := fs.Bool("no-delete-branch", false, "Don't delete remote branch after merge")
+	stackFlag := fs.BoolP("stack", "s", false, "Merge the whole stack bottom-up")
+	toBranch := fs.String("to", "", "Last branch to merge with --stack")
+	checksTimeout := fs.Duration("checks-timeout", 30*time.Minute, "How long to wait for each PR's checks")
+	continueFlag := fs.Bool("continue", false, "Resume a stopped merge train")
+	abortFlag := fs.Bool("abort", false, "Forget a stopped merge train")
 	helpFlag := fs.BoolP("help", "h", false, "Show help")
 
 	if err := fs.Parse(args); err != nil {
@@ -642,6 +664,30 @@ func prMerge(args []string) error {
 		return err
 	}
 
+	if *continueFlag && *abortFlag {
+		return fmt.Errorf("--continue and --abort cannot be used together")
+	}
+	if *abortFlag {
+		if err := mgr.AbortMergeTrain(); err != nil {
+			return err
+		}
+		ui.Success("Merge train aborted. PRs it already merged stay merged")
+		return nil
+	}
+	if *continueFlag || *stackFlag {
+		gh, err := newGitHubClient(g)
+		if err != nil {
+			return err
+		}
+		if *continueFlag {
+			return prMergeContinue(mgr, gh, *checksTimeout)
+		}
+		return prMergeStack(mgr, gh, *toBranch, *method, !*noDeleteBranch, *checksTimeout)
+	}
+	if *toBranch != "" {
+		return fmt.Errorf("--to requires --stack")
+	}
+
 	_, branch, err := mgr.GetCurrentStack()
 	if err != nil {
 		return err
@@ -669,51 +715,159 @@ func prMerge(args []string) error {
 		return fmt.Errorf("PR #%d is closed. Reopen it on GitHub first", branch.PRNumber)
 	}
 
-	// Choose merge method
-	mergeMethod := *method
+	mergeMethod, err := chooseMergeMethod(*method)
+	if err != nil {
+		return err
+	}
 	if mergeMethod == "" {
+		ui.Warn("Cancelled")
+		return nil
+	}
+
+	deleteRemoteBranch := !*noDeleteBranch
+
+	ui.Info(fmt.Sprintf("Merging PR #%d (%s) via %s", branch.PRNumber, branch.Name, mergeMethod))
+	if !ui.ConfirmTUI(fmt.Sprintf("Merge PR #%d via %s?", branch.PRNumber, mergeMethod)) {
+		ui.Warn("Cancelled")
+		return nil
+	}
+
+	if err := gh.MergePR(branch.PRNumber, mergeMethod, deleteRemoteBranch); err != nil {
+		return fmt.Errorf("failed to merge PR: %w. Check for required reviews, CI status, or branch protection rules", err)
+	}
+
+	ui.Success(fmt.Sprintf("Merged PR #%d via %s", branch.PRNumber, mergeMethod))
+
+	if ui.ConfirmTUIWithDefault("Run sync to update the stack and clean up merged branches?", true) {
+		return Sync([]string{"-s"})
+	}
+
+	ui.Info("Run 'ezs sync' later to update the stack")
+	return nil
+}
+
+// chooseMergeMethod validates the --method flag, or asks for a method when it
+// is empty. Returns "" if the user cancelled the selection.
+func chooseMergeMethod(method string) (string, error) {
+	if method == "" {
 		methodOptions := []string{"Squash and merge", "Create a merge commit", "Rebase and merge"}
-		choice := ui.SelectTUI(methodOptions, "Merge method", 0)
-		if choice == -1 {
-			ui.Warn("Cancelled")
-			return nil
-		}
-		switch choice {
+		switch ui.SelectTUI(methodOptions, "Merge method", 0) {
 		case 0:
-			mergeMethod = "squash"
+			method = "squash"
 		case 1:
-			mergeMethod = "merge"
+			method = "merge"
 		case 2:
-			mergeMethod = "rebase"
+			method = "rebase"
+		default:
+			return "", nil
 		}
 	}
 
-	// Validate method
-	switch mergeMethod {
+	switch method {
 	case "merge", "squash", "rebase":
-		// valid
+		return method, nil
 	default:
-		return fmt.Errorf("invalid merge method: %s. Must be one of: merge, squash, rebase", mergeMethod)
+		return "", fmt.Errorf("invalid merge method: %s. Must be one of: merge, squash, rebase", method)
 	}
+}
 
-	deleteRemoteBranch := !*noDeleteBranch
+// prMergeStack starts a merge train for the current stack
+func prMergeStack(mgr *stack.Manager, gh github.ClientInterface, to, method string, deleteRemoteBranch bool, checksTimeout time.Duration) error {
+	if pending, err := mgr.PendingMergeTrain(); err != nil {
+		return err
+	} else if pending != nil {
+		return fmt.Errorf("a merge train is already in progress: run 'ezs pr merge --continue' or 'ezs pr merge --abort'")
+	}
 
-	ui.Info(fmt.Sprintf("Merging PR #%d (%s) via %s", branch.PRNumber, branch.Name, mergeMethod))
-	if !ui.ConfirmTUI(fmt.Sprintf("Merge PR #%d via %s?", branch.PRNumber, mergeMethod)) {
+	currentStack, _, err := mgr.GetCurrentStack()
+	if err != nil {
+		return err
+	}
+
+	branches, err := mgr.PlanMergeTrain(currentStack, to)
+	if err != nil {
+		return err
+	}
+
+	ui.Info(fmt.Sprintf("Will merge %d PR(s) into %s, bottom-up:", len(branches), currentStack.Root))
+	for _, b := range branches {
+		fmt.Fprintf(os.Stderr, "  %s #%d %s\n", ui.IconBullet, b.PRNumber, b.Name)
+	}
+
+	mergeMethod, err := chooseMergeMethod(method)
+	if err != nil {
+		return err
+	}
+	if mergeMethod == "" || !ui.ConfirmTUI(fmt.Sprintf("Merge %d PR(s) via %s?", len(branches), mergeMethod)) {
 		ui.Warn("Cancelled")
 		return nil
 	}
 
-	if err := gh.MergePR(branch.PRNumber, mergeMethod, deleteRemoteBranch); err != nil {
-		return fmt.Errorf("failed to merge PR: %w. Check for required reviews, CI status, or branch protection rules", err)
+	state, err := mgr.StartMergeTrain(currentStack, branches, mergeMethod, deleteRemoteBranch)
+	if err != nil {
+		return err
 	}
+	return runMergeTrain(mgr, gh, state, checksTimeout)
+}
 
-	ui.Success(fmt.Sprintf("Merged PR #%d via %s", branch.PRNumber, mergeMethod))
+// prMergeContinue resumes a merge train that stopped
+func prMergeContinue(mgr *stack.Manager, gh github.ClientInterface, checksTimeout time.Duration) error {
+	state, err := mgr.PendingMergeTrain()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("no merge train in progress")
+	}
+	return runMergeTrain(mgr, gh, state, checksTimeout)
+}
+
+// runMergeTrain runs the train and prints a per-branch summary
+func runMergeTrain(mgr *stack.Manager, gh github.ClientInterface, state *config.MergeTrainState, checksTimeout time.Duration) error {
+	results, err := mgr.RunMergeTrain(gh, state, stack.MergeTrainOptions{
+		ChecksTimeout: checksTimeout,
+		PollInterval:  15 * time.Second,
+		Progress:      ui.Info,
+	})
+
+	fmt.Fprintf(os.Stderr, "\n%sMerge train%s\n", ui.Bold, ui.Reset)
+	stopped := false
+	for _, r := range results {
+		icon, color := ui.IconSuccess, ui.Green
+		switch r.Status {
+		case stack.TrainMerged, stack.TrainAlreadyMerged:
+		case stack.TrainNotStarted:
+			icon, color = ui.IconPending, ui.Gray
+		case stack.TrainChecksPending:
+			icon, color = ui.IconPending, ui.Yellow
+			stopped = true
+		default:
+			icon, color = ui.IconError, ui.Red
+			stopped = true
+		}
+		pr := ""
+		if r.PRNumber > 0 {
+			pr = fmt.Sprintf(" #%d", r.PRNumber)
+		}
+		fmt.Fprintf(os.Stderr, "  %s%s%s %s%s  %s%s%s\n", color, icon, ui.Reset, r.Branch, pr, color, r.Status, ui.Reset)
+		if r.Detail != "" {
+			fmt.Fprintf(os.Stderr, "      %s%s%s\n", ui.Gray, r.Detail, ui.Reset)
+		}
+	}
+	fmt.Fprintln(os.Stderr)
 
+	if err != nil {
+		return err
+	}
+	if stopped {
+		ui.Warn("Merge train stopped. Fix the problem, then run 'ezs pr merge --continue' (or 'ezs pr merge --abort')")
+		return nil
+	}
+
+	ui.Success("Merge train finished")
 	if ui.ConfirmTUIWithDefault("Run sync to update the stack and clean up merged branches?", true) {
 		return Sync([]string{"-s"})
 	}
-
 	ui.Info("Run 'ezs sync' later to update the stack")
 	return nil
 }
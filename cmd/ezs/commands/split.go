@@ -0,0 +1,273 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
+	"github.com/KulkarniKaustubh/ezstack/internal/stack"
+	"github.com/KulkarniKaustubh/ezstack/internal/ui"
+	"github.com/spf13/pflag"
+)
+
+// Split splits a branch into several stacked branches by commit
+func Split(args []string) error {
+	fs := pflag.NewFlagSet("split", pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `%sSplit a branch into several stacked branches%s
+
+%sUSAGE%s
+    ezs split [branch] [options]
+
+%sOPTIONS%s
+    -b, --branch <name>     Branch to split (default: current branch)
+    -s, --select            Pick the split points with fzf instead of the editor
+    -h, --help              Show this help message
+
+%sDESCRIPTION%s
+    Opens the branch's commits (oldest first) in your editor. Add a line
+    'branch <name>' after a commit to end a new branch at that commit.
+    With --select, mark the commits that end a new branch in fzf (Tab)
+    instead, and name each new branch when prompted.
+
+    The new branches are inserted into the stack between the branch and its
+    parent, in order. The original branch keeps its name, its remaining
+    commits, its PR and its children, so nothing is rebased. New branches get
+    a worktree when use_worktrees is enabled for the repo.
+
+%sEXAMPLES%s
+    ezs split                   Split the current branch
+    ezs split feature-a         Split feature-a
+    ezs split --select          Pick the split points with fzf
+`, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
+	}
+
+	branchFlag := fs.StringP("branch", "b", "", "Branch to split")
+	selectFlag := fs.BoolP("select", "s", false, "Pick split points with fzf")
+	helpFlag := fs.BoolP("help", "h", false, "Show help")
+
+	if err := fs.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if *helpFlag {
+		fs.Usage()
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	g := git.New(cwd)
+	mgr, err := stack.NewManager(cwd)
+	if err != nil {
+		return err
+	}
+
+	branchName := *branchFlag
+	if branchName == "" && fs.NArg() >= 1 {
+		branchName = fs.Arg(0)
+	}
+	if branchName == "" {
+		branchName, err = g.CurrentBranch()
+		if err != nil {
+			return err
+		}
+	}
+
+	branch := mgr.GetBranch(branchName)
+	if branch == nil {
+		return ui.NewExitError(ui.ExitNotInStack, "branch '%s' is not in a stack", branchName)
+	}
+
+	// A stacked parent is compared locally; a root parent against origin when possible
+	parentRef := branch.Parent
+	if mgr.GetBranch(branch.Parent) == nil && g.RemoteBranchExists(branch.Parent) {
+		parentRef = "origin/" + branch.Parent
+	}
+	commits, err := g.GetCommitsBetween(parentRef, branchName)
+	if err != nil {
+		return fmt.Errorf("failed to list commits: %w", err)
+	}
+	if len(commits) < 2 {
+		return fmt.Errorf("branch '%s' has %d commit(s) on top of '%s'; need at least 2 to split", branchName, len(commits), branch.Parent)
+	}
+
+	// GetCommitsBetween lists newest first; the plan reads oldest first
+	oldestFirst := make([]git.Commit, len(commits))
+	for i, c := range commits {
+		oldestFirst[len(commits)-1-i] = c
+	}
+
+	var plan string
+	if *selectFlag {
+		plan, err = selectSplitPlan(branchName, oldestFirst)
+		if err != nil {
+			return err
+		}
+	} else {
+		plan, err = ui.EditWithEditor(splitTemplate(branchName, oldestFirst), ".txt")
+		if err != nil {
+			return fmt.Errorf("editor failed: %w", err)
+		}
+	}
+
+	points, err := parseSplitPlan(plan, branchName, oldestFirst)
+	if err != nil {
+		return ui.NewExitError(ui.ExitUsage, "%v", err)
+	}
+	if len(points) == 0 {
+		ui.Warn("No split points marked, nothing to do")
+		return nil
+	}
+	for _, p := range points {
+		if g.BranchExists(p.Name) {
+			return ui.NewExitError(ui.ExitUsage, "branch '%s' already exists", p.Name)
+		}
+	}
+
+	ui.Info(fmt.Sprintf("Splitting '%s' into:", branchName))
+	for _, p := range points {
+		fmt.Fprintf(os.Stderr, "  %s%s%s (ends at %s%.7s%s)\n", ui.Bold, p.Name, ui.Reset, ui.Yellow, p.Commit, ui.Reset)
+	}
+	fmt.Fprintf(os.Stderr, "  %s%s%s (keeps the remaining commits)\n", ui.Bold, branchName, ui.Reset)
+
+	if !ui.ConfirmTUI("Proceed with split?") {
+		ui.Warn("Cancelled")
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	useWorktrees := cfg.GetUseWorktrees(mgr.GetRepoDir())
+
+	created, err := mgr.SplitBranch(branchName, points, useWorktrees)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range created {
+		if b.WorktreePath != "" {
+			ui.Success(fmt.Sprintf("Created branch '%s' at %s", b.Name, b.WorktreePath))
+		} else {
+			ui.Success(fmt.Sprintf("Created branch '%s'", b.Name))
+		}
+	}
+
+	branch = mgr.GetBranch(branchName)
+	if branch != nil && branch.PRNumber > 0 {
+		ui.Info(fmt.Sprintf("PR #%d stays on '%s'. Push the new branches and create their PRs with 'ezs pr create' to retarget it onto '%s'.", branch.PRNumber, branchName, branch.Parent))
+	}
+
+	if s := mgr.GetStackForBranch(branchName); s != nil {
+		ui.PrintStack(s, branchName, false, nil)
+	}
+	return nil
+}
+
+// splitTemplate builds the editor content listing a branch's commits, oldest first
+func splitTemplate(branchName string, commits []git.Commit) string {
+	var sb strings.Builder
+	for _, c := range commits {
+		fmt.Fprintf(&sb, "%.7s %s\n", c.Hash, c.Subject)
+	}
+	fmt.Fprintf(&sb, `
+# Split '%s' into stacked branches.
+#
+# Commits are listed oldest first. Add a line 'branch <name>' after a commit
+# to end a new branch at that commit. Commits after the last marker stay on
+# '%s', which keeps its PR and children.
+#
+# Do not reorder or remove commits. Lines starting with '#' are ignored.
+`, branchName, branchName)
+	return sb.String()
+}
+
+// selectSplitPlan lets the user mark split points in fzf and name each new
+// branch, and writes the result as a plan parseSplitPlan reads. The newest
+// commit is not offered, since it always stays on the original branch.
+func selectSplitPlan(branchName string, commits []git.Commit) (string, error) {
+	options := make([]string, len(commits)-1)
+	for i, c := range commits[:len(commits)-1] {
+		options[i] = fmt.Sprintf("%.7s %s", c.Hash, c.Subject)
+	}
+	selected, err := ui.SelectMultiple(options, fmt.Sprintf("Commits that end a new branch below '%s' (Tab to mark)", branchName))
+	if err != nil {
+		return "", err
+	}
+
+	names := make(map[int]string)
+	for _, i := range selected {
+		names[i] = ui.PromptRequired(fmt.Sprintf("Name for the branch ending at %s", options[i]))
+	}
+
+	var sb strings.Builder
+	for i, c := range commits {
+		fmt.Fprintf(&sb, "%.7s %s\n", c.Hash, c.Subject)
+		if name, ok := names[i]; ok {
+			fmt.Fprintf(&sb, "branch %s\n", name)
+		}
+	}
+	return sb.String(), nil
+}
+
+// parseSplitPlan reads the edited split template and returns the new branches
+// in stack order. commits must be oldest first, as written by splitTemplate.
+func parseSplitPlan(content, branchName string, commits []git.Commit) ([]stack.SplitPoint, error) {
+	var points []stack.SplitPoint
+	seen := map[string]bool{branchName: true}
+	next := 0 // index of the next expected commit
+	lastMarker := -1
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if fields[0] == "branch" {
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("invalid line %q: expected 'branch <name>'", line)
+			}
+			name := fields[1]
+			if next == 0 {
+				return nil, fmt.Errorf("'branch %s' must come after a commit", name)
+			}
+			if lastMarker == next {
+				return nil, fmt.Errorf("'branch %s' would have no commits", name)
+			}
+			if err := git.ValidateBranchName(name); err != nil {
+				return nil, err
+			}
+			if seen[name] {
+				return nil, fmt.Errorf("branch name '%s' is used more than once", name)
+			}
+			seen[name] = true
+			points = append(points, stack.SplitPoint{Name: name, Commit: commits[next-1].Hash})
+			lastMarker = next
+			continue
+		}
+
+		if next >= len(commits) || !strings.HasPrefix(commits[next].Hash, fields[0]) {
+			return nil, fmt.Errorf("unexpected line %q: commits cannot be reordered, removed or added", line)
+		}
+		next++
+	}
+
+	if next != len(commits) {
+		return nil, fmt.Errorf("commits cannot be reordered, removed or added")
+	}
+	if lastMarker == len(commits) {
+		return nil, fmt.Errorf("the last marker leaves no commits on '%s'", branchName)
+	}
+	return points, nil
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
+)
+
+func TestParseSplitPlan(t *testing.T) {
+	commits := []git.Commit{
+		{Hash: "1111111aaaa", Subject: "first"},
+		{Hash: "2222222bbbb", Subject: "second"},
+		{Hash: "3333333cccc", Subject: "third"},
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string // name@hash
+		wantErr bool
+	}{
+		{
+			name:    "no markers",
+			content: splitTemplate("feature", commits),
+		},
+		{
+			name:    "two markers",
+			content: "1111111 first\nbranch part-1\n2222222 second\nbranch part-2\n3333333 third\n",
+			want:    []string{"part-1@1111111aaaa", "part-2@2222222bbbb"},
+		},
+		{
+			name:    "comments and blank lines",
+			content: "# header\n\n1111111 first\n  branch part-1  \n2222222 second\n3333333 third\n# footer\n",
+			want:    []string{"part-1@1111111aaaa"},
+		},
+		{
+			name:    "marker before first commit",
+			content: "branch part-1\n1111111 first\n2222222 second\n3333333 third\n",
+			wantErr: true,
+		},
+		{
+			name:    "marker after last commit",
+			content: "1111111 first\n2222222 second\n3333333 third\nbranch part-1\n",
+			wantErr: true,
+		},
+		{
+			name:    "consecutive markers",
+			content: "1111111 first\nbranch part-1\nbranch part-2\n2222222 second\n3333333 third\n",
+			wantErr: true,
+		},
+		{
+			name:    "reordered commits",
+			content: "2222222 second\n1111111 first\nbranch part-1\n3333333 third\n",
+			wantErr: true,
+		},
+		{
+			name:    "removed commit",
+			content: "1111111 first\nbranch part-1\n2222222 second\n",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate name",
+			content: "1111111 first\nbranch part\n2222222 second\nbranch part\n3333333 third\n",
+			wantErr: true,
+		},
+		{
+			name:    "reuses original name",
+			content: "1111111 first\nbranch feature\n2222222 second\n3333333 third\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid name",
+			content: "1111111 first\nbranch bad..name\n2222222 second\n3333333 third\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, err := parseSplitPlan(tt.content, "feature", commits)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseSplitPlan() = %v, want error", points)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSplitPlan() error = %v", err)
+			}
+			if len(points) != len(tt.want) {
+				t.Fatalf("parseSplitPlan() returned %d points, want %d", len(points), len(tt.want))
+			}
+			for i, p := range points {
+				if got := p.Name + "@" + p.Commit; got != tt.want[i] {
+					t.Errorf("point %d = %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
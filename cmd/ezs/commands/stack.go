@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/KulkarniKaustubh/ezstack/internal/config"
 	"github.com/KulkarniKaustubh/ezstack/internal/git"
@@ -13,9 +14,18 @@ import (
 
 // Stack adds a branch to a stack (alias for reparent with standalone branch)
 func Stack(args []string) error {
-	// Check for "rename" subcommand before flag parsing
-	if len(args) > 0 && args[0] == "rename" {
-		return stackRename(args[1:])
+	// Check for subcommands before flag parsing
+	if len(args) > 0 {
+		switch args[0] {
+		case "rename":
+			return stackRename(args[1:])
+		case "push":
+			return stackPush(args[1:])
+		case "pull":
+			return stackPull(args[1:])
+		case "adopt":
+			return stackAdopt(args[1:])
+		}
 	}
 
 	fs := pflag.NewFlagSet("stack", pflag.ContinueOnError)
@@ -25,6 +35,9 @@ func Stack(args []string) error {
 %sUSAGE%s
     ezs stack [branch] [parent] [options]
     ezs stack rename [stack-hash] [name]
+    ezs stack push [stack-hash...] [--all] [--force]
+    ezs stack pull [stack-hash...]
+    ezs stack adopt [pr-number|branch]
 
 %sOPTIONS%s
     -b, --branch <name>     Branch to add to stack
@@ -34,6 +47,9 @@ func Stack(args []string) error {
 
 %sSUBCOMMANDS%s
     rename                  Rename an existing stack
+    push                    Share stacks with teammates through the remote
+    pull                    Fetch stacks shared by teammates
+    adopt                   Rebuild a stack from a chain of open PRs
 
 %sDESCRIPTION%s
     Adds an untracked branch/worktree to an existing stack by setting its parent.
@@ -49,6 +65,9 @@ func Stack(args []string) error {
     ezs stack -b my-branch --base develop Start a new stack on develop
     ezs stack rename                      Rename a stack (interactive)
     ezs stack rename a1b2c my-feature     Rename stack a1b2c to "my-feature"
+    ezs stack push                        Share the current stack
+    ezs stack pull                        Merge all shared stacks into yours
+    ezs stack adopt 123                   Track PR #123 and the PRs around it
 `, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
 	}
 
@@ -310,6 +329,268 @@ func stackRename(args []string) error {
 	return nil
 }
 
+func stackPush(args []string) error {
+	fs := pflag.NewFlagSet("stack push", pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `%sShare stacks through the remote%s
+
+%sUSAGE%s
+    ezs stack push [stack-hash...] [options]
+
+%sARGUMENTS%s
+    stack-hash    Stack hash prefix (min 3 chars). Defaults to the current stack.
+
+%sOPTIONS%s
+    -a, --all      Push every stack
+    -f, --force    Overwrite stacks a teammate changed since you last pulled
+    -h, --help     Show this help message
+
+%sDESCRIPTION%s
+    Records each stack's root, name, root PR and branch tree as a commit under
+    refs/ezstack/stacks/<hash> and pushes it to origin. Branches themselves are
+    pushed as usual with 'ezs push'. Teammates get the stacks with
+    'ezs stack pull'.
+`, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
+	}
+	allFlag := fs.BoolP("all", "a", false, "Push every stack")
+	forceFlag := fs.BoolP("force", "f", false, "Overwrite remote stacks")
+	helpFlag := fs.BoolP("help", "h", false, "Show help")
+	if err := fs.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if *helpFlag {
+		fs.Usage()
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	mgr, err := stack.NewManager(cwd)
+	if err != nil {
+		return err
+	}
+
+	var stacks []*config.Stack
+	switch {
+	case *allFlag:
+		if fs.NArg() > 0 {
+			return fmt.Errorf("--all cannot be combined with stack hashes")
+		}
+		stacks = mgr.ListStacks()
+	case fs.NArg() > 0:
+		for _, arg := range fs.Args() {
+			s, err := mgr.GetStackByHash(arg)
+			if err != nil {
+				return err
+			}
+			stacks = append(stacks, s)
+		}
+	default:
+		s, _, err := mgr.GetCurrentStack()
+		if err != nil {
+			return fmt.Errorf("%w; pass a stack hash or --all", err)
+		}
+		stacks = append(stacks, s)
+	}
+	if len(stacks) == 0 {
+		return fmt.Errorf("no stacks found")
+	}
+
+	rejected, err := mgr.PushStacks(stacks, *forceFlag)
+	if err != nil {
+		return err
+	}
+	isRejected := make(map[string]bool)
+	for _, hash := range rejected {
+		isRejected[hash] = true
+	}
+	for _, s := range stacks {
+		if isRejected[s.Hash] {
+			ui.Warn(fmt.Sprintf("Stack %s was changed on the remote; run 'ezs stack pull' first, or push with --force", s.DisplayName()))
+		} else {
+			ui.Success(fmt.Sprintf("Pushed stack %s", s.DisplayName()))
+		}
+	}
+	if len(rejected) > 0 {
+		return fmt.Errorf("%d stack(s) rejected by the remote", len(rejected))
+	}
+	return nil
+}
+
+func stackPull(args []string) error {
+	fs := pflag.NewFlagSet("stack pull", pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `%sFetch stacks shared by teammates%s
+
+%sUSAGE%s
+    ezs stack pull [stack-hash...]
+
+%sARGUMENTS%s
+    stack-hash    Full hash of a shared stack. Defaults to every shared stack.
+
+%sOPTIONS%s
+    -h, --help    Show this help message
+
+%sDESCRIPTION%s
+    Fetches refs/ezstack/stacks/* from origin and merges them into your stacks.
+    Stacks you don't have are added. For stacks you already have, branches
+    you don't have are added under their shared parent, and branches you do
+    have keep the parent you gave them. Branches missing locally are created
+    from origin without a worktree; check them out with 'ezs goto'.
+`, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
+	}
+	helpFlag := fs.BoolP("help", "h", false, "Show help")
+	if err := fs.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if *helpFlag {
+		fs.Usage()
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	mgr, err := stack.NewManager(cwd)
+	if err != nil {
+		return err
+	}
+
+	result, err := mgr.PullStacks(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range result.Added {
+		ui.Success(fmt.Sprintf("Added stack %s", mgr.GetStackByHashExact(hash).DisplayName()))
+	}
+	for _, hash := range result.Updated {
+		ui.Success(fmt.Sprintf("Updated stack %s", mgr.GetStackByHashExact(hash).DisplayName()))
+	}
+	for _, name := range result.Branches {
+		ui.Info(fmt.Sprintf("Created branch %s from origin/%s", name, name))
+	}
+	for _, reason := range result.Skipped {
+		ui.Warn("Skipped " + reason)
+	}
+	if len(result.Added) == 0 && len(result.Updated) == 0 {
+		ui.Info("Stacks are already up to date")
+	}
+	return nil
+}
+
+func stackAdopt(args []string) error {
+	fs := pflag.NewFlagSet("stack adopt", pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `%sRebuild a stack from a chain of open PRs%s
+
+%sUSAGE%s
+    ezs stack adopt [pr-number|branch] [options]
+
+%sARGUMENTS%s
+    pr-number|branch    Any PR of the chain, by number or head branch.
+                        Omit to pick from the open PRs.
+
+%sOPTIONS%s
+    -y, --yes     Don't ask for confirmation
+    -h, --help    Show this help message
+
+%sDESCRIPTION%s
+    For stacked PRs created outside ezstack (e.g. in the GitHub UI). Follows
+    the PR's base branch through the other open PRs down to the first base
+    that has no open PR, usually the default branch, which becomes the stack
+    root. Every open PR stacked on the bottom PR is adopted along with it.
+    Branches are fetched from origin and get a worktree each (or are created
+    as plain branches when use_worktrees is off), and their PRs are cached.
+`, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
+	}
+	yesFlag := fs.BoolP("yes", "y", false, "Skip confirmation")
+	helpFlag := fs.BoolP("help", "h", false, "Show help")
+	if err := fs.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if *helpFlag {
+		fs.Usage()
+		return nil
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: ezs stack adopt [pr-number|branch]")
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	g := git.New(cwd)
+	mgr, err := stack.NewManager(cwd)
+	if err != nil {
+		return err
+	}
+
+	gh, err := newGitHubClient(g)
+	if err != nil {
+		return err
+	}
+	ui.Info("Fetching open PRs...")
+	openPRs, err := gh.ListOpenPRs()
+	if err != nil {
+		return fmt.Errorf("failed to list open PRs: %w", err)
+	}
+	if len(openPRs) == 0 {
+		return fmt.Errorf("no open PRs found in this repository")
+	}
+
+	target := fs.Arg(0)
+	if target == "" {
+		options := make([]string, len(openPRs))
+		for i, pr := range openPRs {
+			options[i] = fmt.Sprintf("#%d %s → %s - %s (%s)", pr.Number, pr.Branch, pr.Base, pr.Title, pr.Author)
+		}
+		idx, err := ui.SelectOption(options, "Select a PR of the stack to adopt")
+		if err != nil {
+			return err
+		}
+		target = openPRs[idx].Branch
+	}
+
+	root, prs, err := stack.PlanPRTree(openPRs, target)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "\n%sStack on %s:%s\n", ui.Bold, root, ui.Reset)
+	depth := map[string]int{root: 0}
+	for _, pr := range prs {
+		depth[pr.Branch] = depth[pr.Base] + 1
+		fmt.Fprintf(os.Stderr, "%s%s %s#%d%s %s\n", strings.Repeat("  ", depth[pr.Branch]), pr.Branch, ui.Cyan, pr.Number, ui.Reset, pr.Title)
+	}
+	fmt.Fprintln(os.Stderr)
+
+	if !*yesFlag && !ui.ConfirmTUI(fmt.Sprintf("Adopt these %d PR(s) as a stack?", len(prs))) {
+		ui.Warn("Cancelled")
+		return nil
+	}
+
+	s, err := mgr.AdoptPRTree(root, prs)
+	if err != nil {
+		return err
+	}
+	ui.Success(fmt.Sprintf("Adopted %d PR(s) as stack %s", len(prs), s.DisplayName()))
+	return nil
+}
+
 // promptStackName prompts the user to optionally name a newly created stack
 func promptStackName(mgr *stack.Manager, branchName string) {
 	s := mgr.GetStackForBranch(branchName)
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/stack"
+	"github.com/KulkarniKaustubh/ezstack/internal/ui"
+)
+
+// statusSchemaVersion is bumped whenever a field of the 'ezs status --json'
+// output is renamed, removed or changes meaning. Adding fields doesn't bump it.
+const statusSchemaVersion = 1
+
+// statusJSON is the top level of 'ezs status --json'
+type statusJSON struct {
+	SchemaVersion int               `json:"schema_version"`
+	CurrentBranch string            `json:"current_branch"`
+	PRStatusError string            `json:"pr_status_error,omitempty"` // why live PR/CI data is missing
+	Stacks        []statusStackJSON `json:"stacks"`
+}
+
+type statusStackJSON struct {
+	Hash         string             `json:"hash"`
+	Name         string             `json:"name,omitempty"`
+	Root         string             `json:"root"`
+	RootPRNumber int                `json:"root_pr_number,omitempty"`
+	IsCurrent    bool               `json:"is_current"`
+	Branches     []statusBranchJSON `json:"branches"`
+}
+
+type statusBranchJSON struct {
+	Name         string          `json:"name"`
+	Parent       string          `json:"parent"`
+	IsCurrent    bool            `json:"is_current"`
+	IsMerged     bool            `json:"is_merged"`
+	IsRemote     bool            `json:"is_remote"`
+	WorktreePath string          `json:"worktree_path,omitempty"`
+	Dirty        *bool           `json:"dirty"`
+	PR           *statusPRJSON   `json:"pr"`
+	Commits      statusCommits   `json:"commits"`
+	Origin       statusOriginRef `json:"origin"`
+	NeedsSync    bool            `json:"needs_sync"`
+	SyncReason   string          `json:"sync_reason,omitempty"`
+}
+
+type statusPRJSON struct {
+	Number      int    `json:"number"`
+	URL         string `json:"url,omitempty"`
+	State       string `json:"state,omitempty"`
+	CIState     string `json:"ci_state,omitempty"`
+	CISummary   string `json:"ci_summary,omitempty"`
+	Mergeable   string `json:"mergeable,omitempty"`
+	ReviewState string `json:"review_state,omitempty"`
+}
+
+type statusCommits struct {
+	AheadOfParent int `json:"ahead_of_parent"`
+	BehindParent  int `json:"behind_parent"`
+	BehindRoot    int `json:"behind_root"`
+}
+
+type statusOriginRef struct {
+	Exists   bool `json:"exists"`
+	Ahead    int  `json:"ahead"`
+	Behind   int  `json:"behind"`
+	Diverged bool `json:"diverged"`
+}
+
+// printStatusJSON writes the status of stacks to stdout. statusMap holds the
+// live PR and CI data; when it is nil, PR fields come from the cache.
+func printStatusJSON(mgr *stack.Manager, stacks []*config.Stack, current *config.Stack, currentBranch string, statusMap map[string]*ui.BranchStatus, prErr error) error {
+	out := statusJSON{
+		SchemaVersion: statusSchemaVersion,
+		CurrentBranch: currentBranch,
+		Stacks:        make([]statusStackJSON, 0, len(stacks)),
+	}
+	if prErr != nil {
+		out.PRStatusError = prErr.Error()
+	}
+
+	for _, s := range stacks {
+		sj := statusStackJSON{
+			Hash:         s.Hash,
+			Name:         s.Name,
+			Root:         s.Root,
+			RootPRNumber: s.RootPRNumber,
+			IsCurrent:    current != nil && s.Hash == current.Hash,
+			Branches:     make([]statusBranchJSON, 0, len(s.Branches)),
+		}
+		for _, b := range config.SortBranchesTopologically(s.Branches) {
+			state := mgr.GetBranchState(s, b)
+			bj := statusBranchJSON{
+				Name:         b.Name,
+				Parent:       b.Parent,
+				IsCurrent:    b.Name == currentBranch,
+				IsMerged:     b.IsMerged,
+				IsRemote:     b.IsRemote,
+				WorktreePath: b.WorktreePath,
+				Dirty:        state.Dirty,
+				Commits: statusCommits{
+					AheadOfParent: state.AheadOfParent,
+					BehindParent:  state.BehindParent,
+					BehindRoot:    state.BehindRoot,
+				},
+				Origin: statusOriginRef{
+					Exists:   state.OnOrigin,
+					Ahead:    state.AheadOfOrigin,
+					Behind:   state.BehindOrigin,
+					Diverged: state.Diverged,
+				},
+				NeedsSync:  state.NeedsSync,
+				SyncReason: state.SyncReason,
+			}
+			if b.PRNumber > 0 {
+				bj.PR = &statusPRJSON{Number: b.PRNumber, URL: b.PRUrl, State: b.PRState}
+				if bj.PR.State == "" && b.IsMerged {
+					bj.PR.State = "MERGED"
+				}
+				if status := statusMap[b.Name]; status != nil {
+					bj.PR.State = status.PRState
+					bj.PR.CIState = status.CIState
+					bj.PR.CISummary = status.CISummary
+					bj.PR.Mergeable = status.Mergeable
+					bj.PR.ReviewState = status.ReviewState
+				}
+			}
+			sj.Branches = append(sj.Branches, bj)
+		}
+		out.Stacks = append(out.Stacks, sj)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
+	"github.com/KulkarniKaustubh/ezstack/internal/stack"
+	"github.com/KulkarniKaustubh/ezstack/internal/ui"
+)
+
+const (
+	defaultWatchInterval = 30 * time.Second
+	minWatchInterval     = 5 * time.Second
+	maxWatchInterval     = 5 * time.Minute // backoff cap while nothing changes
+)
+
+// parseWatchInterval parses the interval given to --watch, either a duration
+// like "45s" or "2m" or a number of seconds
+func parseWatchInterval(s string) (time.Duration, error) {
+	if s == "" {
+		return defaultWatchInterval, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		secs, convErr := strconv.Atoi(s)
+		if convErr != nil {
+			return 0, fmt.Errorf("invalid watch interval %q (use e.g. 30s or 2m)", s)
+		}
+		d = time.Duration(secs) * time.Second
+	}
+	if d < minWatchInterval {
+		return 0, fmt.Errorf("watch interval must be at least %s", minWatchInterval)
+	}
+	return d, nil
+}
+
+// statusChange is a PR or CI state that differs between two refreshes
+type statusChange struct {
+	Branch string
+	Field  string // "PR", "CI", "review" or "mergeable"
+	From   string
+	To     string
+}
+
+// bad reports whether the change needs attention, e.g. CI turned red
+func (c statusChange) bad() bool {
+	switch c.To {
+	case "failure", "CLOSED", "CHANGES_REQUESTED", "CONFLICTING":
+		return true
+	}
+	return false
+}
+
+// statusChanges lists what changed between two status maps from
+// fetchBranchStatuses, sorted by branch. Branches missing from cur (e.g. the
+// lookup failed) are skipped; branches only in cur got a PR.
+func statusChanges(prev, cur map[string]*ui.BranchStatus) []statusChange {
+	var changes []statusChange
+	for name, now := range cur {
+		if now == nil {
+			continue
+		}
+		before := prev[name]
+		if before == nil {
+			changes = append(changes, statusChange{Branch: name, Field: "PR", To: now.PRState})
+			continue
+		}
+		if before.PRState != now.PRState {
+			changes = append(changes, statusChange{Branch: name, Field: "PR", From: before.PRState, To: now.PRState})
+		}
+		if before.CIState != now.CIState {
+			changes = append(changes, statusChange{Branch: name, Field: "CI", From: before.CIState, To: now.CIState})
+		}
+		if before.ReviewState != now.ReviewState {
+			changes = append(changes, statusChange{Branch: name, Field: "review", From: before.ReviewState, To: now.ReviewState})
+		}
+		// Mergeability flips to UNKNOWN while GitHub recomputes it; only
+		// conflicts appearing or going away are worth showing
+		if before.Mergeable != now.Mergeable && (before.Mergeable == "CONFLICTING" || now.Mergeable == "CONFLICTING") {
+			changes = append(changes, statusChange{Branch: name, Field: "mergeable", From: before.Mergeable, To: now.Mergeable})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Branch < changes[j].Branch })
+	return changes
+}
+
+// orNone shows an empty state as "none"
+func orNone(state string) string {
+	if state == "" {
+		return "none"
+	}
+	return state
+}
+
+// watchStatus redraws the status of the current stack, or of every stack with
+// all or outside a stack, until the user quits
+func watchStatus(cwd string, all bool, interval time.Duration, debug bool) error {
+	g := git.New(cwd)
+	if err := checkProviderAuth(g); err != nil {
+		return fmt.Errorf("%v (needed for PR/CI status)", err)
+	}
+
+	var prev map[string]*ui.BranchStatus
+	var changes []statusChange
+	var changedAt time.Time
+
+	return ui.Watch(interval, max(maxWatchInterval, interval), func() (string, bool) {
+		var buf bytes.Buffer
+		mgr, err := stack.NewManager(cwd)
+		if err != nil {
+			return fmt.Sprintf("%s%s %v%s\n", ui.Red, ui.IconError, err, ui.Reset), false
+		}
+		currentBranch, _ := g.CurrentBranch()
+		stacks := mgr.ListStacks()
+		if currentStack, _, err := mgr.GetCurrentStack(); err == nil && !all {
+			stacks = []*config.Stack{currentStack}
+		}
+		if len(stacks) == 0 {
+			return "No stacks found. Create one with: ezs new <branch-name>\n", false
+		}
+
+		statusMap := fetchBranchStatuses(g, stacks, debug)
+		for _, s := range stacks {
+			ui.FprintStack(&buf, s, currentBranch, true, statusMap)
+		}
+
+		// An empty map means the lookup failed; keep comparing against the
+		// last good one so the next refresh doesn't report every PR as new
+		changed := false
+		if len(statusMap) > 0 {
+			if prev != nil {
+				if c := statusChanges(prev, statusMap); len(c) > 0 {
+					changes, changedAt, changed = c, time.Now(), true
+				}
+			}
+			prev = statusMap
+		}
+
+		if len(changes) > 0 {
+			fmt.Fprintf(&buf, "%s%sChanged at %s:%s\n", ui.Bold, ui.Cyan, changedAt.Format("15:04:05"), ui.Reset)
+			for _, c := range changes {
+				color := ui.Green
+				if c.bad() {
+					color = ui.Red
+				}
+				fmt.Fprintf(&buf, "  %s%s %s: %s %s %s%s\n", color, c.Branch, c.Field, orNone(c.From), ui.IconArrow, orNone(c.To), ui.Reset)
+			}
+			fmt.Fprintln(&buf)
+		}
+		return buf.String(), changed
+	})
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/ui"
+)
+
+func TestParseWatchInterval(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", defaultWatchInterval, false},
+		{"45s", 45 * time.Second, false},
+		{"2m", 2 * time.Minute, false},
+		{"10", 10 * time.Second, false},
+		{"1s", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseWatchInterval(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWatchInterval(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseWatchInterval(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusChanges(t *testing.T) {
+	prev := map[string]*ui.BranchStatus{
+		"feature-a": {PRState: "OPEN", CIState: "pending", Mergeable: "MERGEABLE"},
+		"feature-b": {PRState: "OPEN", CIState: "success", Mergeable: "MERGEABLE"},
+		"feature-c": {PRState: "DRAFT", CIState: "success"},
+	}
+	cur := map[string]*ui.BranchStatus{
+		"feature-a": {PRState: "OPEN", CIState: "failure", Mergeable: "UNKNOWN"},
+		"feature-b": {PRState: "OPEN", CIState: "success", Mergeable: "CONFLICTING", ReviewState: "APPROVED"},
+		"feature-d": {PRState: "OPEN"},
+	}
+
+	got := statusChanges(prev, cur)
+	want := []statusChange{
+		{Branch: "feature-a", Field: "CI", From: "pending", To: "failure"},
+		{Branch: "feature-b", Field: "review", From: "", To: "APPROVED"},
+		{Branch: "feature-b", Field: "mergeable", From: "MERGEABLE", To: "CONFLICTING"},
+		{Branch: "feature-d", Field: "PR", To: "OPEN"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statusChanges() =\n%+v\nwant\n%+v", got, want)
+	}
+	if !got[0].bad() || got[1].bad() {
+		t.Error("a failing CI run should need attention and an approval should not")
+	}
+
+	if changes := statusChanges(cur, cur); len(changes) != 0 {
+		t.Errorf("statusChanges() with no changes = %+v, want none", changes)
+	}
+}
@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
+	"github.com/KulkarniKaustubh/ezstack/internal/stack"
+	"github.com/KulkarniKaustubh/ezstack/internal/ui"
+	"github.com/spf13/pflag"
+)
+
+// Submit pushes the current stack and creates or updates all of its PRs
+func Submit(args []string) error {
+	fs := pflag.NewFlagSet("submit", pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, `%sPush every branch of the stack and create or update its PRs%s
+
+%sUSAGE%s
+    ezs submit [options]
+
+%sOPTIONS%s
+    -n, --dry-run  Print what would be pushed and created without doing it
+    -d, --draft    Create new PRs as drafts
+    -h, --help     Show this help message
+
+%sNOTES%s
+    Branches are handled parent first. A branch is pushed when it is ahead
+    of origin, with --force-with-lease when it was rebased. Branches without
+    a PR get one against their parent, PRs with the wrong base are retargeted
+    and the stack section of every PR is refreshed.
+`, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
+	}
+	dryRun := fs.BoolP("dry-run", "n", false, "Print the plan only")
+	draft := fs.BoolP("draft", "d", false, "Create new PRs as drafts")
+	helpFlag := fs.BoolP("help", "h", false, "Show help")
+
+	if err := fs.Parse(args); err != nil {
+		if err == pflag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if *helpFlag {
+		fs.Usage()
+		return nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	mgr, err := stack.NewManager(cwd)
+	if err != nil {
+		return err
+	}
+	currentStack, _, err := mgr.GetCurrentStack()
+	if err != nil {
+		return err
+	}
+
+	gh, err := newGitHubClient(git.New(cwd))
+	if err != nil {
+		return err
+	}
+
+	steps, err := mgr.PlanSubmit(gh, currentStack)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		ui.Info("No unmerged branches to submit")
+		return nil
+	}
+
+	actions := 0
+	ui.Info(fmt.Sprintf("Submitting stack %s:", currentStack.DisplayName()))
+	for _, step := range steps {
+		fmt.Fprintf(os.Stderr, "  %s %s%s%s\n", ui.IconBullet, ui.Bold, step.Branch.Name, ui.Reset)
+		if step.Push != stack.PushNone {
+			fmt.Fprintf(os.Stderr, "      %s\n", step.Push)
+			actions++
+		}
+		switch {
+		case step.FoundPR != nil:
+			fmt.Fprintf(os.Stderr, "      record existing PR #%d\n", step.FoundPR.Number)
+			actions++
+		case step.CreatePR:
+			kind := "PR"
+			if *draft {
+				kind = "draft PR"
+			}
+			fmt.Fprintf(os.Stderr, "      create %s (base: %s)\n", kind, step.Branch.Parent)
+			actions++
+		}
+		if step.Retarget != "" {
+			fmt.Fprintf(os.Stderr, "      retarget PR #%d from %s to %s\n", step.Branch.PRNumber, step.Retarget, step.Branch.Parent)
+			actions++
+		}
+		if step.Note != "" {
+			fmt.Fprintf(os.Stderr, "      %s%s%s\n", ui.Gray, step.Note, ui.Reset)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "  %s update stack descriptions\n", ui.IconBullet)
+
+	if *dryRun {
+		ui.Info("Dry run: nothing was pushed or changed")
+		return nil
+	}
+	if actions > 0 && !ui.ConfirmTUI("Submit stack") {
+		ui.Warn("Cancelled")
+		return nil
+	}
+
+	err = mgr.Submit(gh, currentStack, steps, stack.SubmitOptions{
+		Draft:    *draft,
+		Title:    formatBranchTitle,
+		Progress: ui.Info,
+	})
+
+	failed := 0
+	for _, step := range steps {
+		if step.Err != nil {
+			ui.Warn(fmt.Sprintf("%s: %v", step.Branch.Name, step.Err))
+			failed++
+		} else if step.CreatePR {
+			ui.Success(fmt.Sprintf("Created PR #%d for %s: %s", step.Branch.PRNumber, step.Branch.Name, step.Branch.PRUrl))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update PRs: %w", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d branch(es) could not be submitted", failed)
+	}
+	ui.Success("Stack submitted")
+	return nil
+}
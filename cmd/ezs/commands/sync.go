@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"runtime"
+	"strings"
 
 	"github.com/KulkarniKaustubh/ezstack/internal/config"
 	"github.com/KulkarniKaustubh/ezstack/internal/git"
@@ -32,7 +34,10 @@ func Sync(args []string) error {
     --no-delete-local      Don't delete local branches after their PRs are merged
     --dry-run              Preview what would be synced without making changes
     --no-autostash         Don't stash uncommitted changes before rebase
+    -j, --jobs <n>         Stacks to sync at once with --all (default: one per CPU; 1 syncs them in turn, with the usual prompts)
     --json                 Output dry-run results as JSON (requires --dry-run)
+    --continue             Resume a sync that stopped on a rebase conflict
+    --abort                Abandon an interrupted sync and restore pre-sync commits
     -h, --help             Show this help message
 
 %sDESCRIPTION%s
@@ -49,6 +54,11 @@ func Sync(args []string) error {
     which stack to sync. You can also pass a stack hash prefix (minimum
     3 characters) to sync a specific stack from anywhere.
 
+    If a rebase stops on a conflict, the rest of the sync is saved. Resolve
+    the conflict, run 'git rebase --continue' in that worktree, then run
+    'ezs sync --continue' to sync the remaining branches. 'ezs sync --abort'
+    resets every branch the sync already rebased to its pre-sync commit.
+
 %sEXAMPLES%s
     ezs sync              Interactive menu
     ezs sync a1b2c        Sync stack matching hash prefix
@@ -57,6 +67,8 @@ func Sync(args []string) error {
     ezs sync -c           Sync current branch only
     ezs sync -p           Rebase current onto parent
     ezs sync -C           Rebase children onto current
+    ezs sync --continue   Resume after resolving a conflict
+    ezs sync --abort      Roll back an interrupted sync
 `, ui.Bold, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset, ui.Cyan, ui.Reset)
 	}
 
@@ -69,7 +81,10 @@ func Sync(args []string) error {
 	noDeleteLocal := fs.Bool("no-delete-local", false, "Don't delete local branches after their PRs are merged")
 	dryRunFlag := fs.Bool("dry-run", false, "Preview what would be synced")
 	noAutostashFlag := fs.Bool("no-autostash", false, "Don't stash uncommitted changes before rebase")
+	jobsFlag := fs.IntP("jobs", "j", runtime.NumCPU(), "Stacks to sync at once")
 	jsonFlag := fs.Bool("json", false, "Output dry-run results as JSON")
+	continueFlag := fs.Bool("continue", false, "Resume an interrupted sync")
+	abortFlag := fs.Bool("abort", false, "Abort an interrupted sync")
 
 	if err := fs.Parse(args); err != nil {
 		if err == pflag.ErrHelp {
@@ -100,11 +115,36 @@ func Sync(args []string) error {
 	dryRun := *dryRunFlag
 	autostash := !*noAutostashFlag
 	jsonOutput := *jsonFlag
+	jobs := *jobsFlag
+
+	if jobs < 1 {
+		return fmt.Errorf("--jobs must be at least 1")
+	}
 
 	if jsonOutput && !dryRun {
 		return fmt.Errorf("--json requires --dry-run")
 	}
 
+	if *continueFlag && *abortFlag {
+		return fmt.Errorf("--continue and --abort cannot be used together")
+	}
+	if *continueFlag {
+		return syncContinue(mgr, gh)
+	}
+	if *abortFlag {
+		return syncAbort(mgr)
+	}
+
+	if !dryRun {
+		pending, err := mgr.PendingSync()
+		if err != nil {
+			return err
+		}
+		if pending != nil {
+			return fmt.Errorf("a sync is already in progress: run 'ezs sync --continue' or 'ezs sync --abort'")
+		}
+	}
+
 	// Check for positional arg (hash prefix)
 	positionalArgs := fs.Args()
 	if len(positionalArgs) > 0 {
@@ -116,7 +156,7 @@ func Sync(args []string) error {
 		if dryRun {
 			return syncDryRun(mgr, gh, []*config.Stack{targetStack}, jsonOutput)
 		}
-		return syncSpecificStacks(mgr, gh, cwd, deleteLocal, []*config.Stack{targetStack}, autostash)
+		return syncSpecificStacks(mgr, gh, cwd, deleteLocal, []*config.Stack{targetStack}, autostash, jobs)
 	}
 
 	// Try to get current stack (may fail if on main)
@@ -127,18 +167,18 @@ func Sync(args []string) error {
 			if dryRun {
 				return syncDryRunAll(mgr, gh, jsonOutput)
 			}
-			return syncStacks(mgr, gh, cwd, deleteLocal, true, autostash)
+			return syncStacks(mgr, gh, cwd, deleteLocal, true, autostash, jobs)
 		}
 		if dryRun {
 			return syncDryRunAll(mgr, gh, jsonOutput)
 		}
-		return syncFromMain(mgr, gh, cwd, deleteLocal, autostash)
+		return syncFromMain(mgr, gh, cwd, deleteLocal, autostash, jobs)
 	}
 
 	// In a stack worktree - existing behavior
 	spinner := ui.NewDelayedSpinner("Fetching branch status...")
 	spinner.Start()
-	statusMap := fetchBranchStatuses(g, currentStack, false)
+	statusMap := fetchBranchStatuses(g, []*config.Stack{currentStack}, false)
 	spinner.Stop()
 	ui.PrintStack(currentStack, branch.Name, true, statusMap)
 
@@ -150,10 +190,10 @@ func Sync(args []string) error {
 	}
 
 	if *allFlag {
-		return syncStacks(mgr, gh, cwd, deleteLocal, true, autostash)
+		return syncStacks(mgr, gh, cwd, deleteLocal, true, autostash, jobs)
 	}
 	if *stackFlag {
-		return syncStacks(mgr, gh, cwd, deleteLocal, false, autostash)
+		return syncStacks(mgr, gh, cwd, deleteLocal, false, autostash, jobs)
 	}
 	if *currentFlag {
 		return syncCurrentBranch(mgr, gh, branch, cwd, autostash)
@@ -165,35 +205,32 @@ func Sync(args []string) error {
 		return syncChildren(mgr, branch)
 	}
 
-	return syncInteractive(mgr, gh, currentStack, branch, cwd, deleteLocal, autostash)
+	return syncInteractive(mgr, gh, currentStack, branch, cwd, deleteLocal, autostash, jobs)
 }
 
 // syncDryRun previews what sync would do for specific stacks
-func syncDryRun(mgr *stack.Manager, gh *github.Client, stacks []*config.Stack, jsonOutput bool) error {
+func syncDryRun(mgr *stack.Manager, gh github.ClientInterface, stacks []*config.Stack, jsonOutput bool) error {
 	syncNeeded, err := mgr.DetectSyncNeededForStacks(gh, stacks)
 	if err != nil {
 		return err
 	}
-	if jsonOutput {
-		return printSyncInfoJSON(syncNeeded)
-	}
-	if len(syncNeeded) == 0 {
-		ui.Success("All branches are up to date. Nothing to sync.")
-		return nil
-	}
-	ui.Info("[dry-run] The following branches would be synced:")
-	printSyncInfoList(syncNeeded)
-	return nil
+	return printSyncDryRun(syncNeeded, mgr.PredictSync(stacks, syncNeeded), jsonOutput)
 }
 
 // syncDryRunAll previews what sync would do across all stacks
-func syncDryRunAll(mgr *stack.Manager, gh *github.Client, jsonOutput bool) error {
+func syncDryRunAll(mgr *stack.Manager, gh github.ClientInterface, jsonOutput bool) error {
 	syncNeeded, err := mgr.DetectSyncNeededAllStacks(gh)
 	if err != nil {
 		return err
 	}
+	return printSyncDryRun(syncNeeded, mgr.PredictSync(mgr.ListStacks(), syncNeeded), jsonOutput)
+}
+
+// printSyncDryRun prints what sync would do along with the predicted outcome
+// of each rebase
+func printSyncDryRun(syncNeeded []stack.SyncInfo, predictions []stack.RestackPrediction, jsonOutput bool) error {
 	if jsonOutput {
-		return printSyncInfoJSON(syncNeeded)
+		return printSyncInfoJSON(syncNeeded, predictions)
 	}
 	if len(syncNeeded) == 0 {
 		ui.Success("All branches are up to date. Nothing to sync.")
@@ -201,11 +238,12 @@ func syncDryRunAll(mgr *stack.Manager, gh *github.Client, jsonOutput bool) error
 	}
 	ui.Info("[dry-run] The following branches would be synced:")
 	printSyncInfoList(syncNeeded)
+	printRestackPredictions(predictions)
 	return nil
 }
 
 // syncFromMain shows an interactive menu when running sync from main (not in a stack worktree)
-func syncFromMain(mgr *stack.Manager, gh *github.Client, cwd string, deleteLocal bool, autostash bool) error {
+func syncFromMain(mgr *stack.Manager, gh github.ClientInterface, cwd string, deleteLocal bool, autostash bool, jobs int) error {
 	stacks := mgr.ListStacks()
 	if len(stacks) == 0 {
 		ui.Info("No stacks found. Create a branch first with: ezs new <branch-name>")
@@ -227,13 +265,13 @@ func syncFromMain(mgr *stack.Manager, gh *github.Client, cwd string, deleteLocal
 
 	switch selected {
 	case 0:
-		return syncStacks(mgr, gh, cwd, deleteLocal, true, autostash)
+		return syncStacks(mgr, gh, cwd, deleteLocal, true, autostash, jobs)
 	case 1:
 		targetStack, err := ui.SelectStack(stacks, "Select a stack to sync")
 		if err != nil {
 			return err
 		}
-		return syncSpecificStacks(mgr, gh, cwd, deleteLocal, []*config.Stack{targetStack}, autostash)
+		return syncSpecificStacks(mgr, gh, cwd, deleteLocal, []*config.Stack{targetStack}, autostash, jobs)
 	}
 
 	return nil
@@ -257,28 +295,93 @@ func printSyncInfoList(syncNeeded []stack.SyncInfo) {
 	fmt.Fprintln(os.Stderr)
 }
 
+// printRestackPredictions prints the predicted outcome of each rebase
+func printRestackPredictions(predictions []stack.RestackPrediction) {
+	if len(predictions) == 0 {
+		return
+	}
+	ui.Info("Predicted rebase outcome:")
+	for _, p := range predictions {
+		target := fmt.Sprintf("%s%s%s onto %s", ui.Bold, p.Branch, ui.Reset, p.Onto)
+		if p.Follows != "" {
+			target += fmt.Sprintf(" (moves with %s)", p.Follows)
+		}
+		switch {
+		case p.Clean:
+			fmt.Fprintf(os.Stderr, "  %s%s%s %s: clean\n", ui.Green, ui.IconSuccess, ui.Reset, target)
+		case len(p.Conflicts) > 0:
+			fmt.Fprintf(os.Stderr, "  %s%s%s %s: %sconflicts%s in %s (commit %.7s)\n",
+				ui.Red, ui.IconConflict, ui.Reset, target, ui.Red, ui.Reset, strings.Join(p.Conflicts, ", "), p.Commit)
+		case p.BlockedBy != "":
+			fmt.Fprintf(os.Stderr, "  %s%s%s %s: not reached until the conflict in %s is resolved\n",
+				ui.Yellow, ui.IconPending, ui.Reset, target, p.BlockedBy)
+		default:
+			fmt.Fprintf(os.Stderr, "  %s%s%s %s: can't predict: %v\n", ui.Yellow, ui.IconWarning, ui.Reset, target, p.Err)
+		}
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
 // syncInfoJSON represents a sync info entry in JSON output
 type syncInfoJSON struct {
-	Branch       string `json:"branch"`
-	NeedsSync    bool   `json:"needs_sync"`
-	MergedParent string `json:"merged_parent,omitempty"`
-	BehindParent string `json:"behind_parent,omitempty"`
-	BehindBy     int    `json:"behind_by,omitempty"`
-	StackRoot    string `json:"stack_root"`
+	Branch       string   `json:"branch"`
+	NeedsSync    bool     `json:"needs_sync"`
+	MergedParent string   `json:"merged_parent,omitempty"`
+	BehindParent string   `json:"behind_parent,omitempty"`
+	BehindBy     int      `json:"behind_by,omitempty"`
+	StackRoot    string   `json:"stack_root"`
+	MovesWith    string   `json:"moves_with,omitempty"`
+	Onto         string   `json:"onto,omitempty"`
+	Restack      string   `json:"restack,omitempty"` // clean, conflict, blocked or unknown
+	Conflicts    []string `json:"conflicts,omitempty"`
+	Commit       string   `json:"conflict_commit,omitempty"`
+	BlockedBy    string   `json:"blocked_by,omitempty"`
+}
+
+// withPrediction fills in the predicted outcome of the branch's rebase
+func (j *syncInfoJSON) withPrediction(p stack.RestackPrediction) {
+	j.Onto = p.Onto
+	switch {
+	case p.Clean:
+		j.Restack = "clean"
+	case len(p.Conflicts) > 0:
+		j.Restack, j.Conflicts, j.Commit = "conflict", p.Conflicts, p.Commit
+	case p.BlockedBy != "":
+		j.Restack, j.BlockedBy = "blocked", p.BlockedBy
+	default:
+		j.Restack = "unknown"
+	}
 }
 
-// printSyncInfoJSON outputs sync info as JSON to stdout
-func printSyncInfoJSON(syncNeeded []stack.SyncInfo) error {
+// printSyncInfoJSON outputs sync info as JSON to stdout. Branches that only
+// move because their parent is rebased are listed after the others.
+func printSyncInfoJSON(syncNeeded []stack.SyncInfo, predictions []stack.RestackPrediction) error {
+	byBranch := make(map[string]stack.RestackPrediction)
+	for _, p := range predictions {
+		byBranch[p.Branch] = p
+	}
 	result := make([]syncInfoJSON, 0, len(syncNeeded))
 	for _, info := range syncNeeded {
-		result = append(result, syncInfoJSON{
+		entry := syncInfoJSON{
 			Branch:       info.Branch,
 			NeedsSync:    info.NeedsSync,
 			MergedParent: info.MergedParent,
 			BehindParent: info.BehindParent,
 			BehindBy:     info.BehindBy,
 			StackRoot:    info.StackRoot,
-		})
+		}
+		if p, ok := byBranch[info.Branch]; ok {
+			entry.withPrediction(p)
+		}
+		result = append(result, entry)
+	}
+	for _, p := range predictions {
+		if p.Follows == "" {
+			continue
+		}
+		entry := syncInfoJSON{Branch: p.Branch, NeedsSync: true, StackRoot: p.StackRoot, MovesWith: p.Follows}
+		entry.withPrediction(p)
+		result = append(result, entry)
 	}
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
@@ -305,8 +408,12 @@ func formatSyncConfirmMsg(info stack.SyncInfo) string {
 		return fmt.Sprintf("Sync %s%s%s? (%s%d commits%s behind parent %s%s%s)",
 			ui.Bold, info.Branch, ui.Reset, ui.Yellow, info.BehindBy, ui.Reset, ui.Yellow, info.BehindParent, ui.Reset)
 	}
-	return fmt.Sprintf("Sync %s%s%s? (%s%d commits%s behind origin/%s)",
+	msg := fmt.Sprintf("Sync %s%s%s? (%s%d commits%s behind origin/%s)",
 		ui.Bold, info.Branch, ui.Reset, ui.Yellow, info.BehindBy, ui.Reset, info.StackRoot)
+	if len(info.Stacked) > 0 {
+		msg += fmt.Sprintf(" along with %s", strings.Join(info.Stacked, ", "))
+	}
+	return msg
 }
 
 // makeSyncCallbacks creates standard sync callbacks for interactive syncing.
@@ -363,6 +470,7 @@ func printSyncSummary(results []stack.RebaseResult) {
 	fmt.Fprintln(os.Stderr)
 	if hasConflicts {
 		ui.Warn("Some branches have conflicts. Resolve them and run 'git rebase --continue' in each worktree.")
+		ui.Info("Then run 'ezs sync --continue' to sync the remaining branches, or 'ezs sync --abort' to roll back.")
 	}
 	if successCount > 0 {
 		ui.Success(fmt.Sprintf("Synced %d branch(es)!", successCount))
@@ -479,7 +587,7 @@ func handleMergedBranchCleanup(mgr *stack.Manager, mergedBranches []stack.Merged
 }
 
 // syncSpecificStacks syncs a specific set of stacks
-func syncSpecificStacks(mgr *stack.Manager, gh *github.Client, cwd string, deleteLocal bool, stacks []*config.Stack, autostash bool) error {
+func syncSpecificStacks(mgr *stack.Manager, gh github.ClientInterface, cwd string, deleteLocal bool, stacks []*config.Stack, autostash bool, jobs int) error {
 	ui.Info("Fetching latest changes...")
 
 	syncNeeded, err := mgr.DetectSyncNeededForStacks(gh, stacks)
@@ -513,14 +621,20 @@ func syncSpecificStacks(mgr *stack.Manager, gh *github.Client, cwd string, delet
 	if len(syncNeeded) > 0 {
 		fmt.Fprintln(os.Stderr)
 
-		callbacks := makeSyncCallbacks(len(stacks) == 1, autostash)
-		results, err := mgr.SyncSpecificStacks(stacks, gh, callbacks)
-		if err != nil {
-			return err
-		}
+		if jobs > 1 && len(stacks) > 1 {
+			if err := syncStacksParallel(mgr, gh, stacks, syncNeeded, autostash, jobs); err != nil {
+				return err
+			}
+		} else {
+			callbacks := makeSyncCallbacks(len(stacks) == 1, autostash)
+			results, err := mgr.SyncSpecificStacks(stacks, gh, callbacks)
+			if err != nil {
+				return err
+			}
 
-		printSyncResults(results)
-		printSyncSummary(results)
+			printSyncResults(results)
+			printSyncSummary(results)
+		}
 	}
 
 	if len(mergedBranches) > 0 {
@@ -530,23 +644,171 @@ func syncSpecificStacks(mgr *stack.Manager, gh *github.Client, cwd string, delet
 	// Check for stacks that were already fully merged in cache before this sync run
 	cleanupFullyMergedStacks(mgr, stacks)
 
-	// Ensure all PR base branches and stack descriptions are correct
-	if gh != nil {
-		for _, s := range stacks {
-			if err := gh.EnsureCorrectBaseBranches(s); err != nil {
-				ui.Warn(fmt.Sprintf("Failed to update PR base branches: %v", err))
-			}
-			if err := gh.UpdateStackDescription(s, ""); err != nil {
-				ui.Warn(fmt.Sprintf("Failed to update stack descriptions: %v", err))
-			}
+	refreshStackPRs(gh, stacks)
+
+	return nil
+}
+
+// syncStacksParallel syncs several stacks, independent ones concurrently. The
+// user confirms once instead of per branch, each stack's progress is shown on
+// its own line, and pushing is offered for every rebased branch at the end.
+func syncStacksParallel(mgr *stack.Manager, gh github.ClientInterface, stacks []*config.Stack, syncNeeded []stack.SyncInfo, autostash bool, jobs int) error {
+	// Stacks are looked up before syncing since the callbacks run
+	// concurrently with sync updating them
+	stackOf := make(map[string]string)
+	for _, s := range stacks {
+		for _, b := range s.Branches {
+			stackOf[b.Name] = s.DisplayName()
+		}
+	}
+	var labels []string
+	seen := make(map[string]bool)
+	for _, info := range syncNeeded {
+		if label := stackOf[info.Branch]; label != "" && !seen[label] {
+			seen[label] = true
+			labels = append(labels, label)
+		}
+	}
+
+	if !ui.ConfirmTUI(fmt.Sprintf("Sync %d branch(es) in %d stack(s), up to %d stacks at a time", len(syncNeeded), len(labels), min(jobs, len(labels)))) {
+		ui.Warn("Cancelled")
+		return nil
+	}
+
+	progress := ui.NewProgress(labels)
+	callbacks := &stack.SyncCallbacks{
+		BeforeRebase: func(info stack.SyncInfo) bool {
+			progress.Update(stackOf[info.Branch], fmt.Sprintf("rebasing %s...", info.Branch))
+			return true
+		},
+		AfterRebase: func(result stack.RebaseResult, g *git.Git) bool {
+			progress.Update(stackOf[result.Branch], fmt.Sprintf("rebased %s", result.Branch))
+			return true
+		},
+		Autostash: autostash,
+		Parallel:  jobs,
+	}
+
+	progress.Start()
+	results, err := mgr.SyncSpecificStacks(stacks, gh, callbacks)
+	for _, label := range labels {
+		progress.Done(label, stackSyncOutcome(label, stackOf, results))
+	}
+	progress.Stop()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr)
+	printSyncResults(results)
+	printSyncSummary(results)
+
+	var rebased []string
+	for _, r := range results {
+		if r.Success {
+			rebased = append(rebased, r.Branch)
 		}
 	}
+	OfferForcePushMultiple(rebased, func(branchName string) string {
+		if b := mgr.GetBranch(branchName); b != nil {
+			return b.WorktreePath
+		}
+		return ""
+	})
+	return nil
+}
+
+// stackSyncOutcome summarizes the results for one stack's progress line
+func stackSyncOutcome(label string, stackOf map[string]string, results []stack.RebaseResult) string {
+	synced := 0
+	var problems []string
+	for _, r := range results {
+		if stackOf[r.Branch] != label {
+			continue
+		}
+		switch {
+		case r.Success:
+			synced++
+		case r.HasConflict:
+			problems = append(problems, fmt.Sprintf("%sconflict in %s%s", ui.Yellow, r.Branch, ui.Reset))
+		case r.Error != nil:
+			problems = append(problems, fmt.Sprintf("%sfailed %s%s", ui.Red, r.Branch, ui.Reset))
+		}
+	}
+	if len(problems) == 0 {
+		return fmt.Sprintf("%s%s synced %d branch(es)%s", ui.Green, ui.IconSuccess, synced, ui.Reset)
+	}
+	outcome := fmt.Sprintf("%s%s%s ", ui.Yellow, ui.IconWarning, ui.Reset)
+	if synced > 0 {
+		outcome += fmt.Sprintf("synced %d branch(es), ", synced)
+	}
+	return outcome + strings.Join(problems, ", ")
+}
 
+// refreshStackPRs ensures all PR base branches and stack descriptions are correct
+func refreshStackPRs(gh github.ClientInterface, stacks []*config.Stack) {
+	if gh == nil {
+		return
+	}
+	for _, s := range stacks {
+		if err := gh.EnsureCorrectBaseBranches(s); err != nil {
+			ui.Warn(fmt.Sprintf("Failed to update PR base branches: %v", err))
+		}
+		if err := gh.UpdateStackDescription(s, ""); err != nil {
+			ui.Warn(fmt.Sprintf("Failed to update stack descriptions: %v", err))
+		}
+	}
+}
+
+// syncContinue resumes a sync that stopped on a rebase conflict
+func syncContinue(mgr *stack.Manager, gh github.ClientInterface) error {
+	state, err := mgr.PendingSync()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("no sync in progress")
+	}
+
+	var stacks []*config.Stack
+	for _, hash := range state.Stacks {
+		if s := mgr.GetStackByHashExact(hash); s != nil {
+			stacks = append(stacks, s)
+		}
+	}
+
+	ui.Info("Continuing sync...")
+	callbacks := makeSyncCallbacks(len(stacks) == 1, state.Autostash)
+	results, err := mgr.ContinueSync(gh, callbacks)
+	if err != nil {
+		return err
+	}
+
+	printSyncResults(results)
+	printSyncSummary(results)
+	refreshStackPRs(gh, stacks)
+	return nil
+}
+
+// syncAbort abandons an interrupted sync and restores pre-sync commits
+func syncAbort(mgr *stack.Manager) error {
+	restored, err := mgr.AbortSync()
+	for _, name := range restored {
+		ui.Success(fmt.Sprintf("Restored %s to its pre-sync commit", name))
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(restored) > 0 {
+		ui.Info("Branches that were already force-pushed need to be pushed again to restore the remote.")
+	}
+	ui.Success("Sync aborted")
 	return nil
 }
 
 // syncInteractive shows an interactive menu for sync operations
-func syncInteractive(mgr *stack.Manager, gh *github.Client, currentStack *config.Stack, branch *config.Branch, cwd string, deleteLocal bool, autostash bool) error {
+func syncInteractive(mgr *stack.Manager, gh github.ClientInterface, currentStack *config.Stack, branch *config.Branch, cwd string, deleteLocal bool, autostash bool, jobs int) error {
 	options := []string{}
 	optionActions := []string{}
 
@@ -604,9 +866,9 @@ func syncInteractive(mgr *stack.Manager, gh *github.Client, currentStack *config
 	action := optionActions[selected]
 	switch action {
 	case "auto":
-		return syncStacks(mgr, gh, cwd, deleteLocal, false, autostash)
+		return syncStacks(mgr, gh, cwd, deleteLocal, false, autostash, jobs)
 	case "auto-all":
-		return syncStacks(mgr, gh, cwd, deleteLocal, true, autostash)
+		return syncStacks(mgr, gh, cwd, deleteLocal, true, autostash, jobs)
 	case "current":
 		return syncCurrentBranch(mgr, gh, branch, cwd, autostash)
 	case "parent":
@@ -619,7 +881,7 @@ func syncInteractive(mgr *stack.Manager, gh *github.Client, currentStack *config
 }
 
 // syncStacks resolves the target stacks and delegates to syncSpecificStacks.
-func syncStacks(mgr *stack.Manager, gh *github.Client, cwd string, deleteLocal bool, allStacks bool, autostash bool) error {
+func syncStacks(mgr *stack.Manager, gh github.ClientInterface, cwd string, deleteLocal bool, allStacks bool, autostash bool, jobs int) error {
 	var stacks []*config.Stack
 	if allStacks {
 		stacks = mgr.ListStacks()
@@ -634,7 +896,7 @@ func syncStacks(mgr *stack.Manager, gh *github.Client, cwd string, deleteLocal b
 		}
 		stacks = []*config.Stack{currentStack}
 	}
-	return syncSpecificStacks(mgr, gh, cwd, deleteLocal, stacks, autostash)
+	return syncSpecificStacks(mgr, gh, cwd, deleteLocal, stacks, autostash, jobs)
 }
 
 // syncOntoParent rebases the current branch onto its parent
@@ -718,7 +980,7 @@ func syncChildren(mgr *stack.Manager, branch *config.Branch) error {
 }
 
 // syncCurrentBranch syncs only the current branch (wherever it is in the chain)
-func syncCurrentBranch(mgr *stack.Manager, gh *github.Client, branch *config.Branch, cwd string, autostash bool) error {
+func syncCurrentBranch(mgr *stack.Manager, gh github.ClientInterface, branch *config.Branch, cwd string, autostash bool) error {
 	ui.Info("Fetching latest changes...")
 	g := git.New(cwd)
 	if err := g.Fetch(); err != nil {
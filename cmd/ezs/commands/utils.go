@@ -5,10 +5,12 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"text/template"
 
 	"github.com/KulkarniKaustubh/ezstack/internal/config"
 	"github.com/KulkarniKaustubh/ezstack/internal/git"
 	"github.com/KulkarniKaustubh/ezstack/internal/github"
+	"github.com/KulkarniKaustubh/ezstack/internal/gitlab"
 	"github.com/KulkarniKaustubh/ezstack/internal/stack"
 	"github.com/KulkarniKaustubh/ezstack/internal/ui"
 )
@@ -38,29 +40,27 @@ func EmitCd(path string) {
 
 // savePRToCache saves a single branch's PR number and URL to the cache.
 func savePRToCache(cacheDir, branchName string, prNum int, prURL string) {
-	cache, err := config.LoadCacheConfig(cacheDir)
-	if err != nil {
-		return
-	}
-	bc := cache.GetBranchCache(branchName)
-	if bc == nil {
-		bc = &config.BranchCache{}
-	}
-	bc.PRNumber = prNum
-	bc.PRUrl = prURL
-	cache.SetBranchCache(branchName, bc)
-	cache.Save(cacheDir)
+	config.UpdateCacheConfig(cacheDir, func(cache *config.CacheConfig) bool {
+		bc := cache.GetBranchCache(branchName)
+		if bc == nil {
+			bc = &config.BranchCache{}
+		}
+		bc.PRNumber = prNum
+		bc.PRUrl = prURL
+		cache.SetBranchCache(branchName, bc)
+		return true
+	})
 }
 
 // updateStackDescriptions updates PR descriptions for all PRs in the given stack.
-func updateStackDescriptions(gh *github.Client, s *config.Stack, activeBranch string) error {
+func updateStackDescriptions(gh github.ClientInterface, s *config.Stack, activeBranch string) error {
 	ui.Info("Updating PR stack descriptions...")
 	return gh.UpdateStackDescription(s, activeBranch)
 }
 
 // updatePRMetadata updates base branches and stack descriptions for all PRs in the stack.
 // Called after pushes and stack mutations to keep PR metadata in sync.
-func updatePRMetadata(gh *github.Client, mgr *stack.Manager, s *config.Stack, currentBranch *config.Branch) {
+func updatePRMetadata(gh github.ClientInterface, mgr *stack.Manager, s *config.Stack, currentBranch *config.Branch) {
 	// Update base branches for all PRs in the stack
 	for _, b := range s.Branches {
 		if b.PRNumber == 0 {
@@ -109,7 +109,7 @@ func OfferForcePush(branchName, worktreePath string) bool {
 	ui.Warn("Force push required to update remote branch")
 	if ui.ConfirmTUI(fmt.Sprintf("Force push %s (--force-with-lease)", branchName)) {
 		ui.Info("Pushing...")
-		if err := g.PushForce(); err != nil {
+		if err := g.PushForceBranch(branchName); err != nil {
 			ui.Error(fmt.Sprintf("Push failed: %v. Check your network connection and remote access", err))
 			return false
 		}
@@ -132,12 +132,8 @@ func OfferForcePushMultiple(branches []string, getBranchWorktree func(string) st
 
 	pushed := 0
 	for _, branchName := range branches {
-		worktreePath := getBranchWorktree(branchName)
-		if worktreePath == "" {
-			continue
-		}
-
-		g := git.New(worktreePath)
+		// Branches without a worktree are pushed by name from here
+		g := git.New(getBranchWorktree(branchName))
 		needsPush, err := g.IsLocalAheadOfOrigin(branchName)
 		if err != nil || !needsPush {
 			continue
@@ -145,7 +141,7 @@ func OfferForcePushMultiple(branches []string, getBranchWorktree func(string) st
 
 		if ui.ConfirmTUI(fmt.Sprintf("Force push %s (--force-with-lease)", branchName)) {
 			ui.Info(fmt.Sprintf("Pushing %s...", branchName))
-			if err := g.PushForce(); err != nil {
+			if err := g.PushForceBranch(branchName); err != nil {
 				ui.Error(fmt.Sprintf("Push failed for %s: %v. Check remote access or try: git push --force-with-lease", branchName, err))
 			} else {
 				ui.Success(fmt.Sprintf("Pushed %s successfully", branchName))
@@ -168,13 +164,116 @@ func getMainWorktreePath(g *git.Git) string {
 	return mainWorktree
 }
 
-// newGitHubClient creates a GitHub client from the git remote URL.
-func newGitHubClient(g *git.Git) (*github.Client, error) {
+// newGitHubClient creates the PR client for the git remote URL: GitLab when the
+// repo's provider is "gitlab" or the remote looks like GitLab, GitHub otherwise.
+func newGitHubClient(g *git.Git) (github.ClientInterface, error) {
 	remoteURL, err := g.GetRemote("origin")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get remote: %w", err)
 	}
-	return github.NewClient(remoteURL)
+	return newClientForRemote(g, remoteURL)
+}
+
+// newClientForRemote creates the PR client for remoteURL using the repo's provider settings.
+// GitHub repos use the API directly when a token is set and fall back to the gh CLI.
+func newClientForRemote(g *git.Git, remoteURL string) (github.ClientInterface, error) {
+	stackOpts := repoStackOptions(g)
+
+	provider, baseURL := repoProvider(g, remoteURL)
+	switch provider {
+	case "gitlab":
+		client, err := gitlab.NewClient(remoteURL, baseURL)
+		if err != nil {
+			return nil, err
+		}
+		client.SetStackOptions(stackOpts)
+		return client, nil
+	case "github":
+		if token := github.Token(); token != "" {
+			client, err := github.NewAPIClient(remoteURL, baseURL, token)
+			if err != nil {
+				return nil, err
+			}
+			client.SetStackOptions(stackOpts)
+			return client, nil
+		}
+		client, err := github.NewClient(remoteURL)
+		if err != nil {
+			return nil, err
+		}
+		client.SetStackOptions(stackOpts)
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unknown provider '%s' (expected github or gitlab)", provider)
+	}
+}
+
+// repoStackOptions loads the repo's stack_template and stack_comment settings.
+// A stack_template that can't be read or parsed, e.g. one deleted since it was
+// set, is reported and the default stack section is used instead.
+func repoStackOptions(g *git.Git) github.StackOptions {
+	var opts github.StackOptions
+	cfg, err := config.Load()
+	if err != nil {
+		return opts
+	}
+	repoPath := getMainWorktreePath(g)
+	opts.Comment = cfg.GetStackComment(repoPath)
+	if path := cfg.GetStackTemplate(repoPath); path != "" {
+		tmpl, err := loadStackTemplate(path)
+		if err != nil {
+			ui.Warn(fmt.Sprintf("%v; using the default stack section", err))
+		}
+		opts.Template = tmpl
+	}
+	return opts
+}
+
+// loadStackTemplate reads and parses a stack section template file
+func loadStackTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stack_template: %w", err)
+	}
+	tmpl, err := github.ParseStackTemplate(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid stack_template %s: %w", path, err)
+	}
+	return tmpl, nil
+}
+
+// repoProvider returns the repo's PR provider and its configured base URL:
+// the GitLab instance URL or the GitHub API URL.
+func repoProvider(g *git.Git, remoteURL string) (provider, baseURL string) {
+	var cfg *config.Config
+	if loaded, err := config.Load(); err == nil {
+		cfg = loaded
+		provider = cfg.GetProvider(getMainWorktreePath(g))
+	}
+	if provider == "" {
+		provider = "github"
+		if gitlab.IsGitLabURL(remoteURL) {
+			provider = "gitlab"
+		}
+	}
+	if cfg != nil {
+		repoPath := getMainWorktreePath(g)
+		if provider == "gitlab" {
+			baseURL = cfg.GetGitLabURL(repoPath)
+		} else {
+			baseURL = cfg.GetGitHubAPIURL(repoPath)
+		}
+	}
+	return provider, baseURL
+}
+
+// checkProviderAuth verifies that the repo's PR provider is authenticated.
+func checkProviderAuth(g *git.Git) error {
+	remoteURL, _ := g.GetRemote("origin")
+	if provider, _ := repoProvider(g, remoteURL); provider == "gitlab" {
+		return gitlab.CheckAuth()
+	}
+	return github.CheckAuth()
 }
 
 // selectAndRegisterRemotePR fetches open PRs, shows a selection UI,
@@ -230,221 +329,224 @@ func printRemoteBranchWarning() {
 	fmt.Fprintln(os.Stderr)
 }
 
-// discoverAndCachePRs discovers PRs from GitHub for branches that don't have PR numbers cached
-// and saves them to the config. Returns a GitHub client for further use (or nil if unavailable).
-func discoverAndCachePRs(g *git.Git, s *config.Stack, debug bool) *github.Client {
+// discoverAndCachePRs fetches the PR and CI status of every branch in the given stacks
+// with one batched request, records the PRs of branches that don't have PR numbers
+// cached and saves them to the config. If the batched request fails, the branches
+// with cached PR numbers are looked up one at a time instead. Returns a GitHub client
+// for further use (or nil if unavailable) and the statuses by branch name.
+func discoverAndCachePRs(g *git.Git, stacks []*config.Stack, debug bool) (github.ClientInterface, map[string]*github.PRStatus) {
 	remoteURL, err := g.GetRemote("origin")
 	if err != nil {
 		if debug {
 			fmt.Fprintf(os.Stderr, "[DEBUG] discoverAndCachePRs: GetRemote error: %v\n", err)
 		}
-		return nil
+		return nil, nil
 	}
 
 	if debug {
 		fmt.Fprintf(os.Stderr, "[DEBUG] discoverAndCachePRs: remoteURL=%s\n", remoteURL)
 	}
 
-	gh, err := github.NewClient(remoteURL)
+	gh, err := newClientForRemote(g, remoteURL)
 	if err != nil {
 		if debug {
 			fmt.Fprintf(os.Stderr, "[DEBUG] discoverAndCachePRs: NewClient error: %v\n", err)
 		}
-		return nil
+		return nil, nil
 	}
 
-	discoveredPRs := false
-	ghAccessWarningShown := false
-	mainWorktree := getMainWorktreePath(g)
+	var names []string
+	for _, s := range stacks {
+		for _, branch := range s.Branches {
+			names = append(names, branch.Name)
+		}
+	}
 
-	for _, branch := range s.Branches {
+	statuses, err := gh.GetPRStatuses(names)
+	if err != nil {
+		// Not a warning: it would be repeated, e.g. on every 'ezs status --watch' frame
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Checking branch %s (PRNumber=%d)\n", branch.Name, branch.PRNumber)
+			fmt.Fprintf(os.Stderr, "[DEBUG] GetPRStatuses error: %v; looking up known PRs one at a time\n", err)
 		}
+		return gh, fetchCachedPRStatuses(gh, stacks)
+	}
 
-		if branch.PRNumber == 0 {
-			pr, err := gh.GetPRByBranch(branch.Name)
-			if err != nil {
-				if debug {
-					fmt.Fprintf(os.Stderr, "[DEBUG] GetPRByBranch(%s) error: %v\n", branch.Name, err)
-				}
-				if !ghAccessWarningShown {
-					errStr := err.Error()
-					if strings.Contains(errStr, "cannot access repository") ||
-						strings.Contains(errStr, "authentication") {
-						ui.Warn(errStr)
-						ghAccessWarningShown = true
-					}
-				}
-				continue
+	discoveredPRs := false
+	for _, s := range stacks {
+		for _, branch := range s.Branches {
+			if debug {
+				fmt.Fprintf(os.Stderr, "[DEBUG] Checking branch %s (PRNumber=%d)\n", branch.Name, branch.PRNumber)
 			}
-			if pr != nil {
+			status := statuses[branch.Name]
+			if branch.PRNumber == 0 && status != nil {
 				if debug {
-					fmt.Fprintf(os.Stderr, "[DEBUG] Found PR #%d for branch %s\n", pr.Number, branch.Name)
+					fmt.Fprintf(os.Stderr, "[DEBUG] Found PR #%d for branch %s\n", status.PR.Number, branch.Name)
 				}
-				branch.PRNumber = pr.Number
-				branch.PRUrl = pr.URL
+				branch.PRNumber = status.PR.Number
+				branch.PRUrl = status.PR.URL
 				discoveredPRs = true
 			}
 		}
 	}
 
 	if discoveredPRs {
-		cache, _ := config.LoadCacheConfig(mainWorktree)
-		for _, branch := range s.Branches {
-			if branch.PRNumber > 0 {
-				bc := cache.GetBranchCache(branch.Name)
-				if bc == nil {
-					bc = &config.BranchCache{}
+		config.UpdateCacheConfig(getMainWorktreePath(g), func(cache *config.CacheConfig) bool {
+			for _, s := range stacks {
+				for _, branch := range s.Branches {
+					if branch.PRNumber > 0 {
+						bc := cache.GetBranchCache(branch.Name)
+						if bc == nil {
+							bc = &config.BranchCache{}
+						}
+						bc.PRNumber = branch.PRNumber
+						bc.PRUrl = branch.PRUrl
+						cache.SetBranchCache(branch.Name, bc)
+					}
 				}
-				bc.PRNumber = branch.PRNumber
-				bc.PRUrl = branch.PRUrl
-				cache.SetBranchCache(branch.Name, bc)
 			}
-		}
-		cache.Save(mainWorktree)
+			return true
+		})
 	}
 
-	return gh
+	return gh, statuses
+}
+
+// fetchCachedPRStatuses looks up the PR and CI status of each branch with a
+// cached PR number PR by PR, for when the batched request fails. Branches
+// whose PR can't be fetched are left out.
+func fetchCachedPRStatuses(gh github.ClientInterface, stacks []*config.Stack) map[string]*github.PRStatus {
+	statuses := make(map[string]*github.PRStatus)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	// Semaphore to limit concurrent gh CLI calls
+	sem := make(chan struct{}, 10)
+
+	for _, s := range stacks {
+		for _, branch := range s.Branches {
+			if branch.PRNumber == 0 {
+				continue
+			}
+			wg.Add(1)
+			go func(b *config.Branch) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				pr, err := gh.GetPR(b.PRNumber)
+				if err != nil {
+					return
+				}
+				checks, _ := gh.GetPRChecks(b.PRNumber)
+				mu.Lock()
+				statuses[b.Name] = &github.PRStatus{PR: pr, Checks: checks}
+				mu.Unlock()
+			}(branch)
+		}
+	}
+	wg.Wait()
+	return statuses
 }
 
-// fetchBranchStatuses fetches PR and CI status for all branches in a stack (used by ezs status)
-// Also caches merged status to the config when detected
-func fetchBranchStatuses(g *git.Git, s *config.Stack, debug bool) map[string]*ui.BranchStatus {
+// fetchBranchStatuses fetches PR and CI status for all branches in the given stacks
+// (used by ezs status) in one batched request. Also caches merged status to the config
+// when detected. The returned map is keyed by branch name.
+func fetchBranchStatuses(g *git.Git, stacks []*config.Stack, debug bool) map[string]*ui.BranchStatus {
 	statusMap := make(map[string]*ui.BranchStatus)
 
 	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] fetchBranchStatuses for stack %s with %d branches\n", s.Hash, len(s.Branches))
+		fmt.Fprintf(os.Stderr, "[DEBUG] fetchBranchStatuses for %d stack(s)\n", len(stacks))
 	}
 
-	gh := discoverAndCachePRs(g, s, debug)
-	if gh == nil {
+	gh, prStatuses := discoverAndCachePRs(g, stacks, debug)
+	if gh == nil || prStatuses == nil {
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] gh client is nil, returning empty statusMap\n")
+			fmt.Fprintf(os.Stderr, "[DEBUG] no PR statuses available, returning empty statusMap\n")
 		}
 		return statusMap
 	}
 
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-
-	// Semaphore to limit concurrent gh CLI calls
-	sem := make(chan struct{}, 10)
-
-	for _, branch := range s.Branches {
-		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] branch %s PRNumber=%d\n", branch.Name, branch.PRNumber)
-		}
-		if branch.PRNumber == 0 {
-			continue
-		}
+	for _, s := range stacks {
+		for _, b := range s.Branches {
+			if debug {
+				fmt.Fprintf(os.Stderr, "[DEBUG] branch %s PRNumber=%d\n", b.Name, b.PRNumber)
+			}
+			if b.PRNumber == 0 {
+				continue
+			}
 
-		wg.Add(1)
-		go func(b *config.Branch) {
-			defer wg.Done()
+			prStatus := prStatuses[b.Name]
+			if prStatus == nil || prStatus.PR.Number != b.PRNumber {
+				// The cached PR is not the newest one for this head branch; look it up directly
+				pr, err := gh.GetPR(b.PRNumber)
+				if err != nil {
+					continue
+				}
+				checks, _ := gh.GetPRChecks(b.PRNumber)
+				prStatus = &github.PRStatus{PR: pr, Checks: checks}
+			}
 
 			status := &ui.BranchStatus{}
-
-			// Fetch PR and checks in parallel for this branch
-			var prData *github.PR
-			var checksData *github.CheckStatus
-			var prErr, checksErr error
-			var innerWg sync.WaitGroup
-
-			innerWg.Add(2)
-
-			// Fetch PR details
-			go func() {
-				defer innerWg.Done()
-				sem <- struct{}{}        // Acquire semaphore
-				defer func() { <-sem }() // Release semaphore
-				prData, prErr = gh.GetPR(b.PRNumber)
-			}()
-
-			// Fetch PR checks
-			go func() {
-				defer innerWg.Done()
-				sem <- struct{}{}        // Acquire semaphore
-				defer func() { <-sem }() // Release semaphore
-				checksData, checksErr = gh.GetPRChecks(b.PRNumber)
-			}()
-
-			innerWg.Wait()
-
-			// Process PR data
-			if prErr == nil {
-				if prData.Merged {
-					status.PRState = "MERGED"
-					// Cache merged status if not already set; the read of b.IsMerged
-					// must be inside the lock to avoid a data race with other goroutines.
-					mu.Lock()
-					if !b.IsMerged {
-						b.IsMerged = true
-						if debug {
-							fmt.Fprintf(os.Stderr, "[DEBUG] Marking branch %s as merged\n", b.Name)
-						}
+			prData := prStatus.PR
+			if prData.Merged {
+				status.PRState = "MERGED"
+				if !b.IsMerged {
+					b.IsMerged = true
+					if debug {
+						fmt.Fprintf(os.Stderr, "[DEBUG] Marking branch %s as merged\n", b.Name)
 					}
-					mu.Unlock()
-				} else if prData.State == "CLOSED" {
-					status.PRState = "CLOSED"
-				} else if prData.IsDraft {
-					status.PRState = "DRAFT"
-				} else {
-					status.PRState = "OPEN"
 				}
-				status.Mergeable = prData.Mergeable
-				status.ReviewState = prData.ReviewState
-
-				// Cache PR state on the branch for ezs ls
-				mu.Lock()
-				b.PRState = status.PRState
-				mu.Unlock()
+			} else if prData.State == "CLOSED" {
+				status.PRState = "CLOSED"
+			} else if prData.IsDraft {
+				status.PRState = "DRAFT"
+			} else {
+				status.PRState = "OPEN"
 			}
+			status.Mergeable = prData.Mergeable
+			status.ReviewState = prData.ReviewState
+
+			// Cache PR state on the branch for ezs ls
+			b.PRState = status.PRState
 
-			// Process checks data
-			if checksErr == nil && checksData != nil {
+			if checks := prStatus.Checks; checks != nil {
 				if debug {
-					fmt.Fprintf(os.Stderr, "[DEBUG] GetPRChecks(%d): state=%s summary=%s\n", b.PRNumber, checksData.State, checksData.Summary)
+					fmt.Fprintf(os.Stderr, "[DEBUG] checks for #%d: state=%s summary=%s\n", b.PRNumber, checks.State, checks.Summary)
 				}
-				status.CIState = checksData.State
-				status.CISummary = checksData.Summary
+				status.CIState = checks.State
+				status.CISummary = checks.Summary
 			}
 
-			mu.Lock()
 			statusMap[b.Name] = status
-			mu.Unlock()
-		}(branch)
+		}
 	}
 
-	wg.Wait()
-
 	// Save cached PR state for all branches with PR data
 	mainWorktree, err := g.GetMainWorktree()
 	if err == nil {
-		cache, err := config.LoadCacheConfig(mainWorktree)
-		if err == nil {
+		config.UpdateCacheConfig(mainWorktree, func(cache *config.CacheConfig) bool {
 			changed := false
-			for _, branch := range s.Branches {
-				if branch.PRState == "" {
-					continue
-				}
-				bc := cache.GetBranchCache(branch.Name)
-				if bc == nil {
-					bc = &config.BranchCache{}
-				}
-				if bc.PRState != branch.PRState || (branch.IsMerged && !bc.IsMerged) {
-					bc.PRState = branch.PRState
-					if branch.IsMerged {
-						bc.IsMerged = true
+			for _, s := range stacks {
+				for _, branch := range s.Branches {
+					if branch.PRState == "" {
+						continue
+					}
+					bc := cache.GetBranchCache(branch.Name)
+					if bc == nil {
+						bc = &config.BranchCache{}
+					}
+					if bc.PRState != branch.PRState || (branch.IsMerged && !bc.IsMerged) {
+						bc.PRState = branch.PRState
+						if branch.IsMerged {
+							bc.IsMerged = true
+						}
+						cache.SetBranchCache(branch.Name, bc)
+						changed = true
 					}
-					cache.SetBranchCache(branch.Name, bc)
-					changed = true
 				}
 			}
-			if changed {
-				cache.Save(mainWorktree)
-			}
-		}
+			return changed
+		})
 	}
 
 	return statusMap
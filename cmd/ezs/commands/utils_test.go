@@ -1,6 +1,12 @@
 package commands
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/github"
+)
 
 func TestShellQuote(t *testing.T) {
 	tests := []struct {
@@ -27,3 +33,42 @@ func TestShellQuote(t *testing.T) {
 		})
 	}
 }
+
+// fakeStatusClient serves single PRs from memory. Methods the status
+// fallback does not use are left to the nil embedded interface.
+type fakeStatusClient struct {
+	github.ClientInterface
+	prs map[int]*github.PR
+}
+
+func (f *fakeStatusClient) GetPR(number int) (*github.PR, error) {
+	pr, ok := f.prs[number]
+	if !ok {
+		return nil, fmt.Errorf("no PR #%d", number)
+	}
+	return pr, nil
+}
+
+func (f *fakeStatusClient) GetPRChecks(number int) (*github.CheckStatus, error) {
+	return &github.CheckStatus{State: "SUCCESS"}, nil
+}
+
+func TestFetchCachedPRStatuses(t *testing.T) {
+	gh := &fakeStatusClient{prs: map[int]*github.PR{
+		1: {Number: 1, State: "OPEN"},
+	}}
+	stacks := []*config.Stack{{Branches: []*config.Branch{
+		{Name: "feature-a", PRNumber: 1},
+		{Name: "feature-b", PRNumber: 2}, // PR can't be fetched
+		{Name: "feature-c"},              // no cached PR
+	}}}
+
+	statuses := fetchCachedPRStatuses(gh, stacks)
+	if len(statuses) != 1 {
+		t.Fatalf("got statuses for %d branches, want 1: %v", len(statuses), statuses)
+	}
+	status := statuses["feature-a"]
+	if status == nil || status.PR.Number != 1 || status.Checks == nil || status.Checks.State != "SUCCESS" {
+		t.Errorf("feature-a status = %+v, want PR #1 with its checks", status)
+	}
+}
@@ -113,13 +113,13 @@ func main() {
 	var err error
 	switch cmd {
 	case "new", "n":
-		err = commands.New(args)
+		err = commands.RecordOperation("new", args, commands.New)
 	case "list", "ls":
 		err = commands.List(args)
 	case "status", "st":
 		err = commands.Status(args)
 	case "sync", "rebase", "rb":
-		err = commands.Sync(args)
+		err = commands.RecordOperation("sync", args, commands.Sync)
 	case "pr":
 		err = commands.PR(args)
 	case "config", "cfg":
@@ -127,25 +127,39 @@ func main() {
 	case "goto", "go":
 		err = commands.Goto(args)
 	case "delete", "del", "rm":
-		err = commands.Delete(args)
+		err = commands.RecordOperation("delete", args, commands.Delete)
 	case "reparent", "rp":
-		err = commands.Reparent(args)
+		err = commands.RecordOperation("reparent", args, commands.Reparent)
+	case "absorb":
+		err = commands.RecordOperation("absorb", args, commands.Absorb)
+	case "fold":
+		err = commands.RecordOperation("fold", args, commands.Fold)
+	case "split":
+		err = commands.RecordOperation("split", args, commands.Split)
 	case "stack":
-		err = commands.Stack(args)
+		err = commands.RecordOperation("stack", args, commands.Stack)
 	case "unstack":
-		err = commands.Unstack(args)
+		err = commands.RecordOperation("unstack", args, commands.Unstack)
 	case "commit", "ci":
-		err = commands.Commit(args)
+		err = commands.RecordOperation("commit", args, commands.Commit)
 	case "amend":
-		err = commands.Amend(args)
+		err = commands.RecordOperation("amend", args, commands.Amend)
+	case "undo":
+		err = commands.Undo(args)
+	case "oplog":
+		err = commands.Oplog(args)
 	case "diff":
 		err = commands.Diff(args)
+	case "submit":
+		err = commands.RecordOperation("submit", args, commands.Submit)
 	case "push":
 		err = commands.Push(args)
 	case "up":
 		err = commands.Up(args)
 	case "down":
 		err = commands.Down(args)
+	case "ui":
+		err = commands.Dashboard(args)
 	case "menu":
 		err = runInteractiveMenu()
 	default:
@@ -188,23 +202,23 @@ func runInteractiveMenu() error {
 		var cmdErr error
 		switch selected {
 		case 0:
-			cmdErr = commands.New(nil)
+			cmdErr = commands.RecordOperation("new", nil, commands.New)
 		case 1:
 			cmdErr = commands.Status(nil)
 		case 2:
-			cmdErr = commands.Sync(nil)
+			cmdErr = commands.RecordOperation("sync", nil, commands.Sync)
 		case 3:
 			cmdErr = commands.PR(nil)
 		case 4:
 			cmdErr = commands.Goto(nil)
 		case 5:
-			cmdErr = commands.Reparent(nil)
+			cmdErr = commands.RecordOperation("reparent", nil, commands.Reparent)
 		case 6:
-			cmdErr = commands.Stack(nil)
+			cmdErr = commands.RecordOperation("stack", nil, commands.Stack)
 		case 7:
-			cmdErr = commands.Unstack(nil)
+			cmdErr = commands.RecordOperation("unstack", nil, commands.Unstack)
 		case 8:
-			cmdErr = commands.Delete(nil)
+			cmdErr = commands.RecordOperation("delete", nil, commands.Delete)
 		case 9:
 			cmdErr = commands.Config(nil)
 		case 10:
@@ -237,6 +251,9 @@ func printUsage() {
     up            Navigate up the stack (toward parent)
     down          Navigate down the stack (toward children)
     reparent, rp  Change the parent of a branch
+    split         Split a branch into stacked branches by commit
+    fold          Fold a branch into its parent
+    absorb        Absorb staged fixups into the stack commits they fix
     stack         Add a branch to a stack
     unstack       Remove a branch from tracking (keeps git branch)
     delete, del, rm  Delete a branch and its worktree
@@ -244,8 +261,12 @@ func printUsage() {
     amend         Amend last commit and auto-sync children
     diff          Show diff against parent branch
     push          Push current branch or entire stack
+    submit        Push the stack and create or update all its PRs
+    undo          Undo the last stack operation(s)
+    oplog         Show the operation log
     pr            Manage pull requests
     config        Configure ezstack
+    ui            Full-screen stack dashboard
     menu          Interactive command menu
 
 %sOPTIONS%s
@@ -271,8 +292,8 @@ func printUsage() {
     %s# Sync a specific stack by hash prefix (min 3 chars)%s
     ezs sync a1b2c
 
-    %s# Create PRs for the stack%s
-    ezs pr create -t "Part 1: Add feature"
+    %s# Push the stack and create its PRs%s
+    ezs submit
 
     %s# Navigate between worktrees%s
     ezs goto feature-part2
@@ -287,7 +308,7 @@ func printShellInit() {
 # Add this to your shell config: eval "$(ezs --shell-init)"
 ezs() {
     case "${1:-}" in
-        goto|go|new|n|delete|del|rm|sync|rebase|rb|up|down)
+        goto|go|new|n|delete|del|rm|fold|sync|rebase|rb|up|down|ui)
             # These commands may output "cd <path>" which we need to eval
             eval "$(EZS_SHELL_WRAPPER=1 command ezs "$@")"
             ;;
@@ -315,8 +336,8 @@ fi
 
 var topLevelCommands = []string{
 	"new", "list", "status", "sync", "goto", "up", "down",
-	"reparent", "stack", "unstack", "delete", "commit", "amend",
-	"diff", "push", "pr", "config", "menu",
+	"reparent", "split", "fold", "absorb", "stack", "unstack", "delete", "commit", "amend",
+	"diff", "push", "submit", "undo", "oplog", "pr", "config", "ui", "menu",
 }
 
 var prSubcommands = []string{"create", "update", "merge", "draft", "stack"}
@@ -14,15 +14,16 @@ import (
 	"github.com/spf13/viper"
 )
 
-// PRNumberFromURL extracts the PR number from a GitHub PR URL.
-// e.g. "https://github.com/owner/repo/pull/123" → 123
+// PRNumberFromURL extracts the PR number from a GitHub PR URL or a GitLab MR URL.
+// e.g. "https://github.com/owner/repo/pull/123" → 123,
+// "https://gitlab.com/group/repo/-/merge_requests/7" → 7
 // Returns 0 if the URL is empty or unparseable.
 func PRNumberFromURL(url string) int {
 	if url == "" {
 		return 0
 	}
 	parts := strings.Split(strings.TrimRight(url, "/"), "/")
-	if len(parts) < 2 || parts[len(parts)-2] != "pull" {
+	if len(parts) < 2 || (parts[len(parts)-2] != "pull" && parts[len(parts)-2] != "merge_requests") {
 		return 0
 	}
 	n, err := strconv.Atoi(parts[len(parts)-1])
@@ -46,6 +47,7 @@ func init() {
 type Config struct {
 	DefaultBaseBranch string                 `json:"default_base_branch"`
 	GitHubToken       string                 `json:"github_token,omitempty"`
+	GitLabToken       string                 `json:"gitlab_token,omitempty"`
 	Repos             map[string]*RepoConfig `json:"repos"`
 }
 
@@ -57,6 +59,12 @@ type RepoConfig struct {
 	CdAfterNew          *bool  `json:"cd_after_new,omitempty"`
 	UseWorktrees        *bool  `json:"use_worktrees,omitempty"`
 	AutoDraftWipCommits *bool  `json:"auto_draft_wip_commits,omitempty"`
+	Provider            string `json:"provider,omitempty"`         // "github" or "gitlab"; detected from the remote when empty
+	GitLabURL           string `json:"gitlab_url,omitempty"`       // GitLab instance URL when it differs from the remote host
+	GitHubAPIURL        string `json:"github_api_url,omitempty"`   // GitHub API base URL, e.g. for GitHub Enterprise
+	StackTemplate       string `json:"stack_template,omitempty"`   // text/template file replacing the PR stack section
+	StackComment        *bool  `json:"stack_comment,omitempty"`    // keep the stack section in a PR comment instead of the body
+	InMemoryRebase      *bool  `json:"in_memory_rebase,omitempty"` // rebase without checking branches out when there are no conflicts
 }
 
 // GetRepoConfig returns the configuration for a specific repo path
@@ -111,6 +119,62 @@ func (c *Config) GetUseWorktrees(repoPath string) bool {
 	return true
 }
 
+// GetProvider returns the configured PR provider for a repo ("github",
+// "gitlab"), or empty to detect it from the remote URL
+func (c *Config) GetProvider(repoPath string) string {
+	if repoCfg := c.GetRepoConfig(repoPath); repoCfg != nil {
+		return repoCfg.Provider
+	}
+	return ""
+}
+
+// GetGitLabURL returns the GitLab instance URL configured for a repo, if any
+func (c *Config) GetGitLabURL(repoPath string) string {
+	if repoCfg := c.GetRepoConfig(repoPath); repoCfg != nil {
+		return repoCfg.GitLabURL
+	}
+	return ""
+}
+
+// GetGitHubAPIURL returns the GitHub API base URL configured for a repo, if any
+func (c *Config) GetGitHubAPIURL(repoPath string) string {
+	if repoCfg := c.GetRepoConfig(repoPath); repoCfg != nil {
+		return repoCfg.GitHubAPIURL
+	}
+	return ""
+}
+
+// GetStackTemplate returns the path of the PR stack section template
+// configured for a repo, if any. Relative paths are resolved against the repo.
+func (c *Config) GetStackTemplate(repoPath string) string {
+	repoCfg := c.GetRepoConfig(repoPath)
+	if repoCfg == nil || repoCfg.StackTemplate == "" {
+		return ""
+	}
+	if filepath.IsAbs(repoCfg.StackTemplate) {
+		return repoCfg.StackTemplate
+	}
+	return filepath.Join(repoPath, repoCfg.StackTemplate)
+}
+
+// GetStackComment returns whether a repo keeps its stack section in a PR
+// comment rather than the PR body (default: false)
+func (c *Config) GetStackComment(repoPath string) bool {
+	if repoCfg := c.GetRepoConfig(repoPath); repoCfg != nil && repoCfg.StackComment != nil {
+		return *repoCfg.StackComment
+	}
+	return false
+}
+
+// GetInMemoryRebase returns whether sync rebases branches without checking
+// them out, falling back to a worktree rebase on conflicts (default: false)
+func (c *Config) GetInMemoryRebase(repoPath string) bool {
+	if repoCfg := c.GetRepoConfig(repoPath); repoCfg != nil && repoCfg.InMemoryRebase != nil {
+		return *repoCfg.InMemoryRebase
+	}
+	return false
+}
+
 // BranchTree is a recursive map representing the stack hierarchy
 // Each key is a branch name, and its value is another BranchTree of its children
 type BranchTree map[string]BranchTree
@@ -119,11 +183,14 @@ type BranchTree map[string]BranchTree
 type repoData struct {
 	Stacks   map[string]*Stack       `json:"stacks"`
 	Branches map[string]*BranchCache `json:"branches"`
+	Revision int                     `json:"revision,omitempty"` // bumped on every write
+
+	StacksRevision int `json:"stacks_revision,omitempty"` // bumped when the stacks are saved
 }
 
 // currentStackConfigVersion is the latest version of the stacks.json format.
 // Bump this when adding a new migration.
-const currentStackConfigVersion = 5
+const currentStackConfigVersion = 6
 
 // stackConfigFile is the on-disk format that stores stacks for all repos
 type stackConfigFile struct {
@@ -133,9 +200,13 @@ type stackConfigFile struct {
 
 // StackConfig holds metadata about stacks for a single repo
 type StackConfig struct {
-	Stacks  map[string]*Stack `json:"stacks"`
-	Cache   *CacheConfig      `json:"-"` // loaded alongside stacks, not serialized separately
-	repoDir string            // internal, not serialized - used for saving
+	Stacks   map[string]*Stack `json:"stacks"`
+	Cache    *CacheConfig      `json:"-"` // loaded alongside stacks, not serialized separately
+	repoDir  string            // internal, not serialized - used for saving
+
+	revision       int                    // repo revision this config was loaded at
+	stacksRevision int                    // stacks revision this config was loaded at, checked on save
+	loadedBranches map[string]BranchCache // branch cache as loaded, to merge with later cache updates on save
 }
 
 // Stack represents a chain of stacked branches as a tree
@@ -281,6 +352,7 @@ func migrateStackConfig(data []byte, srcVersion, dstVersion int) ([]byte, error)
 		migrateV2ToV3,
 		migrateV3ToV4,
 		migrateV4ToV5,
+		migrateV5ToV6,
 	}
 
 	for v := srcVersion; v < dstVersion; v++ {
@@ -678,6 +750,35 @@ func migrateV4ToV5(data []byte) ([]byte, error) {
 	return json.MarshalIndent(file, "", "  ")
 }
 
+// migrateV5ToV6 moves each repo's stacks and branch cache out of the global
+// stacks.json into $GIT_COMMON_DIR/ezstack/stacks.json, shared by all of the
+// repo's worktrees, along with its operation log, sync state and merge train.
+// Repos whose checkout can't be found stay in the global file until they are loaded from their path again or relocated with
+// 'ezs config relocate'.
+func migrateV5ToV6(data []byte) ([]byte, error) {
+	var file stackConfigFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	for repoPath, rd := range file.Repos {
+		for _, f := range []repoStateFile{opLogs, syncStates, mergeTrains} {
+			if err := f.adopt(repoPath); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to move %s for %s: %v\n", f.name, repoPath, err)
+			}
+		}
+		moved, err := moveIntoRepoStore(repoPath, rd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to move stacks for %s: %v\n", repoPath, err)
+			continue
+		}
+		if moved {
+			delete(file.Repos, repoPath)
+		}
+	}
+	file.Version = 6
+	return json.MarshalIndent(file, "", "  ")
+}
+
 // Load loads the configuration from ~/.ezstack/config.json.
 // Top-level scalar values are resolved through Viper so that EZSTACK_-prefixed
 // environment variables (e.g. EZSTACK_GITHUB_TOKEN) take precedence over the file.
@@ -703,6 +804,7 @@ func Load() (*Config, error) {
 	cfg := &Config{
 		DefaultBaseBranch: v.GetString("default_base_branch"),
 		GitHubToken:       v.GetString("github_token"),
+		GitLabToken:       v.GetString("gitlab_token"),
 		Repos:             make(map[string]*RepoConfig),
 	}
 
@@ -731,69 +833,134 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
-// Save saves the configuration to ~/.ezstack/config.json
+// Save saves the configuration to ~/.ezstack/config.json. Repos registered
+// by another ezs process since this config was loaded are kept.
 func (c *Config) Save() error {
 	configDir, err := ConfigDir()
 	if err != nil {
 		return err
 	}
 
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return err
-	}
+	configPath := filepath.Join(configDir, "config.json")
+	return withFileLock(configPath, func() error {
+		if data, err := os.ReadFile(configPath); err == nil {
+			var onDisk struct {
+				Repos map[string]*RepoConfig `json:"repos"`
+			}
+			if json.Unmarshal(data, &onDisk) == nil {
+				for path, rc := range onDisk.Repos {
+					if _, ok := c.Repos[path]; !ok {
+						if c.Repos == nil {
+							c.Repos = make(map[string]*RepoConfig)
+						}
+						c.Repos[path] = rc
+					}
+				}
+			}
+		}
 
-	data, err := json.MarshalIndent(c, "", "  ")
+		data, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			return err
+		}
+		return atomicWriteFile(configPath, data, 0644)
+	})
+}
+
+// LoadStackConfig loads stack metadata and branch cache for a specific repo.
+// Repos keep them in $GIT_COMMON_DIR/ezstack/stacks.json; older data in
+// $HOME/.ezstack/stacks.json is brought up to date through the versioned
+// migration chain and moved there first.
+func LoadStackConfig(repoDir string) (*StackConfig, error) {
+	store, err := openStackStore(repoDir)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	rd, err := store.read()
+	if err != nil {
+		return nil, err
+	}
+	if rd == nil {
+		rd = &repoData{}
+	}
+	if rd.Stacks == nil {
+		rd.Stacks = make(map[string]*Stack)
+	}
+	if rd.Branches == nil {
+		rd.Branches = make(map[string]*BranchCache)
+	}
+
+	sc := &StackConfig{
+		Stacks: rd.Stacks,
+		Cache: &CacheConfig{
+			Branches: rd.Branches,
+			repoDir:  repoDir,
+		},
+		repoDir:        repoDir,
+		revision:       rd.Revision,
+		stacksRevision: rd.StacksRevision,
+		loadedBranches: copyBranches(rd.Branches),
 	}
 
-	return atomicWriteFile(filepath.Join(configDir, "config.json"), data, 0644)
+	for hash, stack := range sc.Stacks {
+		stack.Hash = hash
+		stack.cache = sc.Cache
+		stack.RootPRNumber = PRNumberFromURL(stack.RootPRUrl)
+		stack.PopulateBranches()
+	}
+
+	return sc, nil
 }
 
-// LoadStackConfig loads stack metadata and branch cache for a specific repo from $HOME/.ezstack/stacks.json
-// It handles migration from older formats using a versioned migration chain.
-func LoadStackConfig(repoDir string) (*StackConfig, error) {
+// openStackStore migrates the global stacks.json if needed, moves the repo's
+// entry out of it if the repo now has a store of its own, and returns the
+// repo's store.
+func openStackStore(repoDir string) (stackStore, error) {
 	configDir, err := ConfigDir()
 	if err != nil {
 		return nil, err
 	}
 
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	stackPath := filepath.Join(configDir, "stacks.json")
+	err = withFileLock(stackPath, func() error {
+		file, err := loadStackConfigFile(stackPath)
+		if err != nil {
+			return err
+		}
+		adopted, err := adoptGlobalEntry(file, repoDir)
+		if err != nil || !adopted {
+			return err
+		}
+		return writeJSON(stackPath, file)
+	})
+	if err != nil {
 		return nil, err
 	}
+	return stackStoreFor(repoDir)
+}
 
-	stackPath := filepath.Join(configDir, "stacks.json")
+// loadStackConfigFile reads the global stacks.json and migrates it to the
+// current version; the caller holds the stacks.json lock
+func loadStackConfigFile(stackPath string) (*stackConfigFile, error) {
 	data, err := os.ReadFile(stackPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			// No stacks.json yet — bootstrap from cache.json if present
-			// by running migration from v1→v2 on an empty v1 file
-			emptyV1 := stackConfigFile{Version: 1, Repos: make(map[string]*repoData)}
-			emptyData, _ := json.MarshalIndent(emptyV1, "", "  ")
-			migratedData, migErr := migrateStackConfig(emptyData, 1, currentStackConfigVersion)
-			if migErr == nil {
-				var check stackConfigFile
-				if json.Unmarshal(migratedData, &check) == nil && len(check.Repos) > 0 {
-					if err := atomicWriteFile(stackPath, migratedData, 0644); err != nil {
-						fmt.Fprintf(os.Stderr, "Warning: failed to persist bootstrap migration: %v\n", err)
-					}
-					data = migratedData
-				}
-			}
-
-			if data == nil {
-				return &StackConfig{
-					Stacks: make(map[string]*Stack),
-					Cache: &CacheConfig{
-						Branches: make(map[string]*BranchCache),
-						repoDir:  repoDir,
-					},
-					repoDir: repoDir,
-				}, nil
-			}
-		} else {
+		if !os.IsNotExist(err) {
 			return nil, err
 		}
+		// No stacks.json yet — bootstrap from cache.json if present
+		// by running migration from v1 on an empty v1 file. The result is
+		// persisted even when empty so repos moved into their own store
+		// aren't bootstrapped again.
+		emptyV1 := stackConfigFile{Version: 1, Repos: make(map[string]*repoData)}
+		emptyData, _ := json.MarshalIndent(emptyV1, "", "  ")
+		migratedData, migErr := migrateStackConfig(emptyData, 1, currentStackConfigVersion)
+		if migErr != nil {
+			return &stackConfigFile{Version: currentStackConfigVersion, Repos: make(map[string]*repoData)}, nil
+		}
+		if err := atomicWriteFile(stackPath, migratedData, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to persist bootstrap migration: %v\n", err)
+		}
+		data = migratedData
 	}
 
 	var versionCheck struct {
@@ -817,42 +984,10 @@ func LoadStackConfig(repoDir string) (*StackConfig, error) {
 	if err := json.Unmarshal(data, &file); err != nil {
 		return nil, err
 	}
-
 	if file.Repos == nil {
 		file.Repos = make(map[string]*repoData)
 	}
-
-	rd := file.Repos[repoDir]
-	if rd == nil {
-		rd = &repoData{
-			Stacks:   make(map[string]*Stack),
-			Branches: make(map[string]*BranchCache),
-		}
-	}
-	if rd.Stacks == nil {
-		rd.Stacks = make(map[string]*Stack)
-	}
-	if rd.Branches == nil {
-		rd.Branches = make(map[string]*BranchCache)
-	}
-
-	sc := &StackConfig{
-		Stacks: rd.Stacks,
-		Cache: &CacheConfig{
-			Branches: rd.Branches,
-			repoDir:  repoDir,
-		},
-		repoDir: repoDir,
-	}
-
-	for hash, stack := range sc.Stacks {
-		stack.Hash = hash
-		stack.cache = sc.Cache
-		stack.RootPRNumber = PRNumberFromURL(stack.RootPRUrl)
-		stack.PopulateBranches()
-	}
-
-	return sc, nil
+	return &file, nil
 }
 
 // IsFullyMerged returns true if every branch in the stack is marked as merged
@@ -886,86 +1021,146 @@ func (s *Stack) PopulateBranchesWithCache(cache *CacheConfig) {
 	s.Branches = s.GetBranches(cache)
 }
 
-// Save saves the stack config and cache for this repo to $HOME/.ezstack/stacks.json
+// Save saves the stack config and cache for this repo to its stack store.
+// It fails with ErrStaleConfig if another command saved this repo's stacks
+// after this config was loaded, rather than overwriting the newer data. If
+// only the branch cache was updated since, e.g. with PR data fetched by
+// status, the branch entries this config didn't change keep those updates.
 func (sc *StackConfig) Save(repoDir string) error {
-	configDir, err := ConfigDir()
-	if err != nil {
-		return err
+	targetRepo := sc.repoDir
+	if targetRepo == "" {
+		targetRepo = repoDir
 	}
 
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	store, err := stackStoreFor(targetRepo)
+	if err != nil {
 		return err
 	}
 
-	stackPath := filepath.Join(configDir, "stacks.json")
+	var saved *repoData
+	err = store.update(func(rd *repoData) (*repoData, bool, error) {
+		if rd == nil {
+			rd = &repoData{}
+		}
+		if rd.StacksRevision != sc.stacksRevision {
+			return nil, false, fmt.Errorf("%w since it was loaded; re-run the command", ErrStaleConfig)
+		}
 
-	// Load existing file first to preserve other repos' data
-	var file stackConfigFile
-	data, err := os.ReadFile(stackPath)
-	if err != nil {
-		if !os.IsNotExist(err) {
-			return err
+		branches := make(map[string]*BranchCache)
+		if sc.Cache != nil {
+			branches = sc.Cache.Branches
 		}
-		file.Repos = make(map[string]*repoData)
-	} else {
-		if err := json.Unmarshal(data, &file); err != nil {
-			return err
+		if rd.Revision != sc.revision {
+			branches = mergeBranches(sc.loadedBranches, branches, rd.Branches)
 		}
-		if file.Repos == nil {
-			file.Repos = make(map[string]*repoData)
+
+		saved = &repoData{
+			Stacks:         sc.Stacks,
+			Branches:       branches,
+			Revision:       rd.Revision + 1,
+			StacksRevision: rd.StacksRevision + 1,
 		}
+		return saved, true, nil
+	})
+	if err != nil {
+		return err
 	}
-
-	targetRepo := sc.repoDir
-	if targetRepo == "" {
-		targetRepo = repoDir
+	sc.revision = saved.Revision
+	sc.stacksRevision = saved.StacksRevision
+	sc.loadedBranches = copyBranches(saved.Branches)
+	if sc.Cache != nil {
+		sc.Cache.Branches = saved.Branches
 	}
+	return nil
+}
 
-	branches := make(map[string]*BranchCache)
-	if sc.Cache != nil {
-		branches = sc.Cache.Branches
+// copyBranches returns a copy of a branch cache's entries
+func copyBranches(branches map[string]*BranchCache) map[string]BranchCache {
+	copied := make(map[string]BranchCache, len(branches))
+	for name, bc := range branches {
+		if bc != nil {
+			copied[name] = *bc
+		}
 	}
+	return copied
+}
 
-	file.Version = currentStackConfigVersion
-	file.Repos[targetRepo] = &repoData{
-		Stacks:   sc.Stacks,
-		Branches: branches,
+// mergeBranches merges a branch cache changed in memory (ours) with the one on
+// disk (theirs), given the entries both started from (base). Entries changed or
+// removed in memory win; every other entry is taken from disk.
+func mergeBranches(base map[string]BranchCache, ours, theirs map[string]*BranchCache) map[string]*BranchCache {
+	merged := make(map[string]*BranchCache, len(theirs))
+	for name, bc := range theirs {
+		merged[name] = bc
+	}
+	for name, bc := range ours {
+		if old, ok := base[name]; !ok || bc == nil || !sameBranchCache(old, *bc) {
+			merged[name] = bc
+		}
+	}
+	for name := range base {
+		if _, ok := ours[name]; !ok {
+			delete(merged, name)
+		}
 	}
+	return merged
+}
+
+// sameBranchCache reports whether two entries hold the same stored metadata
+func sameBranchCache(a, b BranchCache) bool {
+	a.PRNumber, b.PRNumber = 0, 0 // derived from PRUrl
+	return a == b
+}
 
-	newData, err := json.MarshalIndent(file, "", "  ")
+// readStackConfigFile reads stacks.json, returning an empty file if it does not exist yet
+func readStackConfigFile(stackPath string) (*stackConfigFile, error) {
+	file := &stackConfigFile{}
+	data, err := os.ReadFile(stackPath)
 	if err != nil {
-		return err
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse stacks.json: %w", err)
 	}
-
-	return atomicWriteFile(stackPath, newData, 0644)
+	if file.Repos == nil {
+		file.Repos = make(map[string]*repoData)
+	}
+	return file, nil
 }
 
-// LoadCacheConfig loads cached branch metadata. This now delegates to the combined stacks file.
+// LoadCacheConfig loads cached branch metadata. This now delegates to the repo's stack store.
 // Kept for backward compatibility with callers that load cache separately.
 func LoadCacheConfig(repoDir string) (*CacheConfig, error) {
-	configDir, err := ConfigDir()
+	store, err := openStackStore(repoDir)
+	if err != nil {
+		return nil, err
+	}
+	rd, err := store.read()
 	if err != nil {
 		return nil, err
 	}
+	return cacheConfigFrom(rd, repoDir)
+}
 
-	// First try loading from the combined stacks.json
-	stackPath := filepath.Join(configDir, "stacks.json")
-	data, err := os.ReadFile(stackPath)
-	if err == nil {
-		var file stackConfigFile
-		if err := json.Unmarshal(data, &file); err == nil && file.Repos != nil {
-			if rd, ok := file.Repos[repoDir]; ok && rd != nil && rd.Branches != nil {
-				return &CacheConfig{
-					Branches: rd.Branches,
-					repoDir:  repoDir,
-				}, nil
-			}
-		}
+// cacheConfigFrom returns the branch cache stored in rd, falling back to the
+// legacy cache.json when the repo has none
+func cacheConfigFrom(rd *repoData, repoDir string) (*CacheConfig, error) {
+	if rd != nil && rd.Branches != nil {
+		return &CacheConfig{
+			Branches: rd.Branches,
+			repoDir:  repoDir,
+		}, nil
+	}
+
+	configDir, err := ConfigDir()
+	if err != nil {
+		return nil, err
 	}
 
 	// Fall back to legacy cache.json
 	cachePath := filepath.Join(configDir, "cache.json")
-	data, err = os.ReadFile(cachePath)
+	data, err := os.ReadFile(cachePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return &CacheConfig{
@@ -1011,44 +1206,49 @@ func (cc *CacheConfig) SetBranchCache(branchName string, cache *BranchCache) {
 	cc.Branches[branchName] = cache
 }
 
-// Save writes the cache data back to the combined stacks.json file.
-// This loads the current stacks.json, updates the branches for this repo, and writes it back atomically.
+// Save writes the cache data back to the repo's stack store, keeping its stacks.
+// Prefer UpdateCacheConfig, which also reloads the cache under the lock.
 func (cc *CacheConfig) Save(repoDir string) error {
-	configDir, err := ConfigDir()
+	store, err := openStackStore(repoDir)
 	if err != nil {
 		return err
 	}
+	return store.update(func(rd *repoData) (*repoData, bool, error) {
+		return withBranches(rd, cc.Branches), true, nil
+	})
+}
 
-	stackPath := filepath.Join(configDir, "stacks.json")
-	var file stackConfigFile
-
-	data, err := os.ReadFile(stackPath)
-	if err == nil {
-		if uErr := json.Unmarshal(data, &file); uErr != nil {
-			return fmt.Errorf("failed to parse stacks.json: %w", uErr)
-		}
-	}
-	if file.Repos == nil {
-		file.Repos = make(map[string]*repoData)
-	}
-
-	rd := file.Repos[repoDir]
+// withBranches returns rd with its branch cache replaced, creating it if needed.
+// The revision is bumped so stack configs loaded before merge the change on save.
+func withBranches(rd *repoData, branches map[string]*BranchCache) *repoData {
 	if rd == nil {
 		rd = &repoData{
 			Stacks: make(map[string]*Stack),
 		}
-		file.Repos[repoDir] = rd
 	}
+	rd.Branches = branches
+	rd.Revision++
+	return rd
+}
 
-	file.Version = currentStackConfigVersion
-	rd.Branches = cc.Branches
-
-	newData, err := json.MarshalIndent(file, "", "  ")
+// UpdateCacheConfig loads the repo's branch cache, applies update and saves
+// the result, all while holding the stack store's lock so concurrent commands
+// cannot lose each other's changes. Nothing is written if update returns false.
+func UpdateCacheConfig(repoDir string, update func(cc *CacheConfig) bool) error {
+	store, err := openStackStore(repoDir)
 	if err != nil {
 		return err
 	}
-
-	return atomicWriteFile(stackPath, newData, 0644)
+	return store.update(func(rd *repoData) (*repoData, bool, error) {
+		cc, err := cacheConfigFrom(rd, repoDir)
+		if err != nil {
+			return nil, false, err
+		}
+		if !update(cc) {
+			return nil, false, nil
+		}
+		return withBranches(rd, cc.Branches), true, nil
+	})
 }
 
 // GetBranches returns a flat list of branches from the tree structure
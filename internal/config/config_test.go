@@ -2,9 +2,12 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestConfigDir(t *testing.T) {
@@ -525,3 +528,436 @@ func TestBranch_Fields(t *testing.T) {
 		t.Error("IsMerged should be false")
 	}
 }
+
+func TestSyncState_LoadSaveClear(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "sync-state-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	originalHome := os.Getenv("EZSTACK_HOME")
+	defer os.Setenv("EZSTACK_HOME", originalHome)
+	os.Setenv("EZSTACK_HOME", tmpDir)
+
+	state, err := LoadSyncState("/repo1")
+	if err != nil || state != nil {
+		t.Fatalf("LoadSyncState() = %v, %v; want nil, nil", state, err)
+	}
+
+	state = &SyncState{
+		Stacks:   []string{"abc1234"},
+		OldHeads: map[string]string{"feature-a": "1111111", "feature-b": "2222222"},
+	}
+	state.MarkCompleted("feature-a")
+	state.AddConflict(SyncConflict{Branch: "feature-a", WorktreePath: "/wt/feature-a", Stashed: true})
+	if state.IsCompleted("feature-a") {
+		t.Error("conflicted branch should not be completed")
+	}
+
+	if err := SaveSyncState("/repo1", state); err != nil {
+		t.Fatalf("SaveSyncState() error = %v", err)
+	}
+	if err := SaveSyncState("/repo2", &SyncState{Stacks: []string{"def5678"}}); err != nil {
+		t.Fatalf("SaveSyncState() error = %v", err)
+	}
+
+	loaded, err := LoadSyncState("/repo1")
+	if err != nil || loaded == nil {
+		t.Fatalf("LoadSyncState() = %v, %v", loaded, err)
+	}
+	if loaded.OldHeads["feature-b"] != "2222222" {
+		t.Errorf("OldHeads[feature-b] = %q, want %q", loaded.OldHeads["feature-b"], "2222222")
+	}
+	if len(loaded.Conflicts) != 1 || !loaded.Conflicts[0].Stashed {
+		t.Errorf("Conflicts = %+v, want one stashed conflict", loaded.Conflicts)
+	}
+
+	if err := ClearSyncState("/repo1"); err != nil {
+		t.Fatalf("ClearSyncState() error = %v", err)
+	}
+	if loaded, _ := LoadSyncState("/repo1"); loaded != nil {
+		t.Error("repo1 sync state should be cleared")
+	}
+	if loaded, _ := LoadSyncState("/repo2"); loaded == nil {
+		t.Error("repo2 sync state should be preserved")
+	}
+
+	// Clearing the last repo removes the file
+	ClearSyncState("/repo2")
+	if _, err := os.Stat(filepath.Join(tmpDir, "sync-state.json")); !os.IsNotExist(err) {
+		t.Error("sync-state.json should be removed when empty")
+	}
+}
+
+// TestOpLog_ConcurrentAppend verifies that concurrent commands appending to
+// the log don't lose each other's entries
+func TestOpLog_ConcurrentAppend(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "oplog-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	originalHome := os.Getenv("EZSTACK_HOME")
+	defer os.Setenv("EZSTACK_HOME", originalHome)
+	os.Setenv("EZSTACK_HOME", tmpDir)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := AppendOperation("/repo1", &Operation{Command: "sync"}); err != nil {
+				t.Errorf("AppendOperation() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	ops, err := LoadOpLog("/repo1")
+	if err != nil {
+		t.Fatalf("LoadOpLog() error = %v", err)
+	}
+	if len(ops) != 10 {
+		t.Fatalf("got %d operations, want 10", len(ops))
+	}
+	for i, op := range ops {
+		if op.ID != i+1 {
+			t.Errorf("operation %d has ID %d, want %d", i, op.ID, i+1)
+		}
+	}
+}
+
+func TestOpLog_AppendTruncate(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "oplog-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	originalHome := os.Getenv("EZSTACK_HOME")
+	defer os.Setenv("EZSTACK_HOME", originalHome)
+	os.Setenv("EZSTACK_HOME", tmpDir)
+
+	for i := 0; i < maxOpLogEntries+5; i++ {
+		if err := AppendOperation("/repo1", &Operation{Command: "sync"}); err != nil {
+			t.Fatalf("AppendOperation() error = %v", err)
+		}
+	}
+
+	ops, _ := LoadOpLog("/repo1")
+	if len(ops) != maxOpLogEntries {
+		t.Fatalf("len(ops) = %d, want %d", len(ops), maxOpLogEntries)
+	}
+	if ops[len(ops)-1].ID != maxOpLogEntries+5 {
+		t.Errorf("last ID = %d, want %d", ops[len(ops)-1].ID, maxOpLogEntries+5)
+	}
+
+	if err := TruncateOpLog("/repo1", 2); err != nil {
+		t.Fatalf("TruncateOpLog() error = %v", err)
+	}
+	ops, _ = LoadOpLog("/repo1")
+	if len(ops) != maxOpLogEntries-2 {
+		t.Errorf("len(ops) = %d after truncate, want %d", len(ops), maxOpLogEntries-2)
+	}
+}
+
+func TestSnapshotRestoreRepo(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "snapshot-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	originalHome := os.Getenv("EZSTACK_HOME")
+	defer os.Setenv("EZSTACK_HOME", originalHome)
+	os.Setenv("EZSTACK_HOME", tmpDir)
+
+	// A repo with no entry snapshots as null and restoring it removes the entry
+	empty, err := SnapshotRepo("/repo1")
+	if err != nil {
+		t.Fatalf("SnapshotRepo() error = %v", err)
+	}
+
+	sc, _ := LoadStackConfig("/repo1")
+	hash := GenerateStackHash("feature-a")
+	sc.Stacks[hash] = &Stack{Hash: hash, Root: "main", Tree: BranchTree{"feature-a": BranchTree{}}}
+	sc.Save("/repo1")
+
+	snapshot, err := SnapshotRepo("/repo1")
+	if err != nil {
+		t.Fatalf("SnapshotRepo() error = %v", err)
+	}
+
+	delete(sc.Stacks, hash)
+	sc.Save("/repo1")
+
+	if err := RestoreRepo("/repo1", snapshot); err != nil {
+		t.Fatalf("RestoreRepo() error = %v", err)
+	}
+	loaded, _ := LoadStackConfig("/repo1")
+	if _, ok := loaded.Stacks[hash]; !ok {
+		t.Error("restored config should contain the stack")
+	}
+
+	if err := RestoreRepo("/repo1", empty); err != nil {
+		t.Fatalf("RestoreRepo() error = %v", err)
+	}
+	loaded, _ = LoadStackConfig("/repo1")
+	if len(loaded.Stacks) != 0 {
+		t.Errorf("len(Stacks) = %d after restoring empty snapshot, want 0", len(loaded.Stacks))
+	}
+}
+
+func TestStackConfig_SaveStale(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stale-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	originalHome := os.Getenv("EZSTACK_HOME")
+	defer os.Setenv("EZSTACK_HOME", originalHome)
+	os.Setenv("EZSTACK_HOME", tmpDir)
+
+	first, _ := LoadStackConfig("/repo1")
+	second, _ := LoadStackConfig("/repo1")
+
+	hash := GenerateStackHash("feature-a")
+	first.Stacks[hash] = &Stack{Hash: hash, Root: "main", Tree: BranchTree{"feature-a": BranchTree{}}}
+	if err := first.Save("/repo1"); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	// A config that has saved stays current
+	if err := first.Save("/repo1"); err != nil {
+		t.Fatalf("second Save() error = %v", err)
+	}
+
+	// Cache-only updates don't invalidate loaded stack configs
+	err = UpdateCacheConfig("/repo1", func(cc *CacheConfig) bool {
+		cc.SetBranchCache("feature-a", &BranchCache{PRState: "OPEN"})
+		return true
+	})
+	if err != nil {
+		t.Fatalf("UpdateCacheConfig() error = %v", err)
+	}
+	if err := first.Save("/repo1"); err != nil {
+		t.Fatalf("Save() after cache update error = %v", err)
+	}
+
+	second.Stacks = map[string]*Stack{}
+	if err := second.Save("/repo1"); !errors.Is(err, ErrStaleConfig) {
+		t.Fatalf("Save() of stale config error = %v, want ErrStaleConfig", err)
+	}
+	loaded, _ := LoadStackConfig("/repo1")
+	if _, ok := loaded.Stacks[hash]; !ok {
+		t.Error("stale save should not have overwritten the stack")
+	}
+}
+
+// TestStackConfig_SaveKeepsCacheUpdates verifies that saving a config loaded
+// before a cache update, e.g. PR data fetched by status during sync, keeps
+// that update
+func TestStackConfig_SaveKeepsCacheUpdates(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stale-cache-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	originalHome := os.Getenv("EZSTACK_HOME")
+	defer os.Setenv("EZSTACK_HOME", originalHome)
+	os.Setenv("EZSTACK_HOME", tmpDir)
+
+	setup, _ := LoadStackConfig("/repo1")
+	hash := GenerateStackHash("feature-a")
+	setup.Stacks[hash] = &Stack{Hash: hash, Root: "main", Tree: BranchTree{"feature-a": BranchTree{"feature-b": BranchTree{}}}}
+	setup.Cache.SetBranchCache("feature-a", &BranchCache{WorktreePath: "/wt/a"})
+	setup.Cache.SetBranchCache("feature-b", &BranchCache{WorktreePath: "/wt/b"})
+	if err := setup.Save("/repo1"); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	old, _ := LoadStackConfig("/repo1")
+	err = UpdateCacheConfig("/repo1", func(cc *CacheConfig) bool {
+		cc.GetBranchCache("feature-a").PRUrl = "https://github.com/org/repo/pull/42"
+		return true
+	})
+	if err != nil {
+		t.Fatalf("UpdateCacheConfig() error = %v", err)
+	}
+
+	old.Cache.GetBranchCache("feature-b").IsMerged = true
+	if err := old.Save("/repo1"); err != nil {
+		t.Fatalf("Save() of config loaded before the cache update error = %v", err)
+	}
+
+	loaded, _ := LoadStackConfig("/repo1")
+	if bc := loaded.Cache.GetBranchCache("feature-a"); bc == nil || PRNumberFromURL(bc.PRUrl) != 42 {
+		t.Errorf("feature-a cache = %+v, want PR 42 kept", bc)
+	}
+	if bc := loaded.Cache.GetBranchCache("feature-b"); bc == nil || !bc.IsMerged {
+		t.Errorf("feature-b cache = %+v, want the saved change", bc)
+	}
+}
+
+func TestWithFileLock_Timeout(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "lock-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	originalTimeout := lockTimeout
+	defer func() { lockTimeout = originalTimeout }()
+	lockTimeout = 100 * time.Millisecond
+
+	path := filepath.Join(tmpDir, "stacks.json")
+	err = withFileLock(path, func() error {
+		return withFileLock(path, func() error { return nil })
+	})
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("withFileLock() while held error = %v, want ErrLockTimeout", err)
+	}
+
+	if err := withFileLock(path, func() error { return nil }); err != nil {
+		t.Errorf("withFileLock() after release error = %v", err)
+	}
+}
+
+func TestMigrateV5ToV6(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "migrate-v6-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	originalHome := os.Getenv("EZSTACK_HOME")
+	defer os.Setenv("EZSTACK_HOME", originalHome)
+	os.Setenv("EZSTACK_HOME", filepath.Join(tmpDir, "home"))
+
+	repoDir := filepath.Join(tmpDir, "repo")
+	if err := os.MkdirAll(filepath.Join(repoDir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A linked worktree shares the main repo's git directory
+	worktreeDir := filepath.Join(tmpDir, "wt")
+	worktreeGitDir := filepath.Join(repoDir, ".git", "worktrees", "wt")
+	os.MkdirAll(worktreeGitDir, 0755)
+	os.MkdirAll(worktreeDir, 0755)
+	os.WriteFile(filepath.Join(worktreeGitDir, "commondir"), []byte("../..\n"), 0644)
+	os.WriteFile(filepath.Join(worktreeDir, ".git"), []byte("gitdir: "+worktreeGitDir+"\n"), 0644)
+
+	v5Data := []byte(`{
+		"version": 5,
+		"repos": {
+			"` + repoDir + `": {
+				"stacks": {"abc1234": {"root": "main", "tree": {"feature-a": {}}}},
+				"branches": {"feature-a": {"worktree_path": "/wt/a"}}
+			},
+			"/gone/repo": {
+				"stacks": {"def5678": {"root": "main", "tree": {"feature-b": {}}}}
+			}
+		}
+	}`)
+	// Sync state was kept in ~/.ezstack, keyed by repo path
+	globalState := `{"repos": {"` + repoDir + `": {"stacks": ["abc1234"], "old_heads": {}}}}`
+	os.MkdirAll(filepath.Join(tmpDir, "home"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "home", "sync-state.json"), []byte(globalState), 0644)
+
+	migrated, err := migrateV5ToV6(v5Data)
+	if err != nil {
+		t.Fatalf("migrateV5ToV6() error = %v", err)
+	}
+	var file stackConfigFile
+	if err := json.Unmarshal(migrated, &file); err != nil {
+		t.Fatalf("json.Unmarshal error = %v", err)
+	}
+	if file.Version != 6 {
+		t.Errorf("Version = %d, want 6", file.Version)
+	}
+	if _, ok := file.Repos[repoDir]; ok {
+		t.Error("repo with a git directory should have moved out of the global file")
+	}
+	if _, ok := file.Repos["/gone/repo"]; !ok {
+		t.Error("repo without a checkout should stay in the global file")
+	}
+
+	if _, err := os.Stat(filepath.Join(repoDir, ".git", "ezstack", "stacks.json")); err != nil {
+		t.Fatalf("per-repo stacks.json was not written: %v", err)
+	}
+	for _, dir := range []string{repoDir, worktreeDir} {
+		store, _ := stackStoreFor(dir)
+		rd, err := store.read()
+		if err != nil || rd == nil {
+			t.Fatalf("read store for %s = %v, %v", dir, rd, err)
+		}
+		if _, ok := rd.Stacks["abc1234"]; !ok {
+			t.Errorf("store for %s is missing the migrated stack", dir)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "home", "sync-state.json")); !os.IsNotExist(err) {
+		t.Errorf("global sync-state.json should have been emptied, stat error = %v", err)
+	}
+	for _, dir := range []string{repoDir, worktreeDir} {
+		state, err := LoadSyncState(dir)
+		if err != nil || state == nil || len(state.Stacks) != 1 {
+			t.Errorf("LoadSyncState(%s) = %+v, %v, want the migrated state", dir, state, err)
+		}
+	}
+}
+
+func TestRelocateRepo(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "relocate-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	originalHome := os.Getenv("EZSTACK_HOME")
+	defer os.Setenv("EZSTACK_HOME", originalHome)
+	os.Setenv("EZSTACK_HOME", filepath.Join(tmpDir, "home"))
+
+	// The old path no longer exists, so its stacks stayed in the global file
+	oldPath := filepath.Join(tmpDir, "old")
+	sc, _ := LoadStackConfig(oldPath)
+	hash := GenerateStackHash("feature-a")
+	sc.Stacks[hash] = &Stack{Hash: hash, Root: "main", Tree: BranchTree{"feature-a": BranchTree{}}}
+	if err := sc.Save(oldPath); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	cfg, _ := Load()
+	cfg.SetRepoConfig(oldPath, &RepoConfig{WorktreeBaseDir: "/worktrees"})
+	cfg.Save()
+	SaveSyncState(oldPath, &SyncState{})
+
+	newPath := filepath.Join(tmpDir, "new")
+	if err := RelocateRepo(oldPath, newPath); err == nil {
+		t.Fatal("RelocateRepo() to a directory that is not a repo should fail")
+	}
+	os.MkdirAll(filepath.Join(newPath, ".git"), 0755)
+	if err := RelocateRepo(oldPath, newPath); err != nil {
+		t.Fatalf("RelocateRepo() error = %v", err)
+	}
+
+	loaded, _ := LoadStackConfig(newPath)
+	if _, ok := loaded.Stacks[hash]; !ok {
+		t.Error("relocated repo should have the stack")
+	}
+	if old, _ := LoadStackConfig(oldPath); len(old.Stacks) != 0 {
+		t.Error("old path should no longer have stacks")
+	}
+	cfg, _ = Load()
+	if cfg.GetRepoConfig(oldPath) != nil || cfg.GetWorktreeBaseDir(newPath) != "/worktrees" {
+		t.Errorf("repo config was not moved: %+v", cfg.Repos)
+	}
+	if state, _ := LoadSyncState(newPath); state == nil {
+		t.Error("sync state should have moved to the new path")
+	}
+
+	if err := RelocateRepo(oldPath, newPath); err == nil {
+		t.Error("RelocateRepo() with nothing left to move should fail")
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockTimeout is how long a command waits for another ezs process to release
+// a config file before giving up
+var lockTimeout = 10 * time.Second
+
+const lockPollInterval = 50 * time.Millisecond
+
+// ErrLockTimeout is returned when a config file stays locked by another
+// process for longer than lockTimeout
+var ErrLockTimeout = errors.New("timed out waiting for lock")
+
+// ErrStaleConfig is returned when saving a StackConfig that another command
+// has changed on disk since it was loaded
+var ErrStaleConfig = errors.New("stacks.json was changed by another ezs command")
+
+// withFileLock runs fn while holding an exclusive advisory lock on path+".lock".
+// The lock is released when fn returns, or by the OS if the process dies.
+// Locks are not reentrant: fn must not lock the same path again.
+func withFileLock(path string, fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	lockPath := path + ".lock"
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file: %w", err)
+	}
+	defer f.Close()
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			return fmt.Errorf("failed to lock %s: %w", lockPath, err)
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w on %s after %s: another ezs command is still using it, try again when it finishes", ErrLockTimeout, filepath.Base(path), lockTimeout)
+		}
+		time.Sleep(lockPollInterval)
+	}
+	defer unlockFile(f)
+
+	return fn()
+}
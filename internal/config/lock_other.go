@@ -0,0 +1,14 @@
+//go:build !unix
+
+package config
+
+import "os"
+
+// tryLockFile is a no-op where flock is unavailable
+func tryLockFile(f *os.File) (bool, error) {
+	return true, nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,48 @@
+package config
+
+// MergeTrainState records an in-flight 'ezs pr merge --stack' so it can be
+// resumed with '--continue' after a conflict, failing checks or a timeout.
+type MergeTrainState struct {
+	Stack        string   `json:"stack"`    // hash of the stack being landed
+	Branches     []string `json:"branches"` // in merge order, bottom of the stack first
+	Method       string   `json:"method"`
+	DeleteBranch bool     `json:"delete_branch,omitempty"`
+	Merged       []string `json:"merged,omitempty"` // branches whose PRs were merged by this train
+}
+
+// IsMerged reports whether the train already merged the branch's PR
+func (s *MergeTrainState) IsMerged(branchName string) bool {
+	for _, name := range s.Merged {
+		if name == branchName {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkMerged records that the train merged the branch's PR
+func (s *MergeTrainState) MarkMerged(branchName string) {
+	if !s.IsMerged(branchName) {
+		s.Merged = append(s.Merged, branchName)
+	}
+}
+
+// LoadMergeTrainState returns the in-flight merge train for a repo, or nil if there is none
+func LoadMergeTrainState(repoDir string) (*MergeTrainState, error) {
+	state := &MergeTrainState{}
+	found, err := mergeTrains.load(repoDir, state)
+	if err != nil || !found {
+		return nil, err
+	}
+	return state, nil
+}
+
+// SaveMergeTrainState persists the in-flight merge train for a repo to its merge-train.json
+func SaveMergeTrainState(repoDir string, state *MergeTrainState) error {
+	return mergeTrains.save(repoDir, state)
+}
+
+// ClearMergeTrainState removes the in-flight merge train for a repo
+func ClearMergeTrainState(repoDir string) error {
+	return mergeTrains.clear(repoDir)
+}
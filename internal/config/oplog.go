@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// maxOpLogEntries is the number of operations kept per repo
+const maxOpLogEntries = 50
+
+// Operation is one recorded invocation of a stack-mutating command
+type Operation struct {
+	ID       int                  `json:"id"`
+	Command  string               `json:"command"`
+	Args     []string             `json:"args,omitempty"`
+	Time     time.Time            `json:"time"`
+	Refs     map[string]RefChange `json:"refs,omitempty"` // only branches the command moved, created or deleted
+	Snapshot json.RawMessage      `json:"snapshot"`       // this repo's stacks.json entry before the command ran
+}
+
+// RefChange records where a branch pointed before and after an operation.
+// An empty Before means the operation created the branch; an empty After means it deleted it.
+type RefChange struct {
+	Before   string `json:"before,omitempty"`
+	After    string `json:"after,omitempty"`
+	Worktree string `json:"worktree,omitempty"` // worktree the branch was checked out in before the operation
+}
+
+// LoadOpLog returns the recorded operations for a repo, oldest first
+func LoadOpLog(repoDir string) ([]*Operation, error) {
+	var ops []*Operation
+	if _, err := opLogs.load(repoDir, &ops); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// AppendOperation assigns the next ID to op and appends it to the repo's log,
+// dropping the oldest entries beyond maxOpLogEntries.
+func AppendOperation(repoDir string, op *Operation) error {
+	var ops []*Operation
+	return opLogs.update(repoDir, &ops, func(bool) bool {
+		op.ID = 1
+		if len(ops) > 0 {
+			op.ID = ops[len(ops)-1].ID + 1
+		}
+		ops = append(ops, op)
+		if len(ops) > maxOpLogEntries {
+			ops = ops[len(ops)-maxOpLogEntries:]
+		}
+		return true
+	})
+}
+
+// TruncateOpLog removes the newest n operations from the repo's log
+func TruncateOpLog(repoDir string, n int) error {
+	var ops []*Operation
+	return opLogs.update(repoDir, &ops, func(bool) bool {
+		if n >= len(ops) {
+			return false
+		}
+		ops = ops[:len(ops)-n]
+		return true
+	})
+}
+
+// SnapshotRepo returns a copy of the repo's stored stacks and branch cache,
+// or JSON null if the repo has none yet. The save revision is left out so
+// snapshots only differ when the metadata does.
+func SnapshotRepo(repoDir string) (json.RawMessage, error) {
+	store, err := openStackStore(repoDir)
+	if err != nil {
+		return nil, err
+	}
+
+	rd, err := store.read()
+	if err != nil {
+		return nil, err
+	}
+	if rd != nil {
+		rd.Revision, rd.StacksRevision = 0, 0
+	}
+	return json.Marshal(rd)
+}
+
+// RestoreRepo replaces the repo's stored stacks and branch cache with a snapshot taken by SnapshotRepo
+func RestoreRepo(repoDir string, snapshot json.RawMessage) error {
+	var rd *repoData
+	if err := json.Unmarshal(snapshot, &rd); err != nil {
+		return err
+	}
+
+	store, err := openStackStore(repoDir)
+	if err != nil {
+		return err
+	}
+	return store.update(func(cur *repoData) (*repoData, bool, error) {
+		if rd == nil {
+			return nil, true, nil
+		}
+		// Bump the revisions so configs loaded before the restore can't overwrite it
+		if cur == nil {
+			cur = &repoData{}
+		}
+		rd.Revision = cur.Revision + 1
+		rd.StacksRevision = cur.StacksRevision + 1
+		return rd, true, nil
+	})
+}
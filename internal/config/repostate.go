@@ -0,0 +1,244 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// repoStateFile is a JSON file holding a repo's state outside its stacks,
+// e.g. that of an interrupted sync. Like stacks.json it lives in
+// $GIT_COMMON_DIR/ezstack/ when the repo's git directory can be found, so it
+// follows the checkout; otherwise in ~/.ezstack, keyed by repo path.
+type repoStateFile struct {
+	name string
+}
+
+var (
+	opLogs      = repoStateFile{name: "oplog.json"}
+	syncStates  = repoStateFile{name: "sync-state.json"}
+	mergeTrains = repoStateFile{name: "merge-train.json"}
+)
+
+// repoStateEntries is the on-disk layout of the global file, keyed by repo path
+type repoStateEntries struct {
+	Repos map[string]json.RawMessage `json:"repos"`
+}
+
+// globalPath is where the file lives for repos without a store of their own
+func (f repoStateFile) globalPath() (string, error) {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, f.name), nil
+}
+
+func (f repoStateFile) readGlobal() (*repoStateEntries, error) {
+	path, err := f.globalPath()
+	if err != nil {
+		return nil, err
+	}
+
+	file := &repoStateEntries{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(data, file); err != nil {
+		return nil, err
+	}
+	if file.Repos == nil {
+		file.Repos = make(map[string]json.RawMessage)
+	}
+	return file, nil
+}
+
+// modifyGlobal applies fn to the global entries while holding the file's
+// lock, saving them if fn reports a change. The file is removed once no repo
+// has an entry.
+func (f repoStateFile) modifyGlobal(fn func(file *repoStateEntries) (bool, error)) error {
+	path, err := f.globalPath()
+	if err != nil {
+		return err
+	}
+	return withFileLock(path, func() error {
+		file, err := f.readGlobal()
+		if err != nil {
+			return err
+		}
+		changed, err := fn(file)
+		if err != nil || !changed {
+			return err
+		}
+		if len(file.Repos) == 0 {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return nil
+		}
+		return writeJSON(path, file)
+	})
+}
+
+// readRepo returns the contents of a per-repo file, or nil if it doesn't exist
+func readRepo(path string) (json.RawMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// modify applies fn to the repo's value (nil if it has none) while holding
+// the lock of the file it lives in, so concurrent commands don't lose each
+// other's writes. If fn reports a change its result is saved; a nil result
+// removes the value. A value still in the global file is moved into the
+// repo's own file on the way.
+func (f repoStateFile) modify(repoDir string, fn func(raw json.RawMessage) (json.RawMessage, bool, error)) error {
+	commonDir, ok := gitCommonDir(repoDir)
+	if !ok {
+		return f.modifyGlobal(func(file *repoStateEntries) (bool, error) {
+			raw, changed, err := fn(file.Repos[repoDir])
+			if err != nil || !changed {
+				return false, err
+			}
+			if raw == nil {
+				delete(file.Repos, repoDir)
+			} else {
+				file.Repos[repoDir] = raw
+			}
+			return true, nil
+		})
+	}
+
+	path := filepath.Join(commonDir, "ezstack", f.name)
+	return withFileLock(path, func() error {
+		current, err := readRepo(path)
+		if err != nil {
+			return err
+		}
+		adopted := false
+		if current == nil {
+			global, err := f.readGlobal()
+			if err != nil {
+				return err
+			}
+			current, adopted = global.Repos[repoDir]
+		}
+
+		raw, changed, err := fn(current)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			raw = current
+		}
+		if changed || adopted {
+			if raw == nil {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			} else if err := writeJSON(path, raw); err != nil {
+				return err
+			}
+		}
+		if !adopted {
+			return nil
+		}
+		return f.modifyGlobal(func(file *repoStateEntries) (bool, error) {
+			delete(file.Repos, repoDir)
+			return true, nil
+		})
+	})
+}
+
+// load decodes the repo's value into v, reporting whether it has one
+func (f repoStateFile) load(repoDir string, v any) (bool, error) {
+	var raw json.RawMessage
+	if commonDir, ok := gitCommonDir(repoDir); ok {
+		var err error
+		raw, err = readRepo(filepath.Join(commonDir, "ezstack", f.name))
+		if err != nil {
+			return false, err
+		}
+	}
+	if raw == nil {
+		global, err := f.readGlobal()
+		if err != nil {
+			return false, err
+		}
+		raw = global.Repos[repoDir]
+	}
+	if raw == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// update decodes the repo's value into v, applies fn and saves v, all under
+// the file's lock. fn reports whether the repo still has a value; if it
+// doesn't, the value is removed.
+func (f repoStateFile) update(repoDir string, v any, fn func(found bool) bool) error {
+	return f.modify(repoDir, func(raw json.RawMessage) (json.RawMessage, bool, error) {
+		found := raw != nil
+		if found {
+			if err := json.Unmarshal(raw, v); err != nil {
+				return nil, false, err
+			}
+		}
+		if !fn(found) {
+			return nil, found, nil
+		}
+		raw, err := json.Marshal(v)
+		return raw, err == nil, err
+	})
+}
+
+// save replaces the repo's value with v
+func (f repoStateFile) save(repoDir string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return f.modify(repoDir, func(json.RawMessage) (json.RawMessage, bool, error) {
+		return raw, true, nil
+	})
+}
+
+// clear removes the repo's value, if it has one
+func (f repoStateFile) clear(repoDir string) error {
+	return f.modify(repoDir, func(raw json.RawMessage) (json.RawMessage, bool, error) {
+		return nil, raw != nil, nil
+	})
+}
+
+// adopt moves the repo's value out of the global file into the repo's own
+// file, if the repo has one
+func (f repoStateFile) adopt(repoDir string) error {
+	return f.modify(repoDir, func(raw json.RawMessage) (json.RawMessage, bool, error) {
+		return raw, false, nil
+	})
+}
+
+// move re-keys the value in the global file of a repo checked out at oldPath
+// to newPath, reporting whether there was one. Values in the repo's own file
+// move with the checkout.
+func (f repoStateFile) move(oldPath, newPath string) (bool, error) {
+	moved := false
+	err := f.modifyGlobal(func(file *repoStateEntries) (bool, error) {
+		raw, ok := file.Repos[oldPath]
+		if !ok {
+			return false, nil
+		}
+		file.Repos[newPath] = raw
+		delete(file.Repos, oldPath)
+		moved = true
+		return true, nil
+	})
+	return moved, err
+}
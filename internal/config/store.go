@@ -0,0 +1,312 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stackStore persists one repo's stacks and branch cache
+type stackStore interface {
+	// read returns the repo's stored data, or nil if it has none yet
+	read() (*repoData, error)
+
+	// update calls fn with the stored data (nil if none) while holding the
+	// store's lock. If fn reports a change, its result is written back; a nil
+	// result removes the repo's data.
+	update(fn func(rd *repoData) (*repoData, bool, error)) error
+}
+
+// repoStackFile is the on-disk layout of a per-repo stacks.json
+type repoStackFile struct {
+	Version int `json:"version"`
+	repoData
+}
+
+// stackStoreFor picks where a repo's stacks live: in $GIT_COMMON_DIR/ezstack/
+// when the repo's git directory can be found, so every worktree shares them
+// and they move with the checkout, otherwise in the global stacks.json.
+func stackStoreFor(repoDir string) (stackStore, error) {
+	if commonDir, ok := gitCommonDir(repoDir); ok {
+		return &repoStore{path: filepath.Join(commonDir, "ezstack", "stacks.json")}, nil
+	}
+	configDir, err := ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return &globalStore{path: filepath.Join(configDir, "stacks.json"), repoDir: repoDir}, nil
+}
+
+// gitCommonDir returns the git directory shared by all worktrees of the repo
+// checked out at repoDir, without shelling out to git
+func gitCommonDir(repoDir string) (string, bool) {
+	if repoDir == "" {
+		return "", false
+	}
+	gitPath := filepath.Join(repoDir, ".git")
+	info, err := os.Stat(gitPath)
+	if err != nil {
+		return "", false
+	}
+	if info.IsDir() {
+		return gitPath, true
+	}
+
+	// Linked worktrees and submodules have a .git file pointing at their git dir
+	data, err := os.ReadFile(gitPath)
+	if err != nil {
+		return "", false
+	}
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", false
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(repoDir, gitDir)
+	}
+	if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		dir := strings.TrimSpace(string(common))
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(gitDir, dir)
+		}
+		return filepath.Clean(dir), true
+	}
+	return filepath.Clean(gitDir), true
+}
+
+// globalStore keeps a repo's data in ~/.ezstack/stacks.json, keyed by repo path
+type globalStore struct {
+	path    string
+	repoDir string
+}
+
+func (s *globalStore) read() (*repoData, error) {
+	file, err := readStackConfigFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	return file.Repos[s.repoDir], nil
+}
+
+func (s *globalStore) update(fn func(rd *repoData) (*repoData, bool, error)) error {
+	return withFileLock(s.path, func() error {
+		file, err := readStackConfigFile(s.path)
+		if err != nil {
+			return err
+		}
+		rd, changed, err := fn(file.Repos[s.repoDir])
+		if err != nil || !changed {
+			return err
+		}
+		if rd == nil {
+			delete(file.Repos, s.repoDir)
+		} else {
+			file.Repos[s.repoDir] = rd
+		}
+		file.Version = currentStackConfigVersion
+		return writeJSON(s.path, file)
+	})
+}
+
+// repoStore keeps a repo's data in its own stacks.json inside the git directory
+type repoStore struct {
+	path string
+}
+
+func (s *repoStore) read() (*repoData, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var file repoStackFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	return &file.repoData, nil
+}
+
+func (s *repoStore) update(fn func(rd *repoData) (*repoData, bool, error)) error {
+	return withFileLock(s.path, func() error {
+		current, err := s.read()
+		if err != nil {
+			return err
+		}
+		rd, changed, err := fn(current)
+		if err != nil || !changed {
+			return err
+		}
+		if rd == nil {
+			if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return nil
+		}
+		return writeJSON(s.path, &repoStackFile{Version: currentStackConfigVersion, repoData: *rd})
+	})
+}
+
+// writeJSON atomically writes v as indented JSON
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicWriteFile(path, data, 0644)
+}
+
+// adoptGlobalEntry moves the repo's entry out of the global stacks.json into
+// its per-repo store, for repos that could not be found when the file was
+// migrated. The caller holds the global stacks.json lock.
+func adoptGlobalEntry(file *stackConfigFile, repoDir string) (bool, error) {
+	rd := file.Repos[repoDir]
+	if rd == nil {
+		return false, nil
+	}
+	moved, err := moveIntoRepoStore(repoDir, rd)
+	if err != nil || !moved {
+		return false, err
+	}
+	delete(file.Repos, repoDir)
+	return true, nil
+}
+
+// moveIntoRepoStore writes rd into the per-repo store of the repo at repoDir.
+// It does nothing if repoDir is not a git checkout or its store already holds
+// stacks, so stacks created there since are never overwritten.
+func moveIntoRepoStore(repoDir string, rd *repoData) (bool, error) {
+	commonDir, ok := gitCommonDir(repoDir)
+	if !ok {
+		return false, nil
+	}
+	store := &repoStore{path: filepath.Join(commonDir, "ezstack", "stacks.json")}
+
+	moved := false
+	err := store.update(func(existing *repoData) (*repoData, bool, error) {
+		revision, stacksRevision := rd.Revision, rd.StacksRevision
+		if existing != nil {
+			if len(existing.Stacks) > 0 {
+				return nil, false, nil
+			}
+			revision = max(revision, existing.Revision)
+			stacksRevision = max(stacksRevision, existing.StacksRevision)
+		}
+		moved = true
+		data := *rd
+		data.Revision = revision + 1
+		data.StacksRevision = stacksRevision + 1
+		return &data, true, nil
+	})
+	return moved, err
+}
+
+// RelocateRepo moves everything ezstack keeps for a repo from oldPath to the
+// repo now checked out at newPath: its config.json settings, its stacks if
+// they are still in the global stacks.json, its operation log and any
+// interrupted sync or merge train. Stacks already in the repo's own store
+// move with the checkout and need no relocation.
+func RelocateRepo(oldPath, newPath string) error {
+	if _, ok := gitCommonDir(newPath); !ok {
+		return fmt.Errorf("%s is not a git repository", newPath)
+	}
+	configDir, err := ConfigDir()
+	if err != nil {
+		return err
+	}
+
+	configPath := filepath.Join(configDir, "config.json")
+	movedConfig := false
+	err = withFileLock(configPath, func() error {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("failed to parse config.json: %w", err)
+		}
+		var repos map[string]*RepoConfig
+		if r, ok := raw["repos"]; ok {
+			if err := json.Unmarshal(r, &repos); err != nil {
+				return fmt.Errorf("failed to parse config.json: %w", err)
+			}
+		}
+		rc, ok := repos[oldPath]
+		if !ok {
+			return nil
+		}
+		if _, exists := repos[newPath]; exists {
+			return fmt.Errorf("config.json already has settings for %s", newPath)
+		}
+		delete(repos, oldPath)
+		if rc.RepoPath != "" {
+			rc.RepoPath = newPath
+		}
+		repos[newPath] = rc
+		if raw["repos"], err = json.Marshal(repos); err != nil {
+			return err
+		}
+		movedConfig = true
+		return writeJSON(configPath, raw)
+	})
+	if err != nil {
+		return err
+	}
+
+	stackPath := filepath.Join(configDir, "stacks.json")
+	movedStacks := false
+	err = withFileLock(stackPath, func() error {
+		file, err := readStackConfigFile(stackPath)
+		if err != nil {
+			return err
+		}
+		rd := file.Repos[oldPath]
+		if rd == nil {
+			return nil
+		}
+		moved, err := moveIntoRepoStore(newPath, rd)
+		if err != nil {
+			return fmt.Errorf("failed to move stacks to %s: %w", newPath, err)
+		}
+		if !moved {
+			return fmt.Errorf("%s already has stacks; not overwriting them", newPath)
+		}
+		delete(file.Repos, oldPath)
+		movedStacks = true
+		return writeJSON(stackPath, file)
+	})
+	if err != nil {
+		return err
+	}
+
+	movedState, err := relocateRepoState(oldPath, newPath)
+	if err != nil {
+		return err
+	}
+
+	if !movedConfig && !movedStacks && !movedState {
+		return fmt.Errorf("nothing is recorded for %s", oldPath)
+	}
+	return nil
+}
+
+// relocateRepoState re-keys the operation log, sync state and merge train of a moved repo
+func relocateRepoState(oldPath, newPath string) (bool, error) {
+	moved := false
+	for _, f := range []repoStateFile{opLogs, syncStates, mergeTrains} {
+		movedState, err := f.move(oldPath, newPath)
+		if err != nil {
+			return false, err
+		}
+		moved = moved || movedState
+	}
+	return moved, nil
+}
@@ -0,0 +1,129 @@
+package config
+
+import "sync"
+
+// SyncState records an in-flight sync so it can be resumed with
+// 'ezs sync --continue' or rolled back with 'ezs sync --abort'.
+type SyncState struct {
+	Stacks    []string          `json:"stacks"`               // hashes of the stacks selected for this sync
+	AllStacks bool              `json:"all_stacks,omitempty"` // keep going in other stacks after a conflict
+	Autostash bool              `json:"autostash,omitempty"`
+	OldHeads  map[string]string `json:"old_heads"`           // branch -> commit before the sync started
+	Completed []string          `json:"completed,omitempty"` // branches already processed, in order
+	Conflicts []SyncConflict    `json:"conflicts,omitempty"`
+	Running   []SyncConflict    `json:"running,omitempty"` // rebases under way, left behind if ezs dies mid-rebase
+
+	mu sync.Mutex // stacks synced in parallel share the state
+}
+
+// SyncConflict describes a branch whose rebase stopped on a conflict, or one
+// being rebased when the sync was interrupted
+type SyncConflict struct {
+	Branch       string `json:"branch"`
+	WorktreePath string `json:"worktree_path"`
+	SyncedParent string `json:"synced_parent,omitempty"`
+	Stashed      bool   `json:"stashed,omitempty"`  // autostash entry waiting to be popped
+	Temp         bool   `json:"temp,omitempty"`     // WorktreePath is a temporary worktree
+	Detached     bool   `json:"detached,omitempty"` // the branch was detached in WorktreePath for a rebase of its stack
+}
+
+// IsCompleted reports whether the branch was already processed by this sync
+func (s *SyncState) IsCompleted(branchName string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isCompleted(branchName)
+}
+
+func (s *SyncState) isCompleted(branchName string) bool {
+	for _, name := range s.Completed {
+		if name == branchName {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkCompleted records that the branch was processed by this sync
+func (s *SyncState) MarkCompleted(branchName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isCompleted(branchName) {
+		s.Completed = append(s.Completed, branchName)
+	}
+}
+
+// AddConflict records a conflicted branch. The branch is no longer considered
+// completed until the conflict is resolved and the sync is continued.
+func (s *SyncState) AddConflict(conflict SyncConflict) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retry(conflict.Branch)
+	s.Conflicts = append(s.Conflicts, conflict)
+	s.finishRebase(conflict.Branch)
+}
+
+// StartRebase records that a branch is being rebased, replacing what was
+// recorded for it before, e.g. once its worktree is known
+func (s *SyncState) StartRebase(rebase SyncConflict) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finishRebase(rebase.Branch)
+	s.Running = append(s.Running, rebase)
+}
+
+// FinishRebase records that a branch's rebase is over, reporting whether one
+// was recorded
+func (s *SyncState) FinishRebase(branchName string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.finishRebase(branchName)
+}
+
+func (s *SyncState) finishRebase(branchName string) bool {
+	for i, r := range s.Running {
+		if r.Branch == branchName {
+			s.Running = append(s.Running[:i], s.Running[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Retry removes a branch from the completed ones, so the sync processes it again
+func (s *SyncState) Retry(branchName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retry(branchName)
+}
+
+func (s *SyncState) retry(branchName string) {
+	completed := s.Completed[:0]
+	for _, name := range s.Completed {
+		if name != branchName {
+			completed = append(completed, name)
+		}
+	}
+	s.Completed = completed
+}
+
+// LoadSyncState returns the in-flight sync for a repo, or nil if there is none
+func LoadSyncState(repoDir string) (*SyncState, error) {
+	state := &SyncState{}
+	found, err := syncStates.load(repoDir, state)
+	if err != nil || !found {
+		return nil, err
+	}
+	return state, nil
+}
+
+// SaveSyncState persists the in-flight sync for a repo to its sync-state.json
+func SaveSyncState(repoDir string, state *SyncState) error {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return syncStates.save(repoDir, state)
+}
+
+// ClearSyncState removes the in-flight sync for a repo
+func ClearSyncState(repoDir string) error {
+	return syncStates.clear(repoDir)
+}
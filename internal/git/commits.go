@@ -86,3 +86,15 @@ func (g *Git) PushSetUpstream() error {
 	}
 	return g.RunInteractive("push", "-u", "origin", branch)
 }
+
+// PushBranch pushes a branch to origin and sets its upstream. With force the
+// push uses --force-with-lease, so commits someone else pushed are not lost.
+func (g *Git) PushBranch(branch string, force bool) error {
+	args := []string{"push", "-u"}
+	if force {
+		args = append(args, "--force-with-lease")
+	}
+	args = append(args, "origin", branch)
+	_, err := g.runWithSpinner(fmt.Sprintf("Pushing %s...", branch), args...)
+	return err
+}
@@ -120,6 +120,27 @@ func (g *Git) CheckoutBranch(branchName string) error {
 	return err
 }
 
+// DetachHead detaches HEAD at its current commit, leaving files and index as
+// they are, so the branch is no longer checked out here
+func (g *Git) DetachHead() error {
+	_, err := g.run("checkout", "-q", "--detach")
+	return err
+}
+
+// VersionAtLeast reports whether the installed git is at least major.minor
+func (g *Git) VersionAtLeast(major, minor int) bool {
+	output, err := g.run("version")
+	if err != nil {
+		return false
+	}
+	// "git version 2.39.5" or e.g. "git version 2.39.3 (Apple Git-146)"
+	var gotMajor, gotMinor int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(output, "git version "), "%d.%d", &gotMajor, &gotMinor); err != nil {
+		return false
+	}
+	return gotMajor > major || (gotMajor == major && gotMinor >= minor)
+}
+
 // CreateWorktree creates a new worktree
 func (g *Git) CreateWorktree(branchName, worktreePath, baseBranch string) error {
 	// First create the branch from baseBranch
@@ -305,6 +326,28 @@ func (g *Git) ListLocalBranches() ([]string, error) {
 	return strings.Split(output, "\n"), nil
 }
 
+// ListBranchCommits returns the commit every local branch points to
+func (g *Git) ListBranchCommits() (map[string]string, error) {
+	output, err := g.run("for-each-ref", "--format=%(refname:short) %(objectname)", "refs/heads/")
+	if err != nil {
+		return nil, err
+	}
+	commits := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if name, commit, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+			commits[name] = commit
+		}
+	}
+	return commits, nil
+}
+
+// SetBranchRef points a local branch at the given commit, creating it if needed.
+// The branch must not be checked out in any worktree.
+func (g *Git) SetBranchRef(branch, commit string) error {
+	_, err := g.run("update-ref", "refs/heads/"+branch, commit)
+	return err
+}
+
 // BranchExists checks if a local branch exists
 func (g *Git) BranchExists(branch string) bool {
 	_, err := g.run("rev-parse", "--verify", branch)
@@ -365,42 +408,29 @@ type RebaseResult struct {
 // RebaseNonInteractive rebases current branch onto target without interactive mode
 // Returns structured result instead of just error for better conflict handling
 func (g *Git) RebaseNonInteractive(target string) RebaseResult {
-	spinner := ui.NewDelayedSpinner(fmt.Sprintf("Rebasing onto %s...", target))
-	spinner.Start()
-	defer spinner.Stop()
-
-	cmd := exec.Command("git", "rebase", target)
-	cmd.Dir = g.RepoDir
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-	if err != nil {
-		// Check if it's a conflict
-		stderrStr := stderr.String()
-		if strings.Contains(stderrStr, "CONFLICT") ||
-			strings.Contains(stderrStr, "could not apply") ||
-			strings.Contains(stderrStr, "Resolve all conflicts") {
-			return RebaseResult{HasConflict: true, Error: fmt.Errorf("rebase conflict")}
-		}
-		// Check if rebase is in progress
-		inProgress, _ := g.IsRebaseInProgress()
-		if inProgress {
-			return RebaseResult{HasConflict: true, Error: fmt.Errorf("rebase conflict")}
-		}
-		return RebaseResult{Error: fmt.Errorf("rebase failed: %s", stderrStr)}
-	}
-	return RebaseResult{Success: true}
+	return g.rebaseNonInteractive(target, target)
 }
 
 // RebaseOntoNonInteractive rebases commits from oldBase to current onto newBase
 // Returns structured result for better conflict handling
 func (g *Git) RebaseOntoNonInteractive(newBase, oldBase string) RebaseResult {
-	spinner := ui.NewDelayedSpinner(fmt.Sprintf("Rebasing onto %s...", newBase))
+	return g.rebaseNonInteractive(newBase, "--onto", newBase, oldBase)
+}
+
+// RebaseUpdateRefsNonInteractive rebases current branch onto target with
+// --update-refs, so branches pointing at rebased commits move along with it.
+// Branches checked out in other worktrees are not moved by git.
+func (g *Git) RebaseUpdateRefsNonInteractive(target string) RebaseResult {
+	return g.rebaseNonInteractive(target, "--update-refs", target)
+}
+
+// rebaseNonInteractive runs git rebase with args and classifies the outcome
+func (g *Git) rebaseNonInteractive(target string, args ...string) RebaseResult {
+	spinner := ui.NewDelayedSpinner(fmt.Sprintf("Rebasing onto %s...", target))
 	spinner.Start()
 	defer spinner.Stop()
 
-	cmd := exec.Command("git", "rebase", "--onto", newBase, oldBase)
+	cmd := exec.Command("git", append([]string{"rebase"}, args...)...)
 	cmd.Dir = g.RepoDir
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
@@ -429,6 +459,12 @@ func (g *Git) Rebase(target string) error {
 	return g.RunInteractive("rebase", target)
 }
 
+// RebaseAbort aborts an in-progress rebase, restoring the branch to its original commit
+func (g *Git) RebaseAbort() error {
+	_, err := g.run("rebase", "--abort")
+	return err
+}
+
 // StashPush stashes all changes including untracked files
 func (g *Git) StashPush() error {
 	_, err := g.run("stash", "push", "-u", "-m", "ezstack-autostash")
@@ -469,6 +505,12 @@ func (g *Git) PushForce() error {
 	if err != nil {
 		return fmt.Errorf("failed to get current branch: %w", err)
 	}
+	return g.PushForceBranch(branch)
+}
+
+// PushForceBranch force pushes a branch by name with --force-with-lease, so
+// it works for branches that aren't checked out here
+func (g *Git) PushForceBranch(branch string) error {
 	return g.RunInteractive("push", "--force-with-lease", "origin", branch)
 }
 
@@ -0,0 +1,230 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/ui"
+)
+
+// Hunk is one hunk of a unified diff
+type Hunk struct {
+	Lines   []string // "@@" header followed by the hunk body
+	Removed []int    // old-file line numbers removed by the hunk
+	Context []int    // old-file line numbers of context lines
+}
+
+// FilePatch is the diff of a single file, split into hunks
+type FilePatch struct {
+	Path        string
+	Header      []string // "diff --git" through "+++" lines
+	Hunks       []Hunk
+	Unsupported string // non-empty when the file can't be split into hunks (new, deleted, binary, ...)
+}
+
+// Patch renders the file header followed by the given hunks
+func (fp *FilePatch) Patch(hunks []Hunk) string {
+	var sb strings.Builder
+	for _, line := range fp.Header {
+		sb.WriteString(line + "\n")
+	}
+	for _, h := range hunks {
+		for _, line := range h.Lines {
+			sb.WriteString(line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// StagedPatches returns the staged changes of the worktree, one entry per file
+func (g *Git) StagedPatches() ([]FilePatch, error) {
+	cmd := exec.Command("git", "diff", "--cached", "--full-index", "--no-color", "--no-ext-diff", "--no-renames", "-U1")
+	cmd.Dir = g.RepoDir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git diff --cached failed: %s\n%s", err, stderr.String())
+	}
+	return ParsePatch(stdout.String()), nil
+}
+
+// ParsePatch splits the output of git diff into per-file patches and hunks
+func ParsePatch(diff string) []FilePatch {
+	var patches []FilePatch
+	var cur *FilePatch
+	var hunk *Hunk
+	oldLine := 0
+
+	flushHunk := func() {
+		if cur != nil && hunk != nil {
+			cur.Hunks = append(cur.Hunks, *hunk)
+		}
+		hunk = nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	for _, line := range lines {
+		if strings.HasPrefix(line, "diff --git ") {
+			flushHunk()
+			patches = append(patches, FilePatch{Header: []string{line}})
+			cur = &patches[len(patches)-1]
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+
+		if hunk == nil && !strings.HasPrefix(line, "@@") {
+			cur.Header = append(cur.Header, line)
+			switch {
+			case strings.HasPrefix(line, "+++ "):
+				cur.Path = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+				if strings.HasPrefix(cur.Path, "\"") {
+					cur.Unsupported = "quoted path"
+				}
+			case strings.HasPrefix(line, "new file mode"):
+				cur.Unsupported = "new file"
+			case strings.HasPrefix(line, "deleted file mode"):
+				cur.Unsupported = "deleted file"
+			case strings.HasPrefix(line, "old mode"), strings.HasPrefix(line, "new mode"):
+				cur.Unsupported = "mode change"
+			case strings.HasPrefix(line, "Binary files"):
+				cur.Unsupported = "binary file"
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "@@") {
+			flushHunk()
+			hunk = &Hunk{Lines: []string{line}}
+			oldLine = parseHunkOldStart(line)
+			continue
+		}
+
+		hunk.Lines = append(hunk.Lines, line)
+		switch {
+		case strings.HasPrefix(line, " "):
+			hunk.Context = append(hunk.Context, oldLine)
+			oldLine++
+		case strings.HasPrefix(line, "-"):
+			hunk.Removed = append(hunk.Removed, oldLine)
+			oldLine++
+		}
+	}
+	flushHunk()
+
+	for i := range patches {
+		if patches[i].Path == "" && patches[i].Unsupported == "" {
+			patches[i].Unsupported = "no textual changes"
+		}
+	}
+	return patches
+}
+
+// parseHunkOldStart extracts the old-file start line from "@@ -a,b +c,d @@"
+func parseHunkOldStart(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 2 {
+		return 0
+	}
+	old := strings.TrimPrefix(fields[1], "-")
+	if i := strings.Index(old, ","); i >= 0 {
+		old = old[:i]
+	}
+	n, _ := strconv.Atoi(old)
+	return n
+}
+
+// BlameCommits returns the distinct commits that last touched the given lines of path at rev
+func (g *Git) BlameCommits(rev, path string, lines []int) ([]string, error) {
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	args := []string{"blame", "--porcelain"}
+	for _, n := range lines {
+		args = append(args, "-L", fmt.Sprintf("%d,%d", n, n))
+	}
+	args = append(args, rev, "--", path)
+	output, err := g.run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var commits []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		// Line headers are "<sha> <orig-line> <final-line> [<count>]"
+		if len(fields) < 3 || len(fields[0]) != 40 || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		if !seen[fields[0]] {
+			seen[fields[0]] = true
+			commits = append(commits, fields[0])
+		}
+	}
+	return commits, nil
+}
+
+// ApplyPatchIndex applies a patch to the index and working tree, falling back
+// to a three-way merge when the surrounding lines have moved
+func (g *Git) ApplyPatchIndex(patch string) error {
+	f, err := os.CreateTemp("", "ezstack-*.patch")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(patch); err != nil {
+		f.Close()
+		return err
+	}
+	f.Close()
+
+	_, err = g.run("apply", "--3way", "--index", f.Name())
+	return err
+}
+
+// CommitFixup commits the staged changes as a fixup! commit for the given commit
+func (g *Git) CommitFixup(commit string) error {
+	_, err := g.run("commit", "--fixup="+commit)
+	return err
+}
+
+// RebaseAutosquash squashes fixup! commits into their targets, replaying the
+// commits after upstream without opening an editor
+func (g *Git) RebaseAutosquash(upstream string) RebaseResult {
+	spinner := ui.NewDelayedSpinner("Squashing fixups...")
+	spinner.Start()
+	defer spinner.Stop()
+
+	cmd := exec.Command("git", "rebase", "-i", "--autosquash", upstream)
+	cmd.Dir = g.RepoDir
+	cmd.Env = append(os.Environ(), "GIT_SEQUENCE_EDITOR=:")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		stderrStr := stderr.String()
+		if strings.Contains(stderrStr, "CONFLICT") ||
+			strings.Contains(stderrStr, "could not apply") ||
+			strings.Contains(stderrStr, "Resolve all conflicts") {
+			return RebaseResult{HasConflict: true, Error: fmt.Errorf("rebase conflict")}
+		}
+		inProgress, _ := g.IsRebaseInProgress()
+		if inProgress {
+			return RebaseResult{HasConflict: true, Error: fmt.Errorf("rebase conflict")}
+		}
+		return RebaseResult{Error: fmt.Errorf("rebase failed: %s", stderrStr)}
+	}
+	return RebaseResult{Success: true}
+}
@@ -0,0 +1,100 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const samplePatch = `diff --git a/app.go b/app.go
+index 1111111111111111111111111111111111111111..2222222222222222222222222222222222222222 100644
+--- a/app.go
++++ b/app.go
+@@ -3,3 +3,3 @@ package app
+ func a() {
+-	return 1
++	return 2
+ }
+@@ -10,0 +11,1 @@ func b() {
+ 	x := 1
++	y := 2
+diff --git a/new.go b/new.go
+new file mode 100644
+index 0000000000000000000000000000000000000000..3333333333333333333333333333333333333333
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1 @@
++package app
+diff --git a/logo.png b/logo.png
+index 4444444444444444444444444444444444444444..5555555555555555555555555555555555555555 100644
+Binary files a/logo.png and b/logo.png differ
+`
+
+func TestParsePatch(t *testing.T) {
+	patches := ParsePatch(samplePatch)
+	if len(patches) != 3 {
+		t.Fatalf("ParsePatch() returned %d files, want 3", len(patches))
+	}
+
+	app := patches[0]
+	if app.Path != "app.go" || app.Unsupported != "" {
+		t.Errorf("app.go parsed as path=%q unsupported=%q", app.Path, app.Unsupported)
+	}
+	if len(app.Header) != 4 {
+		t.Errorf("app.go header has %d lines, want 4", len(app.Header))
+	}
+	if len(app.Hunks) != 2 {
+		t.Fatalf("app.go has %d hunks, want 2", len(app.Hunks))
+	}
+	if !reflect.DeepEqual(app.Hunks[0].Removed, []int{4}) || !reflect.DeepEqual(app.Hunks[0].Context, []int{3, 5}) {
+		t.Errorf("hunk 0 removed=%v context=%v", app.Hunks[0].Removed, app.Hunks[0].Context)
+	}
+	if len(app.Hunks[1].Removed) != 0 || !reflect.DeepEqual(app.Hunks[1].Context, []int{10}) {
+		t.Errorf("hunk 1 removed=%v context=%v", app.Hunks[1].Removed, app.Hunks[1].Context)
+	}
+
+	if patches[1].Unsupported != "new file" {
+		t.Errorf("new.go Unsupported = %q, want %q", patches[1].Unsupported, "new file")
+	}
+	if patches[2].Unsupported != "binary file" {
+		t.Errorf("logo.png Unsupported = %q, want %q", patches[2].Unsupported, "binary file")
+	}
+
+	// Rendering only the first hunk keeps the header
+	rendered := app.Patch(app.Hunks[:1])
+	if !strings.HasPrefix(rendered, "diff --git a/app.go b/app.go\n") || strings.Contains(rendered, "y := 2") {
+		t.Errorf("Patch() rendered unexpected content:\n%s", rendered)
+	}
+}
+
+func TestBlameCommits(t *testing.T) {
+	repoDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	g := New(repoDir)
+	path := filepath.Join(repoDir, "file.txt")
+	os.WriteFile(path, []byte("one\ntwo\n"), 0644)
+	exec.Command("git", "-C", repoDir, "add", ".").Run()
+	exec.Command("git", "-C", repoDir, "commit", "-m", "first").Run()
+	first, _ := g.GetBranchCommit("HEAD")
+
+	os.WriteFile(path, []byte("one\ntwo\nthree\n"), 0644)
+	exec.Command("git", "-C", repoDir, "commit", "-am", "second").Run()
+	second, _ := g.GetBranchCommit("HEAD")
+
+	commits, err := g.BlameCommits("HEAD", "file.txt", []int{1, 2})
+	if err != nil {
+		t.Fatalf("BlameCommits() error = %v", err)
+	}
+	if !reflect.DeepEqual(commits, []string{first}) {
+		t.Errorf("BlameCommits(1,2) = %v, want [%s]", commits, first)
+	}
+
+	commits, _ = g.BlameCommits("HEAD", "file.txt", []int{2, 3})
+	if len(commits) != 2 || commits[0] != first || commits[1] != second {
+		t.Errorf("BlameCommits(2,3) = %v, want [%s %s]", commits, first, second)
+	}
+}
@@ -0,0 +1,110 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runWithInput executes a git command with input on stdin and returns the output
+func (g *Git) runWithInput(input []byte, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.RepoDir
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %s\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// CommitFile writes a commit whose tree holds a single file with the given
+// contents, on top of parent (no parent if empty). Returns the commit hash.
+// Nothing is checked out or staged.
+func (g *Git) CommitFile(name string, data []byte, parent, message string) (string, error) {
+	blob, err := g.runWithInput(data, "hash-object", "-w", "--stdin")
+	if err != nil {
+		return "", err
+	}
+	tree, err := g.runWithInput([]byte(fmt.Sprintf("100644 blob %s\t%s\n", blob, name)), "mktree")
+	if err != nil {
+		return "", err
+	}
+	args := []string{"commit-tree", tree, "-m", message}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	return g.run(args...)
+}
+
+// ReadFileAt returns the contents of a file in the tree of rev
+func (g *Git) ReadFileAt(rev, name string) ([]byte, error) {
+	cmd := exec.Command("git", "cat-file", "blob", rev+":"+name)
+	cmd.Dir = g.RepoDir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git cat-file blob %s:%s failed: %s\n%s", rev, name, err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+// ListRefs returns the object every ref under prefix (e.g. "refs/ezstack/") points to, keyed by full ref name
+func (g *Git) ListRefs(prefix string) (map[string]string, error) {
+	output, err := g.run("for-each-ref", "--format=%(refname) %(objectname)", prefix)
+	if err != nil {
+		return nil, err
+	}
+	refs := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if name, object, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+			refs[name] = object
+		}
+	}
+	return refs, nil
+}
+
+// UpdateRef points ref at commit, creating it if needed
+func (g *Git) UpdateRef(ref, commit string) error {
+	_, err := g.run("update-ref", ref, commit)
+	return err
+}
+
+// FetchRefs fetches refspecs from origin, e.g. "+refs/ezstack/*:refs/ezstack/*"
+func (g *Git) FetchRefs(refspecs ...string) error {
+	args := append([]string{"fetch", "origin"}, refspecs...)
+	_, err := g.runWithSpinner("Fetching from remote...", args...)
+	return err
+}
+
+// PushRefs pushes refspecs to origin in one push and returns the destination
+// refs the remote rejected (e.g. because they are not fast-forwards). err is
+// only set when the push failed for another reason.
+func (g *Git) PushRefs(refspecs ...string) (rejected []string, err error) {
+	args := append([]string{"push", "--porcelain", "origin"}, refspecs...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.RepoDir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	// Porcelain lines look like "!\t<src>:<dst>\t[rejected] (fetch first)"
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 || fields[0] != "!" {
+			continue
+		}
+		if _, dst, ok := strings.Cut(fields[1], ":"); ok {
+			rejected = append(rejected, dst)
+		}
+	}
+	if runErr != nil && len(rejected) == 0 {
+		return nil, fmt.Errorf("git %s failed: %s\n%s", strings.Join(args, " "), runErr, stderr.String())
+	}
+	return rejected, nil
+}
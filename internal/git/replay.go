@@ -0,0 +1,220 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ErrReplayConflict is returned by Replay when a commit doesn't apply cleanly
+var ErrReplayConflict = errors.New("commits don't apply cleanly")
+
+// ReplayConflict is the error Replay returns for the first commit that
+// doesn't apply cleanly. It matches ErrReplayConflict with errors.Is.
+type ReplayConflict struct {
+	Commit string
+	Files  []string // conflicted paths
+}
+
+func (e *ReplayConflict) Error() string {
+	return fmt.Sprintf("commit %.7s conflicts in %s", e.Commit, strings.Join(e.Files, ", "))
+}
+
+func (e *ReplayConflict) Is(target error) bool {
+	return target == ErrReplayConflict
+}
+
+// ErrReplayUnsupported is returned by Replay for ranges it can't rebuild in
+// memory, e.g. ones containing merge commits
+var ErrReplayUnsupported = errors.New("range can't be replayed in memory")
+
+// Replay rebuilds the commits of branch after upstream on top of newBase
+// without checking anything out, like 'git rebase --onto newBase upstream
+// branch' (an empty upstream means newBase). Each commit is cherry-picked with
+// 'git merge-tree --write-tree' and recreated with its author and message.
+// Commits that become empty are dropped, as rebase does. Returns the new head;
+// no ref is changed. On error nothing has been changed, so callers can fall
+// back to a regular rebase.
+func (g *Git) Replay(branch, newBase, upstream string) (string, error) {
+	if upstream == "" {
+		upstream = newBase
+	}
+	base, err := g.run("rev-parse", "--verify", newBase+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+
+	merges, err := g.run("rev-list", "--merges", upstream+".."+branch)
+	if err != nil {
+		return "", err
+	}
+	if merges != "" {
+		return "", ErrReplayUnsupported
+	}
+	output, err := g.run("rev-list", "--reverse", "--topo-order", upstream+".."+branch)
+	if err != nil {
+		return "", err
+	}
+
+	head := base
+	for _, commit := range strings.Fields(output) {
+		head, err = g.replayCommit(commit, head)
+		if err != nil {
+			return "", err
+		}
+	}
+	return head, nil
+}
+
+// replayCommit cherry-picks commit onto head and returns the new head
+func (g *Git) replayCommit(commit, head string) (string, error) {
+	parent, err := g.run("rev-parse", "--verify", "--quiet", commit+"^")
+	if err != nil {
+		return "", ErrReplayUnsupported // root commit
+	}
+	if parent == head {
+		return commit, nil // already in place
+	}
+
+	// merge-tree before git 2.40 can't be given a merge base, so merge two
+	// throwaway commits whose only common ancestor is the commit's parent
+	ours, err := g.run("commit-tree", head+"^{tree}", "-p", parent, "-m", "ezstack replay")
+	if err != nil {
+		return "", err
+	}
+	theirs, err := g.run("commit-tree", commit+"^{tree}", "-p", parent, "-m", "ezstack replay")
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("git", "merge-tree", "--write-tree", "--no-messages", "--name-only", "-z", ours, theirs)
+	cmd.Dir = g.RepoDir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	// The tree, then on conflict the conflicted paths, NUL-separated
+	fields := strings.Split(stdout.String(), "\x00")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			conflict := &ReplayConflict{Commit: commit}
+			seen := make(map[string]bool)
+			for _, f := range fields[1:] {
+				if f != "" && !seen[f] {
+					seen[f] = true
+					conflict.Files = append(conflict.Files, f)
+				}
+			}
+			return "", conflict
+		}
+		return "", fmt.Errorf("git merge-tree failed: %s\n%s", err, stderr.String())
+	}
+	tree := strings.TrimSpace(fields[0])
+
+	// Drop commits that became empty, but keep ones that were empty to begin with
+	headTree, err := g.run("rev-parse", head+"^{tree}")
+	if err != nil {
+		return "", err
+	}
+	if tree == headTree {
+		parentTree, _ := g.run("rev-parse", parent+"^{tree}")
+		commitTree, _ := g.run("rev-parse", commit+"^{tree}")
+		if parentTree != commitTree {
+			return head, nil
+		}
+	}
+
+	return g.recommit(commit, tree, head)
+}
+
+// recommit writes a commit with the given tree and parent, copying the
+// author and message of commit. The committer is the current user, as with
+// rebase.
+func (g *Git) recommit(commit, tree, parent string) (string, error) {
+	raw, err := g.run("cat-file", "commit", commit)
+	if err != nil {
+		return "", err
+	}
+	header, message, _ := strings.Cut(raw, "\n\n")
+
+	env := os.Environ()
+	for _, line := range strings.Split(header, "\n") {
+		author, ok := strings.CutPrefix(line, "author ")
+		if !ok {
+			continue
+		}
+		// author Name <email> 1700000000 +0100
+		lt, gt := strings.Index(author, "<"), strings.LastIndex(author, ">")
+		if lt < 0 || gt < lt {
+			break
+		}
+		env = append(env,
+			"GIT_AUTHOR_NAME="+strings.TrimSpace(author[:lt]),
+			"GIT_AUTHOR_EMAIL="+author[lt+1:gt],
+			"GIT_AUTHOR_DATE="+strings.TrimSpace(author[gt+1:]),
+		)
+	}
+
+	cmd := exec.Command("git", "commit-tree", tree, "-p", parent, "-F", "-")
+	cmd.Dir = g.RepoDir
+	cmd.Env = env
+	cmd.Stdin = strings.NewReader(message + "\n")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git commit-tree failed: %s\n%s", err, stderr.String())
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// UpdateBranchRef moves a local branch from oldHead to newHead in a single
+// compare-and-swap, failing if the branch no longer points at oldHead
+func (g *Git) UpdateBranchRef(branch, newHead, oldHead string) error {
+	_, err := g.run("update-ref", "-m", "ezstack: restack", "refs/heads/"+branch, newHead, oldHead)
+	return err
+}
+
+// RestackBranch rebases branch like 'git rebase --onto newBase upstream'
+// without a checkout: the commits are replayed in memory and the branch ref is
+// updated atomically. worktree is where the branch is checked out, or empty;
+// its index and files are then moved from the old head to the new one the way
+// 'git checkout' would, keeping uncommitted changes that don't overlap. Any
+// error leaves the branch and worktree as they were.
+func (g *Git) RestackBranch(branch, newBase, upstream, worktree string) error {
+	oldHead, err := g.GetBranchCommit(branch)
+	if err != nil {
+		return err
+	}
+	if worktree != "" {
+		wt := New(worktree)
+		if ref, err := wt.run("symbolic-ref", "-q", "HEAD"); err != nil || ref != "refs/heads/"+branch {
+			return fmt.Errorf("'%s' is not checked out in %s", branch, worktree)
+		}
+	}
+
+	newHead, err := g.Replay(branch, newBase, upstream)
+	if err != nil {
+		return err
+	}
+	if newHead == oldHead {
+		return nil
+	}
+	if err := g.UpdateBranchRef(branch, newHead, oldHead); err != nil {
+		return err
+	}
+
+	if worktree != "" {
+		if _, err := New(worktree).run("read-tree", "-m", "-u", oldHead, newHead); err != nil {
+			if rbErr := g.UpdateBranchRef(branch, oldHead, newHead); rbErr != nil {
+				return fmt.Errorf("failed to update %s (%v) and to restore %s: %w", worktree, err, branch, rbErr)
+			}
+			return err
+		}
+	}
+	return nil
+}
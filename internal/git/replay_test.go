@@ -0,0 +1,120 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitIn(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		t.Fatalf("git %v failed: %v", args, err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func commitIn(t *testing.T, dir, name, content string) {
+	t.Helper()
+	os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	gitIn(t, dir, "add", name)
+	gitIn(t, dir, "commit", "-q", "-m", "edit "+name)
+}
+
+// setupReplayRepo creates a feature branch checked out in a worktree with two
+// commits, then moves the default branch ahead with one commit touching file
+func setupReplayRepo(t *testing.T, file string) (dir, wt, base string, cleanup func()) {
+	t.Helper()
+	dir, cleanup = setupTestRepo(t)
+	base = gitIn(t, dir, "rev-parse", "--abbrev-ref", "HEAD")
+
+	wt = filepath.Join(dir, "wt")
+	gitIn(t, dir, "worktree", "add", "-q", "-b", "feature", wt)
+	gitIn(t, wt, "-c", "user.name=Feature Author", "-c", "user.email=author@test.com", "commit", "-q", "--allow-empty", "-m", "empty")
+	os.WriteFile(filepath.Join(wt, "a.txt"), []byte("feature\n"), 0644)
+	gitIn(t, wt, "add", "a.txt")
+	gitIn(t, wt, "-c", "user.name=Feature Author", "-c", "user.email=author@test.com", "commit", "-q", "-m", "add a.txt\n\nwith a body")
+
+	commitIn(t, dir, file, "main\n")
+	return dir, wt, base, cleanup
+}
+
+func TestRestackBranch(t *testing.T) {
+	dir, wt, base, cleanup := setupReplayRepo(t, "b.txt")
+	defer cleanup()
+	oldHead := gitIn(t, dir, "rev-parse", "feature")
+
+	// Uncommitted changes that don't overlap survive, like with checkout
+	os.WriteFile(filepath.Join(wt, "README.md"), []byte("local edit\n"), 0644)
+
+	g := New(dir)
+	if err := g.RestackBranch("feature", base, "", wt); err != nil {
+		t.Fatalf("RestackBranch() error = %v", err)
+	}
+
+	if err := exec.Command("git", "-C", dir, "merge-base", "--is-ancestor", base, "feature").Run(); err != nil {
+		t.Errorf("feature is not based on %s", base)
+	}
+	if n := gitIn(t, dir, "rev-list", "--count", base+"..feature"); n != "2" {
+		t.Errorf("feature has %s commits on %s, want 2 (empty commit kept)", n, base)
+	}
+	if got := gitIn(t, dir, "log", "-1", "--format=%an <%ae>|%B", "feature"); got != "Feature Author <author@test.com>|add a.txt\n\nwith a body" {
+		t.Errorf("author and message not preserved: %q", got)
+	}
+	if got := gitIn(t, dir, "reflog", "-1", "--format=%gs", "feature"); got != "ezstack: restack" {
+		t.Errorf("last reflog entry = %q, want the restack", got)
+	}
+	if got := gitIn(t, dir, "rev-parse", "feature@{1}"); got != oldHead {
+		t.Errorf("feature@{1} = %s, want the old head %s", got, oldHead)
+	}
+
+	if head := gitIn(t, wt, "rev-parse", "HEAD"); head != gitIn(t, dir, "rev-parse", "feature") {
+		t.Errorf("worktree HEAD = %s, want the new feature head", head)
+	}
+	if _, err := os.Stat(filepath.Join(wt, "b.txt")); err != nil {
+		t.Error("worktree files were not updated to the new head")
+	}
+	if status := gitIn(t, wt, "status", "--porcelain"); status != "M README.md" {
+		t.Errorf("worktree status = %q, want only the local README.md edit", status)
+	}
+}
+
+func TestRestackBranch_Conflict(t *testing.T) {
+	dir, wt, base, cleanup := setupReplayRepo(t, "a.txt")
+	defer cleanup()
+	oldHead := gitIn(t, dir, "rev-parse", "feature")
+
+	err := New(dir).RestackBranch("feature", base, "", wt)
+	if !errors.Is(err, ErrReplayConflict) {
+		t.Fatalf("RestackBranch() error = %v, want ErrReplayConflict", err)
+	}
+	var conflict *ReplayConflict
+	if !errors.As(err, &conflict) || strings.Join(conflict.Files, ",") != "a.txt" {
+		t.Errorf("RestackBranch() error = %v, want a conflict in a.txt", err)
+	}
+	if head := gitIn(t, dir, "rev-parse", "feature"); head != oldHead {
+		t.Errorf("feature moved to %s on conflict", head)
+	}
+	if status := gitIn(t, wt, "status", "--porcelain"); status != "" {
+		t.Errorf("worktree changed on conflict: %q", status)
+	}
+}
+
+func TestReplay_UpToDate(t *testing.T) {
+	dir, _, base, cleanup := setupReplayRepo(t, "b.txt")
+	defer cleanup()
+
+	g := New(dir)
+	head := gitIn(t, dir, "rev-parse", "feature")
+	got, err := g.Replay("feature", base+"~1", "")
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if got != head {
+		t.Errorf("Replay() onto the current base = %s, want the unchanged head %s", got, head)
+	}
+}
@@ -0,0 +1,558 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+)
+
+// DefaultAPIURL is the REST API base URL for github.com
+const DefaultAPIURL = "https://api.github.com"
+
+// APIClient talks to the GitHub REST and GraphQL APIs directly instead of
+// spawning gh. It is used whenever a token is available.
+type APIClient struct {
+	apiURL string // REST base, e.g. https://api.github.com or https://ghe.example.com/api/v3
+	owner  string
+	repo   string
+	token  string
+	http   *http.Client
+
+	stackStyle StackOptions
+}
+
+// Ensure APIClient implements ClientInterface
+var _ ClientInterface = (*APIClient)(nil)
+
+// Token returns the GitHub token from GITHUB_TOKEN, falling back to the
+// github_token config key
+func Token() string {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	if cfg, err := config.Load(); err == nil {
+		return cfg.GitHubToken
+	}
+	return ""
+}
+
+// ParseRepo extracts owner and repo from a remote URL on the given host
+// (github.com when empty)
+func ParseRepo(remoteURL, host string) (owner, repo string, err error) {
+	if host == "" {
+		host = "github.com"
+	}
+	re := regexp.MustCompile(regexp.QuoteMeta(host) + `[:/]([^/]+)/([^/.]+)`)
+	matches := re.FindStringSubmatch(remoteURL)
+	if len(matches) != 3 {
+		return "", "", fmt.Errorf("could not parse GitHub URL: %s", remoteURL)
+	}
+	return matches[1], matches[2], nil
+}
+
+// NewAPIClient creates an API client for the repo behind remoteURL. apiURL
+// defaults to DefaultAPIURL; for GitHub Enterprise it is the instance's
+// /api/v3 URL and the remote is matched against that host.
+func NewAPIClient(remoteURL, apiURL, token string) (*APIClient, error) {
+	host := ""
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	} else if u, err := url.Parse(apiURL); err == nil && u.Hostname() != "api.github.com" {
+		host = u.Hostname()
+	}
+	owner, repo, err := ParseRepo(remoteURL, host)
+	if err != nil {
+		return nil, err
+	}
+	return NewAPIClientForRepo(apiURL, owner, repo, token), nil
+}
+
+// NewAPIClientForRepo creates an API client for owner/repo against an explicit API base URL
+func NewAPIClientForRepo(apiURL, owner, repo, token string) *APIClient {
+	return &APIClient{
+		apiURL: strings.TrimRight(apiURL, "/"),
+		owner:  owner,
+		repo:   repo,
+		token:  token,
+		http:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// graphQLURL returns the GraphQL endpoint that belongs to the REST base URL
+func (c *APIClient) graphQLURL() string {
+	if strings.HasSuffix(c.apiURL, "/api/v3") {
+		return strings.TrimSuffix(c.apiURL, "/v3") + "/graphql"
+	}
+	return c.apiURL + "/graphql"
+}
+
+const prFields = `number url title body state baseRefName headRefName headRefOid mergedAt mergeable isDraft reviewDecision`
+
+// gqlPR is a pull request as returned by the GraphQL API
+type gqlPR struct {
+	Number         int    `json:"number"`
+	URL            string `json:"url"`
+	Title          string `json:"title"`
+	Body           string `json:"body"`
+	State          string `json:"state"`
+	BaseRefName    string `json:"baseRefName"`
+	HeadRefName    string `json:"headRefName"`
+	HeadRefOid     string `json:"headRefOid"`
+	MergedAt       string `json:"mergedAt"`
+	Mergeable      string `json:"mergeable"`
+	IsDraft        bool   `json:"isDraft"`
+	ReviewDecision string `json:"reviewDecision"`
+}
+
+func (p *gqlPR) toPR() *PR {
+	return &PR{
+		Number:      p.Number,
+		URL:         p.URL,
+		Title:       p.Title,
+		Body:        p.Body,
+		State:       p.State,
+		Base:        p.BaseRefName,
+		Head:        p.HeadRefName,
+		HeadSHA:     p.HeadRefOid,
+		MergedAt:    p.MergedAt,
+		Merged:      p.MergedAt != "",
+		Mergeable:   p.Mergeable,
+		IsDraft:     p.IsDraft,
+		ReviewState: p.ReviewDecision,
+	}
+}
+
+// CreatePR creates a new pull request
+func (c *APIClient) CreatePR(title, body, head, base string, draft bool) (*PR, error) {
+	var created struct {
+		Number int `json:"number"`
+	}
+	err := c.rest("POST", c.repoPath("pulls"), map[string]any{
+		"title": title,
+		"body":  body,
+		"head":  head,
+		"base":  base,
+		"draft": draft,
+	}, &created)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetPR(created.Number)
+}
+
+// GetPR gets a PR by number
+func (c *APIClient) GetPR(number int) (*PR, error) {
+	var data struct {
+		Repository struct {
+			PullRequest *gqlPR `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	query := `query($owner: String!, $repo: String!, $number: Int!) {
+  repository(owner: $owner, name: $repo) { pullRequest(number: $number) { ` + prFields + ` } }
+}`
+	if err := c.graphQL(query, map[string]any{"number": number}, &data); err != nil {
+		return nil, err
+	}
+	if data.Repository.PullRequest == nil {
+		return nil, fmt.Errorf("PR #%d not found", number)
+	}
+	return data.Repository.PullRequest.toPR(), nil
+}
+
+// GetPRByBranch gets a PR by its head branch name, preferring an open one
+func (c *APIClient) GetPRByBranch(branch string) (*PR, error) {
+	var data struct {
+		Repository struct {
+			PullRequests struct {
+				Nodes []gqlPR `json:"nodes"`
+			} `json:"pullRequests"`
+		} `json:"repository"`
+	}
+	query := `query($owner: String!, $repo: String!, $branch: String!) {
+  repository(owner: $owner, name: $repo) {
+    pullRequests(headRefName: $branch, first: 20, orderBy: {field: CREATED_AT, direction: DESC}) { nodes { ` + prFields + ` } }
+  }
+}`
+	if err := c.graphQL(query, map[string]any{"branch": branch}, &data); err != nil {
+		return nil, err
+	}
+	nodes := data.Repository.PullRequests.Nodes
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no pull requests found for branch \"%s\"", branch)
+	}
+	for i := range nodes {
+		if nodes[i].State == "OPEN" {
+			return nodes[i].toPR(), nil
+		}
+	}
+	return nodes[0].toPR(), nil
+}
+
+// checkContext is one entry of a commit's status check rollup: either a
+// check run (Status/Conclusion) or a commit status (State)
+type checkContext struct {
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	State      string `json:"state"`
+}
+
+// GetPRChecks gets the CI check status for a PR from the head commit's status check rollup
+func (c *APIClient) GetPRChecks(number int) (*CheckStatus, error) {
+	var data struct {
+		Repository struct {
+			PullRequest *struct {
+				Commits struct {
+					Nodes []struct {
+						Commit struct {
+							StatusCheckRollup *struct {
+								Contexts struct {
+									Nodes []checkContext `json:"nodes"`
+								} `json:"contexts"`
+							} `json:"statusCheckRollup"`
+						} `json:"commit"`
+					} `json:"nodes"`
+				} `json:"commits"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+	query := `query($owner: String!, $repo: String!, $number: Int!) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $number) {
+      commits(last: 1) { nodes { commit { statusCheckRollup { contexts(first: 100) { nodes {
+        ... on CheckRun { status conclusion }
+        ... on StatusContext { state }
+      } } } } } }
+    }
+  }
+}`
+	if err := c.graphQL(query, map[string]any{"number": number}, &data); err != nil || data.Repository.PullRequest == nil {
+		return &CheckStatus{State: "unknown", Summary: "checks unavailable"}, nil
+	}
+
+	var contexts []checkContext
+	for _, n := range data.Repository.PullRequest.Commits.Nodes {
+		if n.Commit.StatusCheckRollup != nil {
+			contexts = append(contexts, n.Commit.StatusCheckRollup.Contexts.Nodes...)
+		}
+	}
+	return summarizeChecks(contexts), nil
+}
+
+// summarizeChecks counts check contexts the way 'gh pr checks' buckets them
+func summarizeChecks(contexts []checkContext) *CheckStatus {
+	passed, failed, pending := 0, 0, 0
+	for _, ctx := range contexts {
+		switch {
+		case ctx.State != "":
+			switch ctx.State {
+			case "SUCCESS":
+				passed++
+			case "FAILURE", "ERROR":
+				failed++
+			default:
+				pending++
+			}
+		case ctx.Status != "COMPLETED":
+			pending++
+		default:
+			switch ctx.Conclusion {
+			case "SUCCESS", "NEUTRAL":
+				passed++
+			case "SKIPPED":
+			default:
+				failed++
+			}
+		}
+	}
+
+	total := passed + failed + pending
+	status := &CheckStatus{}
+	if total == 0 {
+		status.State = "none"
+		status.Summary = "no checks"
+	} else if failed > 0 {
+		status.State = "failure"
+		status.Summary = fmt.Sprintf("%d/%d failed", failed, total)
+	} else if pending > 0 {
+		status.State = "pending"
+		status.Summary = fmt.Sprintf("%d/%d pending", pending, total)
+	} else {
+		status.State = "success"
+		status.Summary = fmt.Sprintf("%d/%d passed", passed, total)
+	}
+	return status
+}
+
+// UpdatePR updates a PR's body
+func (c *APIClient) UpdatePR(number int, body string) error {
+	return c.rest("PATCH", c.repoPath(fmt.Sprintf("pulls/%d", number)), map[string]any{"body": body}, nil)
+}
+
+// UpdatePRBase updates a PR's base branch
+func (c *APIClient) UpdatePRBase(number int, base string) error {
+	return c.rest("PATCH", c.repoPath(fmt.Sprintf("pulls/%d", number)), map[string]any{"base": base}, nil)
+}
+
+// MergePR merges a pull request using the specified method (merge, squash, rebase)
+func (c *APIClient) MergePR(number int, method string, deleteRemoteBranch bool) error {
+	pr, err := c.GetPR(number)
+	if err != nil {
+		return err
+	}
+	if err := c.rest("PUT", c.repoPath(fmt.Sprintf("pulls/%d/merge", number)), map[string]any{"merge_method": method}, nil); err != nil {
+		return err
+	}
+	if deleteRemoteBranch {
+		return c.rest("DELETE", c.repoPath("git/refs/heads/"+pr.Head), nil, nil)
+	}
+	return nil
+}
+
+// ClosePR closes a pull request without merging, optionally leaving a comment
+func (c *APIClient) ClosePR(number int, comment string) error {
+	if comment != "" {
+		if err := c.rest("POST", c.repoPath(fmt.Sprintf("issues/%d/comments", number)), map[string]any{"body": comment}, nil); err != nil {
+			return err
+		}
+	}
+	return c.rest("PATCH", c.repoPath(fmt.Sprintf("pulls/%d", number)), map[string]any{"state": "closed"}, nil)
+}
+
+// SetPRDraft marks a PR as draft
+func (c *APIClient) SetPRDraft(number int) error {
+	return c.draftMutation(number, "convertPullRequestToDraft")
+}
+
+// SetPRReady marks a draft PR as ready for review
+func (c *APIClient) SetPRReady(number int) error {
+	return c.draftMutation(number, "markPullRequestReadyForReview")
+}
+
+// draftMutation runs a GraphQL mutation that takes the PR's node ID; the
+// REST API has no way to toggle drafts
+func (c *APIClient) draftMutation(number int, mutation string) error {
+	var pull struct {
+		NodeID string `json:"node_id"`
+	}
+	if err := c.rest("GET", c.repoPath(fmt.Sprintf("pulls/%d", number)), nil, &pull); err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`mutation($id: ID!) { %s(input: {pullRequestId: $id}) { clientMutationId } }`, mutation)
+	return c.graphQL(query, map[string]any{"id": pull.NodeID}, nil)
+}
+
+// ListPRComments returns the comments on a PR, oldest first
+func (c *APIClient) ListPRComments(number int) ([]Comment, error) {
+	var result []Comment
+	for page := 1; page <= 10; page++ {
+		var comments []Comment
+		if err := c.rest("GET", c.repoPath(fmt.Sprintf("issues/%d/comments?per_page=100&page=%d", number, page)), nil, &comments); err != nil {
+			return nil, err
+		}
+		result = append(result, comments...)
+		if len(comments) < 100 {
+			break
+		}
+	}
+	return result, nil
+}
+
+// CreatePRComment adds a comment to a PR
+func (c *APIClient) CreatePRComment(number int, body string) (*Comment, error) {
+	var comment Comment
+	if err := c.rest("POST", c.repoPath(fmt.Sprintf("issues/%d/comments", number)), map[string]any{"body": body}, &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// UpdatePRComment replaces the body of one of a PR's comments
+func (c *APIClient) UpdatePRComment(number int, commentID int64, body string) error {
+	return c.rest("PATCH", c.repoPath(fmt.Sprintf("issues/comments/%d", commentID)), map[string]any{"body": body}, nil)
+}
+
+// ListOpenPRs returns all open PRs in the repository
+func (c *APIClient) ListOpenPRs() ([]OpenPR, error) {
+	var result []OpenPR
+	for page := 1; page <= 3; page++ {
+		var pulls []struct {
+			Number  int    `json:"number"`
+			Title   string `json:"title"`
+			HTMLURL string `json:"html_url"`
+			Head    struct {
+				Ref string `json:"ref"`
+			} `json:"head"`
+			Base struct {
+				Ref string `json:"ref"`
+			} `json:"base"`
+			User struct {
+				Login string `json:"login"`
+			} `json:"user"`
+		}
+		if err := c.rest("GET", c.repoPath(fmt.Sprintf("pulls?state=open&per_page=100&page=%d", page)), nil, &pulls); err != nil {
+			return nil, err
+		}
+		for _, p := range pulls {
+			result = append(result, OpenPR{
+				Number: p.Number,
+				Title:  p.Title,
+				Branch: p.Head.Ref,
+				Base:   p.Base.Ref,
+				Author: p.User.Login,
+				URL:    p.HTMLURL,
+			})
+		}
+		if len(pulls) < 100 {
+			break
+		}
+	}
+	return result, nil
+}
+
+// UpdateStackDescription updates PR descriptions with stack info
+func (c *APIClient) UpdateStackDescription(stack *config.Stack, currentBranch string) error {
+	return UpdateStackDescriptions(c, stack, currentBranch, PRLabels, c.stackStyle)
+}
+
+// SetStackOptions sets how stack sections are rendered and where they go
+func (c *APIClient) SetStackOptions(opts StackOptions) {
+	c.stackStyle = opts
+}
+
+// EnsureCorrectBaseBranches ensures each PR's base branch matches the expected parent branch
+func (c *APIClient) EnsureCorrectBaseBranches(stack *config.Stack) error {
+	return EnsureBaseBranches(c, stack)
+}
+
+// repoPath returns a REST path under the repository, e.g. /repos/owner/repo/pulls
+func (c *APIClient) repoPath(rest string) string {
+	return fmt.Sprintf("/repos/%s/%s/%s", c.owner, c.repo, rest)
+}
+
+// rest sends a REST request and decodes the JSON response into out
+func (c *APIClient) rest(method, path string, body any, out any) error {
+	data, status, err := c.send(method, c.apiURL+path, body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case status == http.StatusUnauthorized:
+		return c.authError()
+	case status == http.StatusNotFound && strings.Count(path, "/") <= 4:
+		// A 404 on a repository-level endpoint (/repos/o/r/pulls) means no access
+		return fmt.Errorf("cannot access repository %s/%s. Check that your token has access", c.owner, c.repo)
+	case status >= 300:
+		return fmt.Errorf("GitHub %s %s failed: %d\n%s", method, path, status, apiMessage(data))
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// graphQL runs a query with $owner and $repo bound to the client's repository
+func (c *APIClient) graphQL(query string, vars map[string]any, out any) error {
+	if vars == nil {
+		vars = make(map[string]any)
+	}
+	if strings.Contains(query, "$owner") {
+		vars["owner"] = c.owner
+		vars["repo"] = c.repo
+	}
+
+	data, status, err := c.send("POST", c.graphQLURL(), map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return err
+	}
+	if status == http.StatusUnauthorized {
+		return c.authError()
+	}
+	if status >= 300 {
+		return fmt.Errorf("GitHub GraphQL request failed: %d\n%s", status, apiMessage(data))
+	}
+
+	return decodeGraphQL(data, out, c.owner+"/"+c.repo)
+}
+
+// decodeGraphQL unpacks a GraphQL response body into out, turning the first
+// reported error into a Go error
+func decodeGraphQL(data []byte, out any, repo string) error {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		e := resp.Errors[0]
+		if e.Type == "NOT_FOUND" && strings.Contains(e.Message, "repository") {
+			return fmt.Errorf("cannot access repository %s. Check that your token has access", repo)
+		}
+		return fmt.Errorf("GitHub GraphQL error: %s", e.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+// send performs an authenticated request and returns the response body and status
+func (c *APIClient) send(method, endpoint string, body any) ([]byte, int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, endpoint, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("GitHub %s %s failed: %w", method, endpoint, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return data, resp.StatusCode, err
+}
+
+func (c *APIClient) authError() error {
+	return fmt.Errorf("GitHub authentication required. Set GITHUB_TOKEN or run: ezs config set github_token <token>")
+}
+
+// apiMessage extracts the "message" field of an API error response
+func apiMessage(data []byte) string {
+	var e struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &e) == nil && e.Message != "" {
+		return e.Message
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,448 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+)
+
+// fakeGitHub is a minimal stand-in for the GitHub REST and GraphQL APIs.
+// GraphQL queries are answered by matching on the query text.
+type fakeGitHub struct {
+	mu      sync.Mutex
+	bodies  map[string]map[string]any
+	graphql func(query string, vars map[string]any) any
+	rest    map[string]any // "METHOD path" -> response
+}
+
+func newFakeGitHub(t *testing.T) (*fakeGitHub, *APIClient) {
+	t.Helper()
+	f := &fakeGitHub{bodies: make(map[string]map[string]any), rest: make(map[string]any)}
+	srv := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(srv.Close)
+	return f, NewAPIClientForRepo(srv.URL, "owner", "repo", "secret")
+}
+
+func (f *fakeGitHub) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"Bad credentials"}`))
+		return
+	}
+
+	key := r.Method + " " + r.URL.RequestURI()
+	var body map[string]any
+	json.NewDecoder(r.Body).Decode(&body)
+	f.bodies[key] = body
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/graphql" {
+		vars, _ := body["variables"].(map[string]any)
+		json.NewEncoder(w).Encode(map[string]any{"data": f.graphql(body["query"].(string), vars)})
+		return
+	}
+	resp, ok := f.rest[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"Not Found"}`))
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func prNode(number int, state, head string) map[string]any {
+	return map[string]any{
+		"number":         number,
+		"url":            fmt.Sprintf("https://github.com/owner/repo/pull/%d", number),
+		"title":          "Title",
+		"body":           "Body",
+		"state":          state,
+		"baseRefName":    "main",
+		"headRefName":    head,
+		"mergedAt":       nil,
+		"mergeable":      "MERGEABLE",
+		"isDraft":        false,
+		"reviewDecision": "APPROVED",
+	}
+}
+
+func TestAPIClient_GetPR(t *testing.T) {
+	f, c := newFakeGitHub(t)
+	f.graphql = func(query string, vars map[string]any) any {
+		if vars["owner"] != "owner" || vars["repo"] != "repo" {
+			t.Errorf("unexpected repository variables: %v", vars)
+		}
+		node := prNode(int(vars["number"].(float64)), "MERGED", "feature-a")
+		node["mergedAt"] = "2026-01-01T00:00:00Z"
+		return map[string]any{"repository": map[string]any{"pullRequest": node}}
+	}
+
+	pr, err := c.GetPR(3)
+	if err != nil {
+		t.Fatalf("GetPR failed: %v", err)
+	}
+	if pr.Number != 3 || !pr.Merged || pr.State != "MERGED" || pr.Head != "feature-a" || pr.ReviewState != "APPROVED" || pr.Mergeable != "MERGEABLE" {
+		t.Errorf("unexpected PR: %+v", pr)
+	}
+}
+
+func TestAPIClient_GetPRByBranch(t *testing.T) {
+	f, c := newFakeGitHub(t)
+	f.graphql = func(query string, vars map[string]any) any {
+		var nodes []any
+		if vars["branch"] == "feature-a" {
+			nodes = []any{prNode(5, "CLOSED", "feature-a"), prNode(4, "OPEN", "feature-a")}
+		}
+		return map[string]any{"repository": map[string]any{"pullRequests": map[string]any{"nodes": nodes}}}
+	}
+
+	pr, err := c.GetPRByBranch("feature-a")
+	if err != nil {
+		t.Fatalf("GetPRByBranch failed: %v", err)
+	}
+	if pr.Number != 4 {
+		t.Errorf("expected the open PR #4, got #%d", pr.Number)
+	}
+	if _, err := c.GetPRByBranch("missing"); err == nil {
+		t.Error("expected error for branch without PR")
+	}
+}
+
+func TestAPIClient_GetPRChecks(t *testing.T) {
+	tests := []struct {
+		name        string
+		contexts    []map[string]any
+		wantState   string
+		wantSummary string
+	}{
+		{
+			name:        "No checks",
+			wantState:   "none",
+			wantSummary: "no checks",
+		},
+		{
+			name: "All passing",
+			contexts: []map[string]any{
+				{"status": "COMPLETED", "conclusion": "SUCCESS"},
+				{"state": "SUCCESS"},
+				{"status": "COMPLETED", "conclusion": "SKIPPED"},
+			},
+			wantState:   "success",
+			wantSummary: "2/2 passed",
+		},
+		{
+			name: "Pending",
+			contexts: []map[string]any{
+				{"status": "COMPLETED", "conclusion": "SUCCESS"},
+				{"status": "IN_PROGRESS"},
+				{"state": "PENDING"},
+			},
+			wantState:   "pending",
+			wantSummary: "2/3 pending",
+		},
+		{
+			name: "Failure wins",
+			contexts: []map[string]any{
+				{"status": "COMPLETED", "conclusion": "FAILURE"},
+				{"status": "QUEUED"},
+				{"state": "ERROR"},
+			},
+			wantState:   "failure",
+			wantSummary: "2/3 failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, c := newFakeGitHub(t)
+			f.graphql = func(query string, vars map[string]any) any {
+				rollup := map[string]any{"contexts": map[string]any{"nodes": tt.contexts}}
+				commit := map[string]any{"commit": map[string]any{"statusCheckRollup": rollup}}
+				pr := map[string]any{"commits": map[string]any{"nodes": []any{commit}}}
+				return map[string]any{"repository": map[string]any{"pullRequest": pr}}
+			}
+
+			status, err := c.GetPRChecks(1)
+			if err != nil {
+				t.Fatalf("GetPRChecks failed: %v", err)
+			}
+			if status.State != tt.wantState || status.Summary != tt.wantSummary {
+				t.Errorf("GetPRChecks() = %s %q, want %s %q", status.State, status.Summary, tt.wantState, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestAPIClient_CreateAndEditPR(t *testing.T) {
+	f, c := newFakeGitHub(t)
+	f.rest["POST /repos/owner/repo/pulls"] = map[string]any{"number": 7}
+	f.rest["PATCH /repos/owner/repo/pulls/7"] = map[string]any{}
+	f.rest["GET /repos/owner/repo/pulls/7"] = map[string]any{"node_id": "PR_7"}
+	f.rest["POST /repos/owner/repo/issues/7/comments"] = map[string]any{}
+	var mutations []string
+	f.graphql = func(query string, vars map[string]any) any {
+		if strings.HasPrefix(query, "mutation") {
+			mutations = append(mutations, query)
+			if vars["id"] != "PR_7" {
+				t.Errorf("mutation got id %v, want PR_7", vars["id"])
+			}
+			return map[string]any{}
+		}
+		return map[string]any{"repository": map[string]any{"pullRequest": prNode(7, "OPEN", "feature-a")}}
+	}
+
+	pr, err := c.CreatePR("Title", "Body", "feature-a", "main", true)
+	if err != nil {
+		t.Fatalf("CreatePR failed: %v", err)
+	}
+	if pr.Number != 7 {
+		t.Errorf("CreatePR returned #%d, want #7", pr.Number)
+	}
+	if body := f.bodies["POST /repos/owner/repo/pulls"]; body["draft"] != true || body["head"] != "feature-a" {
+		t.Errorf("unexpected create body: %v", body)
+	}
+
+	if err := c.UpdatePRBase(7, "develop"); err != nil {
+		t.Fatalf("UpdatePRBase failed: %v", err)
+	}
+	if body := f.bodies["PATCH /repos/owner/repo/pulls/7"]; body["base"] != "develop" {
+		t.Errorf("unexpected base update body: %v", body)
+	}
+
+	if err := c.SetPRReady(7); err != nil {
+		t.Fatalf("SetPRReady failed: %v", err)
+	}
+	if err := c.SetPRDraft(7); err != nil {
+		t.Fatalf("SetPRDraft failed: %v", err)
+	}
+	if len(mutations) != 2 || !strings.Contains(mutations[0], "markPullRequestReadyForReview") || !strings.Contains(mutations[1], "convertPullRequestToDraft") {
+		t.Errorf("unexpected mutations: %v", mutations)
+	}
+
+	if err := c.ClosePR(7, "Folded into #6."); err != nil {
+		t.Fatalf("ClosePR failed: %v", err)
+	}
+	if body := f.bodies["POST /repos/owner/repo/issues/7/comments"]; body["body"] != "Folded into #6." {
+		t.Errorf("unexpected comment body: %v", body)
+	}
+	if body := f.bodies["PATCH /repos/owner/repo/pulls/7"]; body["state"] != "closed" {
+		t.Errorf("unexpected close body: %v", body)
+	}
+}
+
+func TestAPIClient_StackComment(t *testing.T) {
+	f, c := newFakeGitHub(t)
+	f.graphql = func(query string, vars map[string]any) any {
+		node := prNode(int(vars["number"].(float64)), "OPEN", "feature")
+		if vars["number"].(float64) == 8 {
+			node["body"] = "Body\n\n---\n## PR Stack\n\n1. #7\n2. #8\n"
+		}
+		return map[string]any{"repository": map[string]any{"pullRequest": node}}
+	}
+	f.rest["GET /repos/owner/repo/issues/7/comments?per_page=100&page=1"] = []map[string]any{
+		{"id": 10, "body": "LGTM"},
+		{"id": 11, "body": stackSectionStart + "\nold stack\n" + stackSectionEnd},
+	}
+	f.rest["PATCH /repos/owner/repo/issues/comments/11"] = map[string]any{}
+	f.rest["GET /repos/owner/repo/issues/8/comments?per_page=100&page=1"] = []map[string]any{}
+	f.rest["POST /repos/owner/repo/issues/8/comments"] = map[string]any{"id": 12}
+	f.rest["PATCH /repos/owner/repo/pulls/8"] = map[string]any{}
+
+	c.SetStackOptions(StackOptions{Comment: true})
+	s := &config.Stack{
+		Root: "main",
+		Branches: []*config.Branch{
+			{Name: "feature-a", Parent: "main", PRNumber: 7},
+			{Name: "feature-b", Parent: "feature-a", PRNumber: 8},
+		},
+	}
+	if err := c.UpdateStackDescription(s, ""); err != nil {
+		t.Fatalf("UpdateStackDescription failed: %v", err)
+	}
+
+	edited := f.bodies["PATCH /repos/owner/repo/issues/comments/11"]["body"].(string)
+	if !strings.HasPrefix(edited, stackSectionStart) || !strings.Contains(edited, "- #7 🟢 open ← **This PR**") {
+		t.Errorf("unexpected edited stack comment: %q", edited)
+	}
+	created := f.bodies["POST /repos/owner/repo/issues/8/comments"]["body"].(string)
+	if !strings.Contains(created, "  - #8 🟢 open ← **This PR**") {
+		t.Errorf("unexpected new stack comment: %q", created)
+	}
+	if _, ok := f.bodies["PATCH /repos/owner/repo/pulls/7"]; ok {
+		t.Error("a PR body without a stack section should be left alone")
+	}
+	if body := f.bodies["PATCH /repos/owner/repo/pulls/8"]["body"]; body != "Body" {
+		t.Errorf("stack section should be removed from the body, got %q", body)
+	}
+}
+
+func TestAPIClient_MergePR(t *testing.T) {
+	f, c := newFakeGitHub(t)
+	f.rest["PUT /repos/owner/repo/pulls/2/merge"] = map[string]any{"merged": true}
+	f.rest["DELETE /repos/owner/repo/git/refs/heads/feature-b"] = map[string]any{}
+	f.graphql = func(query string, vars map[string]any) any {
+		return map[string]any{"repository": map[string]any{"pullRequest": prNode(2, "OPEN", "feature-b")}}
+	}
+
+	if err := c.MergePR(2, "squash", true); err != nil {
+		t.Fatalf("MergePR failed: %v", err)
+	}
+	if body := f.bodies["PUT /repos/owner/repo/pulls/2/merge"]; body["merge_method"] != "squash" {
+		t.Errorf("unexpected merge body: %v", body)
+	}
+	if _, ok := f.bodies["DELETE /repos/owner/repo/git/refs/heads/feature-b"]; !ok {
+		t.Error("expected the head branch to be deleted")
+	}
+}
+
+func TestAPIClient_ListOpenPRs(t *testing.T) {
+	f, c := newFakeGitHub(t)
+	f.rest["GET /repos/owner/repo/pulls?state=open&per_page=100&page=1"] = []map[string]any{
+		{"number": 1, "title": "One", "html_url": "https://github.com/owner/repo/pull/1", "head": map[string]any{"ref": "a"}, "base": map[string]any{"ref": "main"}, "user": map[string]any{"login": "alice"}},
+	}
+
+	prs, err := c.ListOpenPRs()
+	if err != nil {
+		t.Fatalf("ListOpenPRs failed: %v", err)
+	}
+	if len(prs) != 1 || prs[0].Branch != "a" || prs[0].Base != "main" || prs[0].Author != "alice" {
+		t.Errorf("unexpected open PRs: %+v", prs)
+	}
+}
+
+func TestAPIClient_Errors(t *testing.T) {
+	_, c := newFakeGitHub(t)
+
+	if _, err := c.ListOpenPRs(); err == nil || !strings.Contains(err.Error(), "cannot access repository owner/repo") {
+		t.Errorf("expected repository access error, got %v", err)
+	}
+
+	c.token = "wrong"
+	if _, err := c.GetPR(1); err == nil || !strings.Contains(err.Error(), "authentication required") {
+		t.Errorf("expected authentication error, got %v", err)
+	}
+}
+
+func TestNewAPIClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		remoteURL string
+		apiURL    string
+		wantOwner string
+		wantGQL   string
+		wantErr   bool
+	}{
+		{name: "github.com", remoteURL: "git@github.com:owner/repo.git", wantOwner: "owner", wantGQL: "https://api.github.com/graphql"},
+		{name: "Enterprise", remoteURL: "https://ghe.example.com/org/repo.git", apiURL: "https://ghe.example.com/api/v3", wantOwner: "org", wantGQL: "https://ghe.example.com/api/graphql"},
+		{name: "Enterprise remote without API URL", remoteURL: "https://ghe.example.com/org/repo.git", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewAPIClient(tt.remoteURL, tt.apiURL, "token")
+			if tt.wantErr {
+				if err == nil {
+					t.Error("NewAPIClient() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewAPIClient() unexpected error: %v", err)
+			}
+			if c.owner != tt.wantOwner || c.repo != "repo" {
+				t.Errorf("owner/repo = %s/%s, want %s/repo", c.owner, c.repo, tt.wantOwner)
+			}
+			if got := c.graphQLURL(); got != tt.wantGQL {
+				t.Errorf("graphQLURL() = %q, want %q", got, tt.wantGQL)
+			}
+		})
+	}
+}
+
+func TestAPIClient_GetPRStatuses(t *testing.T) {
+	f, c := newFakeGitHub(t)
+	calls := 0
+	f.graphql = func(query string, vars map[string]any) any {
+		calls++
+		repo := make(map[string]any)
+		for i := 0; ; i++ {
+			alias := fmt.Sprintf("b%d", i)
+			branch, ok := vars[alias]
+			if !ok {
+				break
+			}
+			if !strings.Contains(query, alias+": pullRequests(headRefName: $"+alias) {
+				t.Errorf("query missing alias %s:\n%s", alias, query)
+			}
+			var nodes []any
+			switch branch {
+			case "feature-a":
+				node := prNode(1, "MERGED", "feature-a")
+				node["mergedAt"] = "2026-01-01T00:00:00Z"
+				nodes = append(nodes, node)
+			case "feature-b":
+				closed := prNode(3, "CLOSED", "feature-b")
+				open := prNode(2, "OPEN", "feature-b")
+				open["headRefOid"] = "abc123"
+				rollup := map[string]any{"contexts": map[string]any{"nodes": []any{
+					map[string]any{"status": "COMPLETED", "conclusion": "SUCCESS"},
+					map[string]any{"status": "IN_PROGRESS"},
+				}}}
+				open["commits"] = map[string]any{"nodes": []any{map[string]any{"commit": map[string]any{"statusCheckRollup": rollup}}}}
+				nodes = append(nodes, closed, open)
+			}
+			repo[alias] = map[string]any{"nodes": nodes}
+		}
+		return map[string]any{"repository": repo}
+	}
+
+	statuses, err := c.GetPRStatuses([]string{"feature-a", "feature-b", "no-pr"})
+	if err != nil {
+		t.Fatalf("GetPRStatuses failed: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 GraphQL request, got %d", calls)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("expected statuses for 2 branches, got %d", len(statuses))
+	}
+	if a := statuses["feature-a"]; !a.PR.Merged || a.Checks.State != "none" {
+		t.Errorf("unexpected feature-a status: %+v %+v", a.PR, a.Checks)
+	}
+	b := statuses["feature-b"]
+	if b.PR.Number != 2 || b.PR.HeadSHA != "abc123" {
+		t.Errorf("expected open PR #2 at abc123, got #%d at %q", b.PR.Number, b.PR.HeadSHA)
+	}
+	if b.Checks.State != "pending" || b.Checks.Summary != "1/2 pending" {
+		t.Errorf("unexpected feature-b checks: %+v", b.Checks)
+	}
+}
+
+func TestAPIClient_GetPRStatusesBatches(t *testing.T) {
+	f, c := newFakeGitHub(t)
+	calls := 0
+	f.graphql = func(query string, vars map[string]any) any {
+		calls++
+		return map[string]any{"repository": map[string]any{}}
+	}
+
+	branches := make([]string, statusBatchSize+1)
+	for i := range branches {
+		branches[i] = fmt.Sprintf("branch-%d", i)
+	}
+	if _, err := c.GetPRStatuses(branches); err != nil {
+		t.Fatalf("GetPRStatuses failed: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 GraphQL requests for %d branches, got %d", len(branches), calls)
+	}
+}
@@ -13,28 +13,32 @@ import (
 
 // Client wraps GitHub operations using gh CLI
 type Client struct {
-	owner string
-	repo  string
+	owner      string
+	repo       string
+	stackStyle StackOptions
 }
 
 // NewClient creates a new GitHub client by parsing the remote URL
 func NewClient(remoteURL string) (*Client, error) {
 	// Parse owner/repo from URL
 	// Handles: git@github.com:owner/repo.git or https://github.com/owner/repo.git
-	re := regexp.MustCompile(`github\.com[:/]([^/]+)/([^/.]+)`)
-	matches := re.FindStringSubmatch(remoteURL)
-	if len(matches) != 3 {
-		return nil, fmt.Errorf("could not parse GitHub URL: %s", remoteURL)
+	owner, repo, err := ParseRepo(remoteURL, "")
+	if err != nil {
+		return nil, err
 	}
 
 	return &Client{
-		owner: matches[1],
-		repo:  matches[2],
+		owner: owner,
+		repo:  repo,
 	}, nil
 }
 
-// CheckAuth verifies that the gh CLI is authenticated and returns an error if not.
+// CheckAuth verifies that a GitHub token is set or the gh CLI is authenticated,
+// and returns an error if not.
 func CheckAuth() error {
+	if Token() != "" {
+		return nil
+	}
 	cmd := exec.Command("gh", "auth", "status")
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
@@ -53,6 +57,7 @@ type PR struct {
 	State       string `json:"state"`
 	Base        string `json:"baseRefName"`
 	Head        string `json:"headRefName"`
+	HeadSHA     string `json:"headRefOid"`
 	MergedAt    string `json:"mergedAt"` // non-empty if merged
 	Merged      bool   // computed from MergedAt
 	Mergeable   string `json:"mergeable"`
@@ -224,6 +229,16 @@ func (c *Client) MergePR(number int, method string, deleteRemoteBranch bool) err
 	return err
 }
 
+// ClosePR closes a pull request without merging, optionally leaving a comment
+func (c *Client) ClosePR(number int, comment string) error {
+	args := []string{"pr", "close", fmt.Sprintf("%d", number)}
+	if comment != "" {
+		args = append(args, "--comment", comment)
+	}
+	_, err := c.runGH(args...)
+	return err
+}
+
 // SetPRDraft marks a PR as draft
 func (c *Client) SetPRDraft(number int) error {
 	_, err := c.runGH("pr", "ready", fmt.Sprintf("%d", number), "--undo")
@@ -236,18 +251,62 @@ func (c *Client) SetPRReady(number int) error {
 	return err
 }
 
+// Comment is a comment on a PR
+type Comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// ListPRComments returns the comments on a PR, oldest first
+func (c *Client) ListPRComments(number int) ([]Comment, error) {
+	output, err := c.runAPI(fmt.Sprintf("repos/%s/%s/issues/%d/comments", c.owner, c.repo, number), "--paginate", "--jq", ".[] | {id, body}")
+	if err != nil {
+		return nil, err
+	}
+	var comments []Comment
+	dec := json.NewDecoder(strings.NewReader(output))
+	for dec.More() {
+		var comment Comment
+		if err := dec.Decode(&comment); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
+
+// CreatePRComment adds a comment to a PR
+func (c *Client) CreatePRComment(number int, body string) (*Comment, error) {
+	output, err := c.runAPI(fmt.Sprintf("repos/%s/%s/issues/%d/comments", c.owner, c.repo, number), "-X", "POST", "-f", "body="+body)
+	if err != nil {
+		return nil, err
+	}
+	var comment Comment
+	if err := json.Unmarshal([]byte(output), &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// UpdatePRComment replaces the body of one of a PR's comments
+func (c *Client) UpdatePRComment(number int, commentID int64, body string) error {
+	_, err := c.runAPI(fmt.Sprintf("repos/%s/%s/issues/comments/%d", c.owner, c.repo, commentID), "-X", "PATCH", "-f", "body="+body)
+	return err
+}
+
 // OpenPR represents a minimal PR for listing
 type OpenPR struct {
 	Number int    `json:"number"`
 	Title  string `json:"title"`
 	Branch string `json:"headRefName"`
+	Base   string `json:"baseRefName"`
 	Author string `json:"author"`
 	URL    string `json:"url"`
 }
 
 // ListOpenPRs returns all open PRs in the repository
 func (c *Client) ListOpenPRs() ([]OpenPR, error) {
-	output, err := c.runGH("pr", "list", "--state", "open", "--json", "number,title,headRefName,url,author", "--limit", "300")
+	output, err := c.runGH("pr", "list", "--state", "open", "--json", "number,title,headRefName,baseRefName,url,author", "--limit", "300")
 	if err != nil {
 		return nil, err
 	}
@@ -256,6 +315,7 @@ func (c *Client) ListOpenPRs() ([]OpenPR, error) {
 		Number int    `json:"number"`
 		Title  string `json:"title"`
 		Branch string `json:"headRefName"`
+		Base   string `json:"baseRefName"`
 		URL    string `json:"url"`
 		Author struct {
 			Login string `json:"login"`
@@ -271,6 +331,7 @@ func (c *Client) ListOpenPRs() ([]OpenPR, error) {
 			Number: pr.Number,
 			Title:  pr.Title,
 			Branch: pr.Branch,
+			Base:   pr.Base,
 			Author: pr.Author.Login,
 			URL:    pr.URL,
 		}
@@ -321,8 +382,30 @@ func (c *Client) runGH(args ...string) (string, error) {
 	return stdout.String(), nil
 }
 
+// runAPI executes 'gh api' against a REST endpoint. Unlike the pr commands it
+// takes no -R flag, so the endpoint names the repository itself.
+func (c *Client) runAPI(endpoint string, args ...string) (string, error) {
+	cmd := exec.Command("gh", append([]string{"api", endpoint}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		stderrStr := stderr.String()
+		if strings.Contains(stderrStr, "auth login") || strings.Contains(stderrStr, "HTTP 401") {
+			return "", fmt.Errorf("GitHub authentication required. Run: gh auth login")
+		}
+		return "", fmt.Errorf("gh api %s failed: %s\n%s", endpoint, err, stderrStr)
+	}
+	return stdout.String(), nil
+}
+
 // EnsureCorrectBaseBranches ensures each PR's base branch matches the expected parent branch.
 func (c *Client) EnsureCorrectBaseBranches(stack *config.Stack) error {
+	return EnsureBaseBranches(c, stack)
+}
+
+// EnsureBaseBranches retargets every open PR in the stack whose base is not its parent branch.
+func EnsureBaseBranches(c ClientInterface, stack *config.Stack) error {
 	for _, branch := range stack.Branches {
 		if branch.PRNumber == 0 {
 			continue
@@ -348,8 +431,29 @@ func (c *Client) EnsureCorrectBaseBranches(stack *config.Stack) error {
 	return nil
 }
 
+// StackLabels controls how a stack section names and references review requests
+type StackLabels struct {
+	Noun      string // "PR" or "MR"
+	RefPrefix string // "#" for pull requests, "!" for GitLab merge requests
+}
+
+// PRLabels are the labels used in GitHub PR descriptions
+var PRLabels = StackLabels{Noun: "PR", RefPrefix: "#"}
+
 // UpdateStackDescription updates PR descriptions with stack info.
 func (c *Client) UpdateStackDescription(stack *config.Stack, currentBranch string) error {
+	return UpdateStackDescriptions(c, stack, currentBranch, PRLabels, c.stackStyle)
+}
+
+// SetStackOptions sets how stack sections are rendered and where they go
+func (c *Client) SetStackOptions(opts StackOptions) {
+	c.stackStyle = opts
+}
+
+// UpdateStackDescriptions rewrites the stack section of every PR in the stack
+// through c, in the PR body or, with opts.Comment, in a comment of its own.
+// Providers other than GitHub share it with their own labels.
+func UpdateStackDescriptions(c ClientInterface, stack *config.Stack, currentBranch string, labels StackLabels, opts StackOptions) error {
 	// Count how many PRs are in the stack (including root PR if present)
 	prCount := 0
 	if stack.RootPRNumber > 0 {
@@ -379,77 +483,32 @@ func (c *Client) UpdateStackDescription(stack *config.Stack, currentBranch strin
 
 		// Generate stack section with arrow pointing to THIS PR
 		// Uses PR numbers/URLs from the config cache (.ezstack.json)
-		stackSection := generateStackSection(stack, branch.Name)
+		stackSection, err := generateStackSectionWith(stack, branch.Name, labels, opts.Template)
+		if err != nil {
+			return err
+		}
+
+		if opts.Comment {
+			if err := upsertStackComment(c, branch.PRNumber, stackSection); err != nil {
+				return fmt.Errorf("failed to update stack comment on %s %s%d: %w", labels.Noun, labels.RefPrefix, branch.PRNumber, err)
+			}
+			// Drop a section left in the body from before the switch to comments
+			if body := stripStackSection(pr.Body); body != strings.TrimSpace(normalizeNewlines(pr.Body)) {
+				if err := c.UpdatePR(branch.PRNumber, body); err != nil {
+					return fmt.Errorf("failed to update %s %s%d: %w", labels.Noun, labels.RefPrefix, branch.PRNumber, err)
+				}
+			}
+			continue
+		}
 
 		// Update the body with the stack section
 		newBody := updateBodyWithStack(pr.Body, stackSection, branch.Name == currentBranch)
 		if newBody != pr.Body {
 			if err := c.UpdatePR(branch.PRNumber, newBody); err != nil {
-				return fmt.Errorf("failed to update PR #%d: %w", branch.PRNumber, err)
+				return fmt.Errorf("failed to update %s %s%d: %w", labels.Noun, labels.RefPrefix, branch.PRNumber, err)
 			}
 		}
 	}
 
 	return nil
 }
-
-func generateStackSection(stack *config.Stack, currentPRBranch string) string {
-	var sb strings.Builder
-	sb.WriteString("\n\n---\n## PR Stack\n\n")
-
-	num := 1
-
-	// Include root PR first if present (e.g., remote base branch PR)
-	if stack.RootPRNumber > 0 {
-		if stack.RootPRUrl != "" {
-			sb.WriteString(fmt.Sprintf("%d. %s (base)\n", num, stack.RootPRUrl))
-		} else {
-			sb.WriteString(fmt.Sprintf("%d. #%d (base)\n", num, stack.RootPRNumber))
-		}
-		num++
-	}
-
-	// Sort branches topologically (parent -> child order)
-	sortedBranches := config.SortBranchesTopologically(stack.Branches)
-
-	for _, branch := range sortedBranches {
-		// Skip branches that don't have a PR yet
-		if branch.PRNumber == 0 && branch.PRUrl == "" {
-			continue
-		}
-
-		// Use markdown list format - GitHub unfurls PR URLs in lists to show title
-		suffix := ""
-		if branch.Name == currentPRBranch {
-			suffix = " ← **This PR**"
-		}
-
-		if branch.PRUrl != "" {
-			sb.WriteString(fmt.Sprintf("%d. %s%s\n", num, branch.PRUrl, suffix))
-		} else {
-			sb.WriteString(fmt.Sprintf("%d. #%d%s\n", num, branch.PRNumber, suffix))
-		}
-		num++
-	}
-	sb.WriteString("\n_This stack was created by [ezstack](https://github.com/KulkarniKaustubh/ezstack)_\n")
-
-	return sb.String()
-}
-
-func updateBodyWithStack(body, stackSection string, isCurrent bool) string {
-	// Normalize line endings (GitHub API may return \r\n)
-	body = strings.ReplaceAll(body, "\r\n", "\n")
-	body = strings.ReplaceAll(body, "\r", "\n")
-
-	// Remove existing stack section - it's always appended at the end,
-	// so we just truncate from the first "---\n## PR Stack" marker onwards
-	for _, marker := range []string{"---\n## PR Stack", "---\n## 📚 PR Stack"} {
-		if idx := strings.Index(body, marker); idx != -1 {
-			body = body[:idx]
-			break
-		}
-	}
-
-	// Add new stack section
-	return strings.TrimSpace(body) + stackSection
-}
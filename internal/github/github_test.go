@@ -117,7 +117,28 @@ func TestGenerateStackSection(t *testing.T) {
 				},
 			},
 			currentPRBranch: "feature-b",
-			wantContains:    []string{"1.", "2.", "3.", "pull/1", "pull/2", "pull/3"},
+			wantContains: []string{
+				"\n- https://github.com/org/repo/pull/1 🟢 open\n",
+				"\n  - https://github.com/org/repo/pull/2 🟢 open ← **This PR**\n",
+				"\n    - https://github.com/org/repo/pull/3 🟢 open\n",
+			},
+		},
+		{
+			name: "Branching stack",
+			stack: &Stack{
+				Name: "feature-a",
+				Branches: []*Branch{
+					{Name: "feature-a", PRNumber: 1, PRUrl: "https://github.com/org/repo/pull/1", PRState: "MERGED"},
+					{Name: "feature-b", Parent: "feature-a", PRNumber: 2, PRState: "DRAFT"},
+					{Name: "feature-c", Parent: "feature-a", PRNumber: 3, PRUrl: "https://github.com/org/repo/pull/3"},
+				},
+			},
+			currentPRBranch: "feature-c",
+			wantContains: []string{
+				"\n- https://github.com/org/repo/pull/1 🟣 merged\n",
+				"\n  - #2 📝 draft\n",
+				"\n  - https://github.com/org/repo/pull/3 🟢 open ← **This PR**\n",
+			},
 		},
 		{
 			name: "Branch without PR",
@@ -132,6 +153,18 @@ func TestGenerateStackSection(t *testing.T) {
 			wantContains:    []string{"pull/1", "← **This PR**"},
 			wantNotContains: []string{"feature-b", "no PR yet"},
 		},
+		{
+			name: "Children of a branch without PR move up",
+			stack: &Stack{
+				Name: "feature-a",
+				Branches: []*Branch{
+					{Name: "feature-a", PRNumber: 0},
+					{Name: "feature-b", Parent: "feature-a", PRNumber: 2, PRUrl: "https://github.com/org/repo/pull/2"},
+				},
+			},
+			currentPRBranch: "feature-b",
+			wantContains:    []string{"\n- https://github.com/org/repo/pull/2 🟢 open ← **This PR**\n"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -162,23 +195,66 @@ type Stack struct {
 
 type Branch struct {
 	Name     string
+	Parent   string // previous branch in the list when empty
 	PRNumber int
 	PRUrl    string
+	PRState  string
 }
 
 // convertTestStack converts test Stack to config.Stack
 func convertTestStack(s *Stack) *config.Stack {
-	branches := make([]*config.Branch, len(s.Branches))
-	for i, b := range s.Branches {
-		branches[i] = &config.Branch{
+	stack := &config.Stack{
+		Hash: s.Name,
+		Root: "main",
+		Tree: config.BranchTree{},
+	}
+	parent := "main"
+	for _, b := range s.Branches {
+		if b.Parent != "" {
+			parent = b.Parent
+		}
+		stack.AddBranch(b.Name, parent)
+		stack.Branches = append(stack.Branches, &config.Branch{
 			Name:     b.Name,
+			Parent:   parent,
 			PRNumber: b.PRNumber,
 			PRUrl:    b.PRUrl,
-		}
+			PRState:  b.PRState,
+		})
+		parent = b.Name
+	}
+	return stack
+}
+
+func TestGenerateStackSectionWithTemplate(t *testing.T) {
+	stack := convertTestStack(&Stack{
+		Name: "feature-a",
+		Branches: []*Branch{
+			{Name: "feature-a", PRNumber: 1},
+			{Name: "feature-b", PRNumber: 2, PRState: "DRAFT"},
+		},
+	})
+	tmpl, err := ParseStackTemplate("Stacked on {{.Root}}:{{range .All}} {{.Branch}}={{.State}}{{end}} current={{.Current.Number}}")
+	if err != nil {
+		t.Fatalf("ParseStackTemplate() error = %v", err)
+	}
+
+	section, err := generateStackSectionWith(stack, "feature-b", PRLabels, tmpl)
+	if err != nil {
+		t.Fatalf("generateStackSectionWith() error = %v", err)
+	}
+	if !strings.Contains(section, "Stacked on main: feature-a=open feature-b=draft current=2") {
+		t.Errorf("custom section = %q", section)
+	}
+
+	// A body with a custom section gets it replaced, keeping text written after it
+	body := "Description\n" + section + "\nNotes after the stack"
+	updated := updateBodyWithStack(body, generateStackSection(stack, "feature-b"), true)
+	if strings.Contains(updated, "Stacked on") || !strings.Contains(updated, "Notes after the stack") || !strings.Contains(updated, "## PR Stack") {
+		t.Errorf("updateBodyWithStack() = %q", updated)
 	}
-	return &config.Stack{
-		Hash:     s.Name,
-		Branches: branches,
+	if strings.Count(updated, stackSectionStart) != 1 {
+		t.Errorf("updateBodyWithStack() should leave exactly one stack section:\n%s", updated)
 	}
 }
 
@@ -2,8 +2,9 @@ package github
 
 import "github.com/KulkarniKaustubh/ezstack/internal/config"
 
-// ClientInterface defines the interface for GitHub operations
-// This allows for mocking in tests
+// ClientInterface defines the interface for pull request operations.
+// It is implemented by the GitHub client and by internal/gitlab, and allows
+// for mocking in tests
 type ClientInterface interface {
 	// CreatePR creates a new pull request
 	CreatePR(title, body, head, base string, draft bool) (*PR, error)
@@ -17,6 +18,10 @@ type ClientInterface interface {
 	// GetPRChecks gets the CI check status for a PR
 	GetPRChecks(number int) (*CheckStatus, error)
 
+	// GetPRStatuses gets the PR and CI status of each head branch in as few
+	// requests as the provider allows. Branches without a PR are absent.
+	GetPRStatuses(branches []string) (map[string]*PRStatus, error)
+
 	// UpdatePR updates a PR's body
 	UpdatePR(number int, body string) error
 
@@ -29,14 +34,29 @@ type ClientInterface interface {
 	// MergePR merges a pull request using the specified method
 	MergePR(number int, method string, deleteRemoteBranch bool) error
 
+	// ClosePR closes a pull request without merging, optionally leaving a comment
+	ClosePR(number int, comment string) error
+
 	// SetPRDraft marks a PR as draft
 	SetPRDraft(number int) error
 
 	// SetPRReady marks a draft PR as ready for review
 	SetPRReady(number int) error
 
+	// ListPRComments returns the comments on a PR, oldest first
+	ListPRComments(number int) ([]Comment, error)
+
+	// CreatePRComment adds a comment to a PR
+	CreatePRComment(number int, body string) (*Comment, error)
+
+	// UpdatePRComment replaces the body of one of a PR's comments
+	UpdatePRComment(number int, commentID int64, body string) error
+
 	// UpdateStackDescription updates PR descriptions with stack info
 	UpdateStackDescription(stack *config.Stack, currentBranch string) error
+
+	// EnsureCorrectBaseBranches retargets PRs whose base is not their parent branch
+	EnsureCorrectBaseBranches(stack *config.Stack) error
 }
 
 // Ensure Client implements ClientInterface
@@ -0,0 +1,267 @@
+package github
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+)
+
+// The stack section is wrapped in these comments so it can be found and
+// replaced whatever a custom template renders
+const (
+	stackSectionStart = "<!-- ezstack:stack -->"
+	stackSectionEnd   = "<!-- /ezstack:stack -->"
+)
+
+// legacyStackMarkers start the stack sections written before they were
+// wrapped in comments. Those sections always ran to the end of the body.
+var legacyStackMarkers = []string{"---\n## PR Stack", "---\n## 📚 PR Stack", "---\n## MR Stack"}
+
+// defaultStackTemplate renders the stack as a nested list following the tree
+const defaultStackTemplate = `---
+## {{.Noun}} Stack
+
+{{range .All}}{{indent .Depth}}- {{.Ref}} {{.Marker}}{{if .Current}} ← **This {{$.Noun}}**{{end}}
+{{end}}
+_This stack was created by [ezstack](https://github.com/KulkarniKaustubh/ezstack)_
+`
+
+// StackOptions controls how UpdateStackDescriptions renders stack sections
+// and where it puts them
+type StackOptions struct {
+	Template *template.Template // custom section from ParseStackTemplate, nil for the default
+	Comment  bool               // keep the section in a comment instead of the PR body
+}
+
+// StackSection is the data a stack section template is executed with
+type StackSection struct {
+	Noun      string        // "PR" or "MR"
+	RefPrefix string        // "#" or "!"
+	Root      string        // branch the stack is based on
+	Entries   []*StackEntry // top-level entries, each holding its children
+	All       []*StackEntry // every entry, parents before children
+	Current   *StackEntry   // entry of the PR the section is written into
+}
+
+// StackEntry is one PR in a stack section. Branches without a PR are left
+// out and their children take their place.
+type StackEntry struct {
+	Branch   string
+	Number   int
+	URL      string
+	Ref      string // URL when known, otherwise e.g. "#12"
+	State    string // "open", "draft", "merged", "closed", or "base" for the root's PR
+	Marker   string // state shown next to the PR
+	Depth    int    // nesting level, 0 for top-level entries
+	Current  bool
+	Children []*StackEntry
+}
+
+var stackStateMarkers = map[string]string{
+	"open":   "🟢 open",
+	"draft":  "📝 draft",
+	"merged": "🟣 merged",
+	"closed": "🔴 closed",
+	"base":   "(base)",
+}
+
+// ParseStackTemplate parses a stack section template. Templates are executed
+// with a StackSection and can use indent, which returns two spaces per level.
+func ParseStackTemplate(text string) (*template.Template, error) {
+	return template.New("stack").Funcs(template.FuncMap{
+		"indent": func(depth int) string { return strings.Repeat("  ", depth) },
+	}).Parse(text)
+}
+
+var defaultStackSection = template.Must(ParseStackTemplate(defaultStackTemplate))
+
+// newStackSection builds the template data for the stack section written
+// into currentPRBranch's PR
+func newStackSection(stack *config.Stack, currentPRBranch string, labels StackLabels) *StackSection {
+	section := &StackSection{Noun: labels.Noun, RefPrefix: labels.RefPrefix, Root: stack.Root}
+	ref := func(number int, url string) string {
+		if url != "" {
+			return url
+		}
+		return fmt.Sprintf("%s%d", labels.RefPrefix, number)
+	}
+
+	byName := make(map[string]*config.Branch, len(stack.Branches))
+	for _, b := range stack.Branches {
+		byName[b.Name] = b
+	}
+
+	var walk func(tree config.BranchTree, depth int) []*StackEntry
+	walk = func(tree config.BranchTree, depth int) []*StackEntry {
+		names := make([]string, 0, len(tree))
+		for name := range tree {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var entries []*StackEntry
+		for _, name := range names {
+			b := byName[name]
+			if b == nil || (b.PRNumber == 0 && b.PRUrl == "") {
+				entries = append(entries, walk(tree[name], depth)...)
+				continue
+			}
+			entry := &StackEntry{
+				Branch:  b.Name,
+				Number:  b.PRNumber,
+				URL:     b.PRUrl,
+				Ref:     ref(b.PRNumber, b.PRUrl),
+				State:   branchPRState(b),
+				Depth:   depth,
+				Current: b.Name == currentPRBranch,
+			}
+			entry.Marker = stackStateMarkers[entry.State]
+			if entry.Current {
+				section.Current = entry
+			}
+			entry.Children = walk(tree[name], depth+1)
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	tree := stack.Tree
+	if len(tree) == 0 {
+		tree = treeFromBranches(stack.Branches)
+	}
+
+	if stack.RootPRNumber > 0 {
+		base := &StackEntry{
+			Branch: stack.Root,
+			Number: stack.RootPRNumber,
+			URL:    stack.RootPRUrl,
+			Ref:    ref(stack.RootPRNumber, stack.RootPRUrl),
+			State:  "base",
+		}
+		base.Marker = stackStateMarkers[base.State]
+		base.Children = walk(tree, 1)
+		section.Entries = []*StackEntry{base}
+	} else {
+		section.Entries = walk(tree, 0)
+	}
+
+	var flatten func(entries []*StackEntry)
+	flatten = func(entries []*StackEntry) {
+		for _, e := range entries {
+			section.All = append(section.All, e)
+			flatten(e.Children)
+		}
+	}
+	flatten(section.Entries)
+	return section
+}
+
+// treeFromBranches rebuilds the tree of a stack that only has its branch list
+func treeFromBranches(branches []*config.Branch) config.BranchTree {
+	nodes := make(map[string]config.BranchTree, len(branches))
+	for _, b := range branches {
+		nodes[b.Name] = config.BranchTree{}
+	}
+	tree := config.BranchTree{}
+	for _, b := range branches {
+		if parent, ok := nodes[b.Parent]; ok && b.Parent != b.Name {
+			parent[b.Name] = nodes[b.Name]
+		} else {
+			tree[b.Name] = nodes[b.Name]
+		}
+	}
+	return tree
+}
+
+// branchPRState returns the cached state of a branch's PR in lower case
+func branchPRState(b *config.Branch) string {
+	switch {
+	case b.IsMerged || b.PRState == "MERGED":
+		return "merged"
+	case b.PRState == "DRAFT":
+		return "draft"
+	case b.PRState == "CLOSED":
+		return "closed"
+	default:
+		return "open"
+	}
+}
+
+// generateStackSectionWith renders the stack section for currentPRBranch's
+// PR with tmpl, or with the default nested list when tmpl is nil
+func generateStackSectionWith(stack *config.Stack, currentPRBranch string, labels StackLabels, tmpl *template.Template) (string, error) {
+	if tmpl == nil {
+		tmpl = defaultStackSection
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, newStackSection(stack, currentPRBranch, labels)); err != nil {
+		return "", fmt.Errorf("failed to render stack section: %w", err)
+	}
+	return "\n\n" + stackSectionStart + "\n\n" + strings.TrimSpace(sb.String()) + "\n\n" + stackSectionEnd + "\n", nil
+}
+
+func generateStackSection(stack *config.Stack, currentPRBranch string) string {
+	section, _ := generateStackSectionWith(stack, currentPRBranch, PRLabels, nil)
+	return section
+}
+
+// updateBodyWithStack replaces the stack section of a PR body, in the current
+// or any earlier format, with stackSection
+func updateBodyWithStack(body, stackSection string, isCurrent bool) string {
+	return stripStackSection(body) + stackSection
+}
+
+// stripStackSection removes the stack section from a PR body, in the current
+// or any earlier format, and trims the result
+func stripStackSection(body string) string {
+	body = normalizeNewlines(body)
+
+	// Cut out the wrapped section, keeping anything written after it
+	if start := strings.Index(body, stackSectionStart); start != -1 {
+		rest := ""
+		if end := strings.Index(body[start:], stackSectionEnd); end != -1 {
+			rest = body[start+end+len(stackSectionEnd):]
+		}
+		body = strings.TrimRight(body[:start], " \n") + "\n\n" + strings.TrimLeft(rest, " \n")
+	}
+
+	// Older sections were always appended at the end, so truncate from their
+	// first marker onwards
+	for _, marker := range legacyStackMarkers {
+		if idx := strings.Index(body, marker); idx != -1 {
+			body = body[:idx]
+			break
+		}
+	}
+	return strings.TrimSpace(body)
+}
+
+// normalizeNewlines converts line endings to \n (GitHub API may return \r\n)
+func normalizeNewlines(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\r", "\n")
+}
+
+// upsertStackComment writes stackSection into the PR's stack comment, the
+// first comment carrying the section marker, creating it if there is none
+func upsertStackComment(c ClientInterface, number int, stackSection string) error {
+	body := strings.TrimSpace(stackSection)
+	comments, err := c.ListPRComments(number)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		if !strings.Contains(comment.Body, stackSectionStart) {
+			continue
+		}
+		if strings.TrimSpace(normalizeNewlines(comment.Body)) == body {
+			return nil
+		}
+		return c.UpdatePRComment(number, comment.ID, body)
+	}
+	_, err = c.CreatePRComment(number, body)
+	return err
+}
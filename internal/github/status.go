@@ -0,0 +1,150 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// PRStatus is a PR together with its CI status, as returned by GetPRStatuses.
+// PR.Body is not fetched.
+type PRStatus struct {
+	PR     *PR
+	Checks *CheckStatus
+}
+
+// statusBatchSize caps the branches per GraphQL request to stay well inside
+// GitHub's node limits; a typical status call fits in one request
+const statusBatchSize = 50
+
+const statusFields = `number url title state baseRefName headRefName headRefOid mergedAt mergeable isDraft reviewDecision
+  commits(last: 1) { nodes { commit { statusCheckRollup { contexts(first: 100) { nodes {
+    ... on CheckRun { status conclusion }
+    ... on StatusContext { state }
+  } } } } } }`
+
+// statusQuery builds one query with an aliased pullRequests lookup per head
+// branch ($b0, $b1, ...)
+func statusQuery(n int) string {
+	var sb strings.Builder
+	sb.WriteString("query($owner: String!, $repo: String!")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, ", $b%d: String!", i)
+	}
+	sb.WriteString(") {\n  repository(owner: $owner, name: $repo) {\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "    b%d: pullRequests(headRefName: $b%d, first: 5, orderBy: {field: CREATED_AT, direction: DESC}) { nodes { %s } }\n", i, i, statusFields)
+	}
+	sb.WriteString("  }\n}")
+	return sb.String()
+}
+
+// gqlStatusPR is a PR node of the status query
+type gqlStatusPR struct {
+	gqlPR
+	Commits struct {
+		Nodes []struct {
+			Commit struct {
+				StatusCheckRollup *struct {
+					Contexts struct {
+						Nodes []checkContext `json:"nodes"`
+					} `json:"contexts"`
+				} `json:"statusCheckRollup"`
+			} `json:"commit"`
+		} `json:"nodes"`
+	} `json:"commits"`
+}
+
+// parseStatuses maps the status query's aliases back to branch names,
+// preferring an open PR over older closed or merged ones
+func parseStatuses(data map[string]json.RawMessage, branches []string) (map[string]*PRStatus, error) {
+	var repo map[string]struct {
+		Nodes []gqlStatusPR `json:"nodes"`
+	}
+	if err := json.Unmarshal(data["repository"], &repo); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*PRStatus)
+	for i, branch := range branches {
+		nodes := repo[fmt.Sprintf("b%d", i)].Nodes
+		if len(nodes) == 0 {
+			continue
+		}
+		node := &nodes[0]
+		for j := range nodes {
+			if nodes[j].State == "OPEN" {
+				node = &nodes[j]
+				break
+			}
+		}
+
+		var contexts []checkContext
+		for _, n := range node.Commits.Nodes {
+			if n.Commit.StatusCheckRollup != nil {
+				contexts = append(contexts, n.Commit.StatusCheckRollup.Contexts.Nodes...)
+			}
+		}
+		result[branch] = &PRStatus{PR: node.toPR(), Checks: summarizeChecks(contexts)}
+	}
+	return result, nil
+}
+
+// GetPRStatuses fetches the PR and CI status of every given head branch
+// with one GraphQL request per statusBatchSize branches. Branches without a
+// PR are absent from the result.
+func (c *APIClient) GetPRStatuses(branches []string) (map[string]*PRStatus, error) {
+	return batchStatuses(branches, func(query string, vars map[string]any, out any) error {
+		return c.graphQL(query, vars, out)
+	})
+}
+
+// GetPRStatuses fetches the PR and CI status of every given head branch
+// through a single 'gh api graphql' call per statusBatchSize branches
+func (c *Client) GetPRStatuses(branches []string) (map[string]*PRStatus, error) {
+	return batchStatuses(branches, func(query string, vars map[string]any, out any) error {
+		args := []string{"api", "graphql", "-f", "query=" + query, "-f", "owner=" + c.owner, "-f", "repo=" + c.repo}
+		for name, value := range vars {
+			args = append(args, "-f", fmt.Sprintf("%s=%v", name, value))
+		}
+		cmd := exec.Command("gh", args...)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			// gh exits non-zero on GraphQL errors but still prints the response
+			if stdout.Len() > 0 {
+				return decodeGraphQL(stdout.Bytes(), out, c.owner+"/"+c.repo)
+			}
+			return fmt.Errorf("gh api graphql failed: %s\n%s", err, stderr.String())
+		}
+		return decodeGraphQL(stdout.Bytes(), out, c.owner+"/"+c.repo)
+	})
+}
+
+// batchStatuses runs the status query in chunks through run and merges the results
+func batchStatuses(branches []string, run func(query string, vars map[string]any, out any) error) (map[string]*PRStatus, error) {
+	result := make(map[string]*PRStatus)
+	for start := 0; start < len(branches); start += statusBatchSize {
+		chunk := branches[start:min(start+statusBatchSize, len(branches))]
+		vars := make(map[string]any)
+		for i, b := range chunk {
+			vars[fmt.Sprintf("b%d", i)] = b
+		}
+
+		var data map[string]json.RawMessage
+		if err := run(statusQuery(len(chunk)), vars, &data); err != nil {
+			return nil, err
+		}
+		statuses, err := parseStatuses(data, chunk)
+		if err != nil {
+			return nil, err
+		}
+		for b, s := range statuses {
+			result[b] = s
+		}
+	}
+	return result, nil
+}
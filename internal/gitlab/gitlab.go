@@ -0,0 +1,506 @@
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/github"
+)
+
+// Client talks to the GitLab REST API (v4). Merge requests are exposed
+// through the same types the GitHub client uses, so the rest of ezstack
+// can treat them as PRs.
+type Client struct {
+	apiURL  string // e.g. https://gitlab.com/api/v4
+	project string // full project path, e.g. group/subgroup/repo
+	token   string
+	http    *http.Client
+
+	stackStyle github.StackOptions
+}
+
+// Ensure Client implements github.ClientInterface
+var _ github.ClientInterface = (*Client)(nil)
+
+// MRLabels are the labels used in merge request descriptions
+var MRLabels = github.StackLabels{Noun: "MR", RefPrefix: "!"}
+
+var (
+	scpRemoteRe = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+	draftRe     = regexp.MustCompile(`(?i)^\s*(\[draft\]|\(draft\)|draft:|draft\s+-|wip:|\[wip\])\s*`)
+)
+
+// ParseRemote extracts the host and project path from a git remote URL.
+// Handles: git@host:group/repo.git, ssh://git@host:22/group/repo.git and
+// https://host/group/sub/repo.git
+func ParseRemote(remoteURL string) (host, project string, err error) {
+	remoteURL = strings.TrimSpace(remoteURL)
+	if strings.Contains(remoteURL, "://") {
+		u, perr := url.Parse(remoteURL)
+		if perr != nil {
+			return "", "", fmt.Errorf("could not parse GitLab URL: %s", remoteURL)
+		}
+		host, project = u.Hostname(), u.Path
+	} else if m := scpRemoteRe.FindStringSubmatch(remoteURL); m != nil {
+		host, project = m[1], m[2]
+	}
+	project = strings.TrimSuffix(strings.Trim(project, "/"), ".git")
+	if host == "" || !strings.Contains(project, "/") {
+		return "", "", fmt.Errorf("could not parse GitLab URL: %s", remoteURL)
+	}
+	return host, project, nil
+}
+
+// IsGitLabURL reports whether a remote URL looks like a GitLab instance
+func IsGitLabURL(remoteURL string) bool {
+	host, _, err := ParseRemote(remoteURL)
+	return err == nil && strings.Contains(strings.ToLower(host), "gitlab")
+}
+
+// Token returns the GitLab token from GITLAB_TOKEN, falling back to the
+// gitlab_token config key
+func Token() string {
+	if token := os.Getenv("GITLAB_TOKEN"); token != "" {
+		return token
+	}
+	if cfg, err := config.Load(); err == nil {
+		return cfg.GitLabToken
+	}
+	return ""
+}
+
+// CheckAuth verifies that a GitLab token is available
+func CheckAuth() error {
+	if Token() == "" {
+		return fmt.Errorf("GitLab authentication required. Set GITLAB_TOKEN or run: ezs config set gitlab_token <token>")
+	}
+	return nil
+}
+
+// NewClient creates a GitLab client from the remote URL. instanceURL
+// overrides the web URL of the instance (e.g. http://gitlab.internal:8080)
+// when it can't be derived from the remote.
+func NewClient(remoteURL, instanceURL string) (*Client, error) {
+	host, project, err := ParseRemote(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+	if instanceURL == "" {
+		instanceURL = "https://" + host
+	}
+	return NewClientWithBaseURL(strings.TrimRight(instanceURL, "/")+"/api/v4", project, Token()), nil
+}
+
+// NewClientWithBaseURL creates a client against an explicit API base URL
+func NewClientWithBaseURL(apiURL, project, token string) *Client {
+	return &Client{
+		apiURL:  strings.TrimRight(apiURL, "/"),
+		project: project,
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// mergeRequest is the subset of the GitLab merge request resource we use
+type mergeRequest struct {
+	IID                 int    `json:"iid"`
+	WebURL              string `json:"web_url"`
+	Title               string `json:"title"`
+	Description         string `json:"description"`
+	State               string `json:"state"` // opened, closed, merged, locked
+	SourceBranch        string `json:"source_branch"`
+	TargetBranch        string `json:"target_branch"`
+	MergedAt            string `json:"merged_at"`
+	SHA                 string `json:"sha"`
+	Draft               bool   `json:"draft"`
+	WorkInProgress      bool   `json:"work_in_progress"`
+	HasConflicts        bool   `json:"has_conflicts"`
+	MergeStatus         string `json:"merge_status"`
+	DetailedMergeStatus string `json:"detailed_merge_status"`
+	Author              struct {
+		Username string `json:"username"`
+	} `json:"author"`
+}
+
+// toPR converts a merge request into the shared PR type
+func (mr *mergeRequest) toPR() *github.PR {
+	pr := &github.PR{
+		Number:   mr.IID,
+		URL:      mr.WebURL,
+		Title:    mr.Title,
+		Body:     mr.Description,
+		Base:     mr.TargetBranch,
+		Head:     mr.SourceBranch,
+		HeadSHA:  mr.SHA,
+		MergedAt: mr.MergedAt,
+		IsDraft:  mr.Draft || mr.WorkInProgress,
+	}
+
+	switch mr.State {
+	case "merged":
+		pr.State = "MERGED"
+		pr.Merged = true
+	case "closed":
+		pr.State = "CLOSED"
+	default:
+		pr.State = "OPEN"
+	}
+
+	switch {
+	case mr.HasConflicts || mr.DetailedMergeStatus == "conflict":
+		pr.Mergeable = "CONFLICTING"
+	case mr.MergeStatus == "can_be_merged" || mr.DetailedMergeStatus == "mergeable":
+		pr.Mergeable = "MERGEABLE"
+	default:
+		pr.Mergeable = "UNKNOWN"
+	}
+
+	if mr.DetailedMergeStatus == "not_approved" {
+		pr.ReviewState = "REVIEW_REQUIRED"
+	}
+	return pr
+}
+
+// CreatePR opens a merge request. Drafts are created with a "Draft:" title prefix.
+func (c *Client) CreatePR(title, body, head, base string, draft bool) (*github.PR, error) {
+	if draft && !draftRe.MatchString(title) {
+		title = "Draft: " + title
+	}
+	var mr mergeRequest
+	err := c.do("POST", c.projectPath("merge_requests"), map[string]any{
+		"source_branch": head,
+		"target_branch": base,
+		"title":         title,
+		"description":   body,
+	}, &mr)
+	if err != nil {
+		return nil, err
+	}
+	return mr.toPR(), nil
+}
+
+// GetPR gets a merge request by IID
+func (c *Client) GetPR(number int) (*github.PR, error) {
+	mr, err := c.getMR(number)
+	if err != nil {
+		return nil, err
+	}
+	return mr.toPR(), nil
+}
+
+// GetPRByBranch gets the merge request for a source branch, preferring an open one
+func (c *Client) GetPRByBranch(branch string) (*github.PR, error) {
+	query := url.Values{
+		"source_branch": {branch},
+		"order_by":      {"created_at"},
+		"sort":          {"desc"},
+	}
+	var mrs []mergeRequest
+	if err := c.do("GET", c.projectPath("merge_requests")+"?"+query.Encode(), nil, &mrs); err != nil {
+		return nil, err
+	}
+	if len(mrs) == 0 {
+		return nil, fmt.Errorf("no merge request found for branch %s", branch)
+	}
+	for i := range mrs {
+		if mrs[i].State == "opened" {
+			return mrs[i].toPR(), nil
+		}
+	}
+	return mrs[0].toPR(), nil
+}
+
+// GetPRChecks summarizes the jobs of the merge request's latest pipeline
+func (c *Client) GetPRChecks(number int) (*github.CheckStatus, error) {
+	var pipelines []struct {
+		ID     int    `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := c.do("GET", c.projectPath(fmt.Sprintf("merge_requests/%d/pipelines", number)), nil, &pipelines); err != nil {
+		return &github.CheckStatus{State: "unknown", Summary: "checks unavailable"}, nil
+	}
+	if len(pipelines) == 0 {
+		return &github.CheckStatus{State: "none", Summary: "no checks"}, nil
+	}
+	latest := pipelines[0]
+
+	var jobs []struct {
+		Status       string `json:"status"`
+		AllowFailure bool   `json:"allow_failure"`
+	}
+	if err := c.do("GET", c.projectPath(fmt.Sprintf("pipelines/%d/jobs?per_page=100", latest.ID)), nil, &jobs); err != nil || len(jobs) == 0 {
+		return pipelineStatus(latest.Status), nil
+	}
+
+	passed, failed, pending := 0, 0, 0
+	for _, j := range jobs {
+		switch j.Status {
+		case "success":
+			passed++
+		case "failed":
+			if j.AllowFailure {
+				passed++
+			} else {
+				failed++
+			}
+		case "created", "pending", "running", "waiting_for_resource", "preparing", "scheduled":
+			pending++
+		}
+	}
+
+	total := passed + failed + pending
+	status := &github.CheckStatus{}
+	if total == 0 {
+		return pipelineStatus(latest.Status), nil
+	} else if failed > 0 {
+		status.State = "failure"
+		status.Summary = fmt.Sprintf("%d/%d failed", failed, total)
+	} else if pending > 0 {
+		status.State = "pending"
+		status.Summary = fmt.Sprintf("%d/%d pending", pending, total)
+	} else {
+		status.State = "success"
+		status.Summary = fmt.Sprintf("%d/%d passed", passed, total)
+	}
+	return status, nil
+}
+
+// pipelineStatus maps a pipeline's overall status when its jobs can't be counted
+func pipelineStatus(status string) *github.CheckStatus {
+	switch status {
+	case "success":
+		return &github.CheckStatus{State: "success", Summary: "pipeline passed"}
+	case "failed", "canceled":
+		return &github.CheckStatus{State: "failure", Summary: "pipeline " + status}
+	case "skipped", "manual":
+		return &github.CheckStatus{State: "none", Summary: "pipeline " + status}
+	default:
+		return &github.CheckStatus{State: "pending", Summary: "pipeline " + status}
+	}
+}
+
+// GetPRStatuses gets the merge request and pipeline status of each source
+// branch. The REST API has no batched lookup, so this costs two requests per branch.
+func (c *Client) GetPRStatuses(branches []string) (map[string]*github.PRStatus, error) {
+	result := make(map[string]*github.PRStatus)
+	for _, branch := range branches {
+		pr, err := c.GetPRByBranch(branch)
+		if err != nil {
+			if strings.Contains(err.Error(), "no merge request found") {
+				continue
+			}
+			return nil, err
+		}
+		checks, _ := c.GetPRChecks(pr.Number)
+		result[branch] = &github.PRStatus{PR: pr, Checks: checks}
+	}
+	return result, nil
+}
+
+// UpdatePR updates a merge request's description
+func (c *Client) UpdatePR(number int, body string) error {
+	return c.updateMR(number, map[string]any{"description": body})
+}
+
+// UpdatePRBase updates a merge request's target branch
+func (c *Client) UpdatePRBase(number int, base string) error {
+	return c.updateMR(number, map[string]any{"target_branch": base})
+}
+
+// MergePR merges a merge request. "squash" squashes the commits; "merge" and
+// "rebase" both defer to the project's configured merge method.
+func (c *Client) MergePR(number int, method string, deleteRemoteBranch bool) error {
+	return c.do("PUT", c.projectPath(fmt.Sprintf("merge_requests/%d/merge", number)), map[string]any{
+		"squash":                      method == "squash",
+		"should_remove_source_branch": deleteRemoteBranch,
+	}, nil)
+}
+
+// ClosePR closes a merge request without merging, optionally leaving a comment
+func (c *Client) ClosePR(number int, comment string) error {
+	if comment != "" {
+		if err := c.do("POST", c.projectPath(fmt.Sprintf("merge_requests/%d/notes", number)), map[string]any{"body": comment}, nil); err != nil {
+			return err
+		}
+	}
+	return c.updateMR(number, map[string]any{"state_event": "close"})
+}
+
+// ListPRComments returns the notes on a merge request written by people,
+// oldest first. System notes such as "added 1 commit" are left out.
+func (c *Client) ListPRComments(number int) ([]github.Comment, error) {
+	var result []github.Comment
+	for page := 1; page <= 10; page++ {
+		var notes []struct {
+			ID     int64  `json:"id"`
+			Body   string `json:"body"`
+			System bool   `json:"system"`
+		}
+		path := c.projectPath(fmt.Sprintf("merge_requests/%d/notes?sort=asc&order_by=created_at&per_page=100&page=%d", number, page))
+		if err := c.do("GET", path, nil, &notes); err != nil {
+			return nil, err
+		}
+		for _, n := range notes {
+			if !n.System {
+				result = append(result, github.Comment{ID: n.ID, Body: n.Body})
+			}
+		}
+		if len(notes) < 100 {
+			break
+		}
+	}
+	return result, nil
+}
+
+// CreatePRComment adds a note to a merge request
+func (c *Client) CreatePRComment(number int, body string) (*github.Comment, error) {
+	var comment github.Comment
+	if err := c.do("POST", c.projectPath(fmt.Sprintf("merge_requests/%d/notes", number)), map[string]any{"body": body}, &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// UpdatePRComment replaces the body of one of a merge request's notes
+func (c *Client) UpdatePRComment(number int, commentID int64, body string) error {
+	return c.do("PUT", c.projectPath(fmt.Sprintf("merge_requests/%d/notes/%d", number, commentID)), map[string]any{"body": body}, nil)
+}
+
+// SetPRDraft marks a merge request as draft by prefixing its title
+func (c *Client) SetPRDraft(number int) error {
+	mr, err := c.getMR(number)
+	if err != nil {
+		return err
+	}
+	if mr.Draft || draftRe.MatchString(mr.Title) {
+		return nil
+	}
+	return c.updateMR(number, map[string]any{"title": "Draft: " + mr.Title})
+}
+
+// SetPRReady marks a draft merge request as ready by removing its draft prefix
+func (c *Client) SetPRReady(number int) error {
+	mr, err := c.getMR(number)
+	if err != nil {
+		return err
+	}
+	title := draftRe.ReplaceAllString(mr.Title, "")
+	if title == mr.Title {
+		return nil
+	}
+	return c.updateMR(number, map[string]any{"title": title})
+}
+
+// ListOpenPRs returns all open merge requests in the project
+func (c *Client) ListOpenPRs() ([]github.OpenPR, error) {
+	var result []github.OpenPR
+	for page := 1; page <= 3; page++ {
+		var mrs []mergeRequest
+		path := c.projectPath(fmt.Sprintf("merge_requests?state=opened&per_page=100&page=%d", page))
+		if err := c.do("GET", path, nil, &mrs); err != nil {
+			return nil, err
+		}
+		for _, mr := range mrs {
+			result = append(result, github.OpenPR{
+				Number: mr.IID,
+				Title:  mr.Title,
+				Branch: mr.SourceBranch,
+				Base:   mr.TargetBranch,
+				Author: mr.Author.Username,
+				URL:    mr.WebURL,
+			})
+		}
+		if len(mrs) < 100 {
+			break
+		}
+	}
+	return result, nil
+}
+
+// UpdateStackDescription updates merge request descriptions with stack info
+func (c *Client) UpdateStackDescription(stack *config.Stack, currentBranch string) error {
+	return github.UpdateStackDescriptions(c, stack, currentBranch, MRLabels, c.stackStyle)
+}
+
+// SetStackOptions sets how stack sections are rendered and where they go
+func (c *Client) SetStackOptions(opts github.StackOptions) {
+	c.stackStyle = opts
+}
+
+// EnsureCorrectBaseBranches retargets merge requests whose target is not their parent branch
+func (c *Client) EnsureCorrectBaseBranches(stack *config.Stack) error {
+	return github.EnsureBaseBranches(c, stack)
+}
+
+func (c *Client) getMR(number int) (*mergeRequest, error) {
+	var mr mergeRequest
+	if err := c.do("GET", c.projectPath(fmt.Sprintf("merge_requests/%d", number)), nil, &mr); err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
+
+func (c *Client) updateMR(number int, fields map[string]any) error {
+	return c.do("PUT", c.projectPath(fmt.Sprintf("merge_requests/%d", number)), fields, nil)
+}
+
+// projectPath returns an API path under the project, e.g. /projects/group%2Frepo/merge_requests
+func (c *Client) projectPath(rest string) string {
+	return "/projects/" + url.PathEscape(c.project) + "/" + rest
+}
+
+// do sends a request to the API and decodes the JSON response into out
+func (c *Client) do(method, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.apiURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitLab %s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("GitLab authentication required. Set GITLAB_TOKEN or run: ezs config set gitlab_token <token>")
+	case resp.StatusCode == http.StatusNotFound && strings.Contains(string(data), "Project Not Found"):
+		return fmt.Errorf("cannot access repository %s. Check that your GitLab token has access", c.project)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("GitLab %s %s failed: %s\n%s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
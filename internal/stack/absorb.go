@@ -0,0 +1,313 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
+)
+
+// AbsorbFixup is a group of staged hunks that fix up one commit in the stack
+type AbsorbFixup struct {
+	Branch  string
+	Commit  string
+	Subject string
+	Files   []string
+	patch   string
+}
+
+// AbsorbPlan describes where 'ezs absorb' will route the staged hunks
+type AbsorbPlan struct {
+	Branch     string         // branch the staged changes live in
+	Fixups     []*AbsorbFixup // in stack order, root-most branch first
+	Unabsorbed []string       // "<file>: <reason>" for hunks that stay staged
+}
+
+// AbsorbResult reports what Absorb did
+type AbsorbResult struct {
+	Absorbed  []*AbsorbFixup
+	Failed    []string // fixups that did not apply and stay in the working tree
+	Restacked []RebaseResult
+	Conflict  *RebaseResult // set when a rebase stopped on a conflict
+	Stashed   bool          // the branch's changes are still stashed (only when Conflict is in its worktree)
+}
+
+// unmergedChain returns the unmerged stack branches from the root down to branchName
+func (m *Manager) unmergedChain(branchName string) []*config.Branch {
+	var chain []*config.Branch
+	for b := m.GetBranch(branchName); b != nil; b = m.GetBranch(b.Parent) {
+		if !b.IsMerged {
+			chain = append([]*config.Branch{b}, chain...)
+		}
+	}
+	return chain
+}
+
+// PlanAbsorb blames every staged hunk in the branch's worktree against the
+// branch and its ancestors, and groups the hunks by the commit that last
+// touched the lines they change. Hunks whose lines come from several commits,
+// or from outside the stack, are left where they are.
+func (m *Manager) PlanAbsorb(branchName string) (*AbsorbPlan, error) {
+	branch := m.GetBranch(branchName)
+	if branch == nil {
+		return nil, fmt.Errorf("branch '%s' not found in any stack", branchName)
+	}
+	worktree := m.worktreeForBranch(branch)
+	if worktree == "" {
+		return nil, fmt.Errorf("branch '%s' has no worktree", branchName)
+	}
+	g := git.New(worktree)
+
+	// Map every commit in the chain to the branch that owns it
+	owner := make(map[string]string)
+	subjects := make(map[string]string)
+	for _, b := range m.unmergedChain(branchName) {
+		commits, err := m.git.GetCommitsBetween(m.getParentRef(b.Parent), b.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commits of '%s': %w", b.Name, err)
+		}
+		for _, c := range commits {
+			owner[c.Hash] = b.Name
+			subjects[c.Hash] = c.Subject
+		}
+	}
+
+	patches, err := g.StagedPatches()
+	if err != nil {
+		return nil, err
+	}
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no staged changes to absorb")
+	}
+
+	plan := &AbsorbPlan{Branch: branchName}
+	byCommit := make(map[string]*AbsorbFixup)
+	hunks := make(map[string]map[int][]git.Hunk) // commit -> patch index -> hunks
+
+	for i := range patches {
+		fp := &patches[i]
+		if fp.Unsupported != "" {
+			plan.Unabsorbed = append(plan.Unabsorbed, fmt.Sprintf("%s: %s", fp.Path, fp.Unsupported))
+			continue
+		}
+		for _, h := range fp.Hunks {
+			lines := h.Removed
+			if len(lines) == 0 {
+				// Pure insertion: attribute it to the lines around it
+				lines = h.Context
+			}
+			commits, err := g.BlameCommits("HEAD", fp.Path, lines)
+			if err != nil || len(commits) == 0 {
+				plan.Unabsorbed = append(plan.Unabsorbed, fmt.Sprintf("%s: %s (cannot blame)", fp.Path, h.Lines[0]))
+				continue
+			}
+			if len(commits) > 1 {
+				plan.Unabsorbed = append(plan.Unabsorbed, fmt.Sprintf("%s: %s (touches lines from several commits)", fp.Path, h.Lines[0]))
+				continue
+			}
+			commit := commits[0]
+			if owner[commit] == "" {
+				plan.Unabsorbed = append(plan.Unabsorbed, fmt.Sprintf("%s: %s (lines come from outside the stack)", fp.Path, h.Lines[0]))
+				continue
+			}
+
+			fixup := byCommit[commit]
+			if fixup == nil {
+				fixup = &AbsorbFixup{Branch: owner[commit], Commit: commit, Subject: subjects[commit]}
+				byCommit[commit] = fixup
+				hunks[commit] = make(map[int][]git.Hunk)
+			}
+			if len(hunks[commit][i]) == 0 {
+				fixup.Files = append(fixup.Files, fp.Path)
+			}
+			hunks[commit][i] = append(hunks[commit][i], h)
+		}
+	}
+
+	// Build each fixup's patch and order the fixups root-most first
+	for _, b := range m.unmergedChain(branchName) {
+		commits, _ := m.git.GetCommitsBetween(m.getParentRef(b.Parent), b.Name)
+		for j := len(commits) - 1; j >= 0; j-- {
+			fixup := byCommit[commits[j].Hash]
+			if fixup == nil {
+				continue
+			}
+			for i := range patches {
+				if hs := hunks[fixup.Commit][i]; len(hs) > 0 {
+					fixup.patch += patches[i].Patch(hs)
+				}
+			}
+			plan.Fixups = append(plan.Fixups, fixup)
+		}
+	}
+
+	return plan, nil
+}
+
+// Absorb applies a plan from PlanAbsorb. Each fixup is committed in its
+// branch's worktree (or a temporary one) and squashed into its target
+// commit, then every branch below the first changed branch is restacked with
+// rebase --onto. The branch's own changes are stashed while this happens and
+// popped afterwards; hunks that were absorbed merge away as already applied.
+func (m *Manager) Absorb(plan *AbsorbPlan) (*AbsorbResult, error) {
+	result := &AbsorbResult{}
+	if len(plan.Fixups) == 0 {
+		return result, nil
+	}
+
+	branch := m.GetBranch(plan.Branch)
+	if branch == nil {
+		return nil, fmt.Errorf("branch '%s' not found in any stack", plan.Branch)
+	}
+	home := git.New(m.worktreeForBranch(branch))
+
+	fixupsByBranch := make(map[string][]*AbsorbFixup)
+	for _, f := range plan.Fixups {
+		fixupsByBranch[f.Branch] = append(fixupsByBranch[f.Branch], f)
+	}
+
+	// Everything from the root-most target down gets rewritten
+	top := plan.Fixups[0].Branch
+	stack := m.GetStackForBranch(top)
+	var affected []*config.Branch
+	for _, b := range stack.Branches {
+		if !b.IsMerged && (b.Name == top || m.isDescendantOf(b.Name, top)) {
+			affected = append(affected, b)
+		}
+	}
+
+	// Other worktrees are rebased in place, so they must be clean
+	for _, b := range affected {
+		if b.Name == plan.Branch {
+			continue
+		}
+		if path := m.worktreeForBranch(b); path != "" {
+			if dirty, err := git.New(path).HasChanges(); err != nil {
+				return nil, fmt.Errorf("failed to check %s for changes: %w", path, err)
+			} else if dirty {
+				return nil, fmt.Errorf("worktree %s has uncommitted changes; commit or stash them first", path)
+			}
+		}
+	}
+
+	oldHeads := make(map[string]string)
+	for _, b := range affected {
+		head, err := m.git.GetBranchCommit(b.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve '%s': %w", b.Name, err)
+		}
+		oldHeads[b.Name] = head
+	}
+
+	if err := home.StashPush(); err != nil {
+		return nil, fmt.Errorf("failed to stash changes: %w", err)
+	}
+	result.Stashed = true
+
+	for _, b := range affected {
+		r := m.absorbIntoBranch(b, oldHeads, fixupsByBranch[b.Name], result)
+		if r == nil {
+			continue
+		}
+		result.Restacked = append(result.Restacked, *r)
+		if r.HasConflict || r.Error != nil {
+			result.Conflict = r
+			break
+		}
+	}
+
+	// Leave the stash alone if the branch itself is mid-rebase
+	if result.Conflict != nil && result.Conflict.Branch == plan.Branch {
+		return result, nil
+	}
+	if err := home.StashPop(); err != nil {
+		return result, fmt.Errorf("absorbed, but restoring your changes failed (they are in 'git stash list'): %w", err)
+	}
+	result.Stashed = false
+	return result, nil
+}
+
+// absorbIntoBranch restacks one branch onto its rewritten parent and commits
+// and squashes its fixups. It returns nil when the branch needed no changes.
+func (m *Manager) absorbIntoBranch(b *config.Branch, oldHeads map[string]string, fixups []*AbsorbFixup, result *AbsorbResult) *RebaseResult {
+	parentOld, parentRewritten := oldHeads[b.Parent]
+	if parentRewritten {
+		newHead, err := m.git.GetBranchCommit(b.Parent)
+		parentRewritten = err == nil && newHead != parentOld
+	}
+	if !parentRewritten && len(fixups) == 0 {
+		return nil
+	}
+
+	r := &RebaseResult{Branch: b.Name, WorktreePath: m.worktreeForBranch(b)}
+	worktree := r.WorktreePath
+	if worktree == "" {
+		tmp, cleanup, err := m.tempWorktree(b.Name)
+		if err != nil {
+			r.Error = err
+			return r
+		}
+		defer cleanup()
+		worktree = tmp
+	}
+	g := git.New(worktree)
+
+	// A conflict can't be left for the user in a temporary worktree
+	defer func() {
+		if r.HasConflict && r.WorktreePath == "" {
+			g.RebaseAbort()
+			r.HasConflict = false
+			r.Error = fmt.Errorf("conflict while rewriting '%s', which has no worktree; branch left unchanged", b.Name)
+		}
+	}()
+
+	if parentRewritten {
+		rebase := g.RebaseOntoNonInteractive(b.Parent, parentOld)
+		if rebase.HasConflict || rebase.Error != nil {
+			r.HasConflict, r.Error = rebase.HasConflict, rebase.Error
+			return r
+		}
+	}
+
+	committed := false
+	for _, f := range fixups {
+		if err := g.ApplyPatchIndex(f.patch); err != nil {
+			g.ResetHard("HEAD")
+			result.Failed = append(result.Failed, fmt.Sprintf("%s (%.7s %s)", f.Branch, f.Commit, f.Subject))
+			continue
+		}
+		if err := g.CommitFixup(f.Commit); err != nil {
+			g.ResetHard("HEAD")
+			result.Failed = append(result.Failed, fmt.Sprintf("%s (%.7s %s)", f.Branch, f.Commit, f.Subject))
+			continue
+		}
+		result.Absorbed = append(result.Absorbed, f)
+		committed = true
+	}
+
+	if committed {
+		upstream, err := m.git.GetMergeBase(b.Name, m.getParentRef(b.Parent))
+		if err != nil {
+			r.Error = fmt.Errorf("failed to find fork point: %w", err)
+			return r
+		}
+		squash := g.RebaseAutosquash(upstream)
+		if squash.HasConflict || squash.Error != nil {
+			r.HasConflict, r.Error = squash.HasConflict, squash.Error
+			return r
+		}
+	}
+
+	r.Success = true
+	return r
+}
+
+// isDescendantOf reports whether branchName is below ancestorName in its stack
+func (m *Manager) isDescendantOf(branchName, ancestorName string) bool {
+	for b := m.GetBranch(branchName); b != nil; b = m.GetBranch(b.Parent) {
+		if b.Parent == ancestorName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package stack
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		t.Fatalf("git %v failed: %v", args, err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// setupAbsorbStack builds main -> feature-a -> feature-b -> feature-c, where
+// feature-a has no worktree and owns lib.txt, and feature-b owns app.txt
+func setupAbsorbStack(t *testing.T) (repoDir, worktreeBaseDir string, cleanup func()) {
+	t.Helper()
+	repoDir, worktreeBaseDir, cleanup = setupTestEnv(t)
+
+	mgr, _ := NewManager(repoDir)
+	if _, err := mgr.CreateBranchNoWorktree("feature-a", "main", ""); err != nil {
+		t.Fatalf("CreateBranchNoWorktree() error = %v", err)
+	}
+	exec.Command("git", "-C", repoDir, "checkout", "-q", "feature-a").Run()
+	os.WriteFile(filepath.Join(repoDir, "lib.txt"), []byte("alpha\nbeta\ngamma\n"), 0644)
+	exec.Command("git", "-C", repoDir, "add", ".").Run()
+	exec.Command("git", "-C", repoDir, "commit", "-m", "add lib").Run()
+	exec.Command("git", "-C", repoDir, "checkout", "-q", "main").Run()
+
+	mgr, _ = NewManager(repoDir)
+	mgr.CreateBranch("feature-b", "feature-a", "", "")
+	bDir := filepath.Join(worktreeBaseDir, "feature-b")
+	os.WriteFile(filepath.Join(bDir, "app.txt"), []byte("one\ntwo\nthree\n"), 0644)
+	exec.Command("git", "-C", bDir, "add", ".").Run()
+	exec.Command("git", "-C", bDir, "commit", "-m", "add app").Run()
+
+	mgr, _ = NewManager(repoDir)
+	mgr.CreateBranch("feature-c", "feature-b", "", "")
+	commitFile(t, filepath.Join(worktreeBaseDir, "feature-c"), "c.txt")
+
+	return repoDir, worktreeBaseDir, cleanup
+}
+
+func TestManager_Absorb(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupAbsorbStack(t)
+	defer cleanup()
+
+	cDir := filepath.Join(worktreeBaseDir, "feature-c")
+	os.WriteFile(filepath.Join(cDir, "lib.txt"), []byte("alpha\nBETA\ngamma\n"), 0644)
+	os.WriteFile(filepath.Join(cDir, "app.txt"), []byte("one\nTWO\nthree\n"), 0644)
+	os.WriteFile(filepath.Join(cDir, "c.txt"), []byte("c.txt changed\n"), 0644)
+	exec.Command("git", "-C", cDir, "add", "lib.txt", "app.txt").Run()
+
+	mgr, _ := NewManager(cDir)
+	plan, err := mgr.PlanAbsorb("feature-c")
+	if err != nil {
+		t.Fatalf("PlanAbsorb() error = %v", err)
+	}
+	if len(plan.Fixups) != 2 {
+		t.Fatalf("PlanAbsorb() produced %d fixups, want 2 (unabsorbed: %v)", len(plan.Fixups), plan.Unabsorbed)
+	}
+	if plan.Fixups[0].Branch != "feature-a" || plan.Fixups[1].Branch != "feature-b" {
+		t.Errorf("fixups routed to %s, %s; want feature-a, feature-b", plan.Fixups[0].Branch, plan.Fixups[1].Branch)
+	}
+
+	result, err := mgr.Absorb(plan)
+	if err != nil {
+		t.Fatalf("Absorb() error = %v", err)
+	}
+	if result.Conflict != nil {
+		t.Fatalf("Absorb() stopped on %s: %v", result.Conflict.Branch, result.Conflict.Error)
+	}
+	if len(result.Absorbed) != 2 || len(result.Failed) != 0 {
+		t.Errorf("absorbed %d, failed %v", len(result.Absorbed), result.Failed)
+	}
+
+	// Each branch still has exactly one commit, now carrying the fix
+	for _, tc := range []struct{ branch, parent, file, want string }{
+		{"feature-a", "main", "lib.txt", "alpha\nBETA\ngamma"},
+		{"feature-b", "feature-a", "app.txt", "one\nTWO\nthree"},
+	} {
+		if n := gitOutput(t, repoDir, "rev-list", "--count", tc.parent+".."+tc.branch); n != "1" {
+			t.Errorf("%s has %s commits on %s, want 1", tc.branch, n, tc.parent)
+		}
+		if got := gitOutput(t, repoDir, "show", tc.branch+":"+tc.file); got != tc.want {
+			t.Errorf("%s:%s = %q, want %q", tc.branch, tc.file, got, tc.want)
+		}
+	}
+
+	// feature-c was restacked and keeps its unstaged change
+	if gitOutput(t, repoDir, "merge-base", "feature-b", "feature-c") != gitOutput(t, repoDir, "rev-parse", "feature-b") {
+		t.Error("feature-c was not restacked onto feature-b")
+	}
+	status := gitOutput(t, cDir, "status", "--porcelain")
+	if status != "M c.txt" {
+		t.Errorf("feature-c status = %q, want only c.txt modified", status)
+	}
+
+	// The temporary worktree for feature-a is gone
+	for _, line := range strings.Split(gitOutput(t, repoDir, "worktree", "list"), "\n") {
+		if strings.Contains(line, "[feature-a]") {
+			t.Errorf("temporary worktree left behind: %s", line)
+		}
+	}
+}
+
+func TestManager_PlanAbsorb_Unabsorbed(t *testing.T) {
+	_, worktreeBaseDir, cleanup := setupAbsorbStack(t)
+	defer cleanup()
+
+	cDir := filepath.Join(worktreeBaseDir, "feature-c")
+	// README.md comes from main, new.txt is a new file
+	os.WriteFile(filepath.Join(cDir, "README.md"), []byte("# Changed\n"), 0644)
+	os.WriteFile(filepath.Join(cDir, "new.txt"), []byte("new\n"), 0644)
+	exec.Command("git", "-C", cDir, "add", ".").Run()
+
+	mgr, _ := NewManager(cDir)
+	plan, err := mgr.PlanAbsorb("feature-c")
+	if err != nil {
+		t.Fatalf("PlanAbsorb() error = %v", err)
+	}
+	if len(plan.Fixups) != 0 {
+		t.Errorf("PlanAbsorb() produced %d fixups, want 0", len(plan.Fixups))
+	}
+	if len(plan.Unabsorbed) != 2 {
+		t.Errorf("Unabsorbed = %v, want 2 entries", plan.Unabsorbed)
+	}
+}
@@ -0,0 +1,177 @@
+package stack
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/github"
+)
+
+// PlanPRTree finds the open PR identified by target (a PR number or head
+// branch), follows PR bases down to the first base that isn't the head of
+// another open PR, and returns that base as the stack root along with every
+// open PR stacked on top of the bottom PR, parents before children.
+func PlanPRTree(prs []github.OpenPR, target string) (string, []github.OpenPR, error) {
+	byHead := make(map[string]github.OpenPR)
+	children := make(map[string][]github.OpenPR)
+	for _, pr := range prs {
+		byHead[pr.Branch] = pr
+		children[pr.Base] = append(children[pr.Base], pr)
+	}
+
+	var start *github.OpenPR
+	if n, err := strconv.Atoi(strings.TrimPrefix(target, "#")); err == nil {
+		for i := range prs {
+			if prs[i].Number == n {
+				start = &prs[i]
+				break
+			}
+		}
+	}
+	if start == nil {
+		if pr, ok := byHead[target]; ok {
+			start = &pr
+		}
+	}
+	if start == nil {
+		return "", nil, fmt.Errorf("no open PR found for '%s'", target)
+	}
+
+	bottom := *start
+	seen := map[string]bool{bottom.Branch: true}
+	for {
+		parent, ok := byHead[bottom.Base]
+		if !ok {
+			break
+		}
+		if seen[parent.Branch] {
+			return "", nil, fmt.Errorf("PR bases form a cycle at '%s'", parent.Branch)
+		}
+		seen[parent.Branch] = true
+		bottom = parent
+	}
+
+	tree := []github.OpenPR{bottom}
+	for i := 0; i < len(tree); i++ {
+		next := children[tree[i].Branch]
+		sort.Slice(next, func(a, b int) bool { return next[a].Number < next[b].Number })
+		tree = append(tree, next...)
+	}
+	return bottom.Base, tree, nil
+}
+
+// AdoptPRTree registers a tree of PRs planned by PlanPRTree as a new stack on
+// root. Each head branch is fetched from origin and checked out in its own
+// worktree (or as a plain branch when use_worktrees is off), reusing local
+// branches and worktrees that already exist, and its PR is cached. If a step
+// fails, the branches and worktrees created so far are removed.
+func (m *Manager) AdoptPRTree(root string, prs []github.OpenPR) (*config.Stack, error) {
+	if len(prs) == 0 {
+		return nil, fmt.Errorf("no PRs to adopt")
+	}
+	for _, pr := range prs {
+		if s := m.GetStackForBranch(pr.Branch); s != nil {
+			return nil, fmt.Errorf("branch '%s' is already in stack %s", pr.Branch, s.DisplayName())
+		}
+		if pr.Branch == root {
+			return nil, fmt.Errorf("branch '%s' cannot be both the root and part of the stack", root)
+		}
+	}
+
+	if err := m.Fetch(); err != nil {
+		return nil, err
+	}
+	for _, pr := range prs {
+		if !m.git.BranchExists(pr.Branch) && !m.git.RemoteBranchExists(pr.Branch) {
+			return nil, fmt.Errorf("branch '%s' of PR #%d is not on origin (is the PR from a fork?)", pr.Branch, pr.Number)
+		}
+	}
+
+	useWorktrees := m.config.GetUseWorktrees(m.repoDir)
+	baseDir := ""
+	if m.repoConfig != nil {
+		baseDir = m.repoConfig.WorktreeBaseDir
+	}
+	if useWorktrees && baseDir == "" {
+		return nil, fmt.Errorf("no worktree base directory configured for this repo. Run: ezs config set worktree_base_dir <path>")
+	}
+
+	existing := make(map[string]string)
+	if worktrees, err := m.git.ListWorktrees(); err == nil {
+		for _, wt := range worktrees {
+			existing[wt.Branch] = wt.Path
+		}
+	}
+
+	// Branches and worktrees this call creates, removed again if a later
+	// step fails so a failed adopt leaves nothing behind
+	type created struct {
+		branch    string
+		worktree  string
+		newBranch bool
+	}
+	var made []created
+	removeCreated := func() {
+		for i := len(made) - 1; i >= 0; i-- {
+			c := made[i]
+			if c.worktree != "" {
+				m.git.RemoveWorktree(c.worktree, false, "")
+			}
+			if c.newBranch {
+				m.git.DeleteBranch(c.branch, true)
+			}
+		}
+	}
+
+	cache := m.stackConfig.Cache
+	for _, pr := range prs {
+		worktreePath := existing[pr.Branch]
+		newBranch := !m.git.BranchExists(pr.Branch)
+		switch {
+		case worktreePath != "":
+			// Already checked out somewhere; use it as it is
+		case useWorktrees:
+			worktreePath = filepath.Join(baseDir, pr.Branch)
+			if err := m.git.CreateWorktree(pr.Branch, worktreePath, "origin/"+pr.Branch); err != nil {
+				// The branch may have been created before the worktree failed
+				made = append(made, created{branch: pr.Branch, newBranch: newBranch})
+				removeCreated()
+				return nil, fmt.Errorf("failed to create worktree for %s: %w", pr.Branch, err)
+			}
+			made = append(made, created{branch: pr.Branch, worktree: worktreePath, newBranch: newBranch})
+		case newBranch:
+			if err := m.git.CreateBranchOnly(pr.Branch, "origin/"+pr.Branch); err != nil {
+				removeCreated()
+				return nil, fmt.Errorf("failed to create branch %s: %w", pr.Branch, err)
+			}
+			made = append(made, created{branch: pr.Branch, newBranch: true})
+		}
+		cache.SetBranchCache(pr.Branch, &config.BranchCache{
+			WorktreePath: worktreePath,
+			PRNumber:     pr.Number,
+			PRUrl:        pr.URL,
+		})
+	}
+
+	stack := &config.Stack{
+		Hash: m.generateUniqueHash(prs[0].Branch),
+		Root: root,
+		Tree: config.BranchTree{},
+	}
+	for _, pr := range prs {
+		stack.AddBranch(pr.Branch, pr.Base)
+	}
+	m.stackConfig.Stacks[stack.Hash] = stack
+	stack.PopulateBranchesWithCache(cache)
+
+	if err := m.stackConfig.Save(m.repoDir); err != nil {
+		delete(m.stackConfig.Stacks, stack.Hash)
+		removeCreated()
+		return nil, fmt.Errorf("failed to save stack config: %w", err)
+	}
+	return stack, nil
+}
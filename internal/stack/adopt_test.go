@@ -0,0 +1,148 @@
+package stack
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/github"
+)
+
+func TestPlanPRTree(t *testing.T) {
+	prs := []github.OpenPR{
+		{Number: 4, Branch: "feature-c", Base: "feature-a"},
+		{Number: 2, Branch: "feature-a", Base: "main"},
+		{Number: 3, Branch: "feature-b", Base: "feature-a"},
+		{Number: 5, Branch: "feature-d", Base: "feature-b"},
+		{Number: 6, Branch: "other", Base: "main"},
+	}
+
+	root, tree, err := PlanPRTree(prs, "5")
+	if err != nil {
+		t.Fatalf("PlanPRTree() error = %v", err)
+	}
+	if root != "main" {
+		t.Errorf("root = %q, want main", root)
+	}
+	var got []string
+	for _, pr := range tree {
+		got = append(got, pr.Branch)
+	}
+	want := []string{"feature-a", "feature-b", "feature-c", "feature-d"}
+	if len(got) != len(want) {
+		t.Fatalf("tree = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("tree = %v, want %v", got, want)
+		}
+	}
+
+	if _, tree, _ := PlanPRTree(prs, "other"); len(tree) != 1 {
+		t.Errorf("PlanPRTree(other) = %v, want just the one PR", tree)
+	}
+	if _, _, err := PlanPRTree(prs, "missing"); err == nil {
+		t.Error("PlanPRTree() with an unknown target should fail")
+	}
+}
+
+func TestManager_AdoptPRTree(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+
+	bareDir := filepath.Join(filepath.Dir(repoDir), "bare.git")
+	exec.Command("git", "init", "--bare", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "remote", "add", "origin", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "push", "-u", "origin", "main").Run()
+
+	// Branches stacked with plain git, as another tool would
+	gitOutput(t, repoDir, "checkout", "-q", "-b", "feature-a")
+	commitFile(t, repoDir, "a.txt")
+	gitOutput(t, repoDir, "checkout", "-q", "-b", "feature-b")
+	commitFile(t, repoDir, "b.txt")
+	gitOutput(t, repoDir, "checkout", "-q", "main")
+	gitOutput(t, repoDir, "push", "-q", "origin", "feature-a", "feature-b")
+	gitOutput(t, repoDir, "branch", "-q", "-D", "feature-b")
+
+	prs := []github.OpenPR{
+		{Number: 1, Branch: "feature-a", Base: "main", URL: "https://github.com/org/repo/pull/1"},
+		{Number: 2, Branch: "feature-b", Base: "feature-a", URL: "https://github.com/org/repo/pull/2"},
+	}
+	root, tree, err := PlanPRTree(prs, "feature-b")
+	if err != nil {
+		t.Fatalf("PlanPRTree() error = %v", err)
+	}
+
+	mgr, _ := NewManager(repoDir)
+	s, err := mgr.AdoptPRTree(root, tree)
+	if err != nil {
+		t.Fatalf("AdoptPRTree() error = %v", err)
+	}
+	if s.Root != "main" {
+		t.Errorf("stack root = %q, want main", s.Root)
+	}
+
+	mgr, _ = NewManager(repoDir)
+	b := mgr.GetBranch("feature-b")
+	if b == nil || b.Parent != "feature-a" || b.PRNumber != 2 {
+		t.Fatalf("feature-b = %+v, want parent feature-a and PR #2", b)
+	}
+	if b.WorktreePath != filepath.Join(worktreeBaseDir, "feature-b") {
+		t.Errorf("feature-b worktree = %q, want one under the worktree base dir", b.WorktreePath)
+	}
+	if got := gitOutput(t, b.WorktreePath, "rev-parse", "HEAD"); got != gitOutput(t, repoDir, "rev-parse", "origin/feature-b") {
+		t.Error("feature-b should be checked out at origin/feature-b")
+	}
+
+	if _, err := mgr.AdoptPRTree(root, tree); err == nil {
+		t.Error("adopting branches that are already in a stack should fail")
+	}
+}
+
+// TestManager_AdoptPRTree_PartialFailure verifies that when checking out a
+// later PR fails, the branches and worktrees already created are removed
+func TestManager_AdoptPRTree_PartialFailure(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+
+	bareDir := filepath.Join(filepath.Dir(repoDir), "bare.git")
+	exec.Command("git", "init", "--bare", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "remote", "add", "origin", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "push", "-u", "origin", "main").Run()
+
+	gitOutput(t, repoDir, "checkout", "-q", "-b", "feature-a")
+	commitFile(t, repoDir, "a.txt")
+	gitOutput(t, repoDir, "checkout", "-q", "-b", "feature-b")
+	commitFile(t, repoDir, "b.txt")
+	gitOutput(t, repoDir, "checkout", "-q", "main")
+	gitOutput(t, repoDir, "push", "-q", "origin", "feature-a", "feature-b")
+	gitOutput(t, repoDir, "branch", "-q", "-D", "feature-a", "feature-b")
+
+	// Something is already in the way of feature-b's worktree
+	blocked := filepath.Join(worktreeBaseDir, "feature-b")
+	os.MkdirAll(blocked, 0755)
+	os.WriteFile(filepath.Join(blocked, "file.txt"), []byte("in the way\n"), 0644)
+
+	prs := []github.OpenPR{
+		{Number: 1, Branch: "feature-a", Base: "main"},
+		{Number: 2, Branch: "feature-b", Base: "feature-a"},
+	}
+	mgr, _ := NewManager(repoDir)
+	if _, err := mgr.AdoptPRTree("main", prs); err == nil {
+		t.Fatal("AdoptPRTree() should fail when a worktree can't be created")
+	}
+
+	for _, name := range []string{"feature-a", "feature-b"} {
+		if mgr.git.BranchExists(name) {
+			t.Errorf("%s should have been deleted", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(worktreeBaseDir, "feature-a")); !os.IsNotExist(err) {
+		t.Errorf("feature-a worktree should have been removed, stat error = %v", err)
+	}
+	mgr, _ = NewManager(repoDir)
+	if s := mgr.GetStackForBranch("feature-a"); s != nil {
+		t.Errorf("feature-a should not be in a stack, found %s", s.DisplayName())
+	}
+}
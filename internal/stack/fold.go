@@ -0,0 +1,102 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
+)
+
+// FoldResult describes the outcome of folding a branch into its parent
+type FoldResult struct {
+	Parent   *config.Branch // parent branch, now containing the folded commits
+	Children []string       // children moved onto the parent
+	PRNumber int            // PR of the folded branch, 0 if it had none
+}
+
+// FoldBranch folds a branch into its parent: the parent is fast-forwarded to
+// the branch, the branch's children are moved under the parent, and the branch
+// and its worktree are deleted. The branch must be based on the parent's
+// current HEAD (run sync first otherwise), and neither worktree may have
+// uncommitted changes.
+func (m *Manager) FoldBranch(branchName string) (*FoldResult, error) {
+	branch := m.GetBranch(branchName)
+	if branch == nil {
+		return nil, fmt.Errorf("branch '%s' not found in any stack", branchName)
+	}
+	parent := m.GetBranch(branch.Parent)
+	if parent == nil {
+		return nil, fmt.Errorf("cannot fold '%s' into '%s': parent is the stack root", branchName, branch.Parent)
+	}
+
+	based, err := m.git.IsBranchMerged(parent.Name, branchName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare '%s' with '%s': %w", branchName, parent.Name, err)
+	}
+	if !based {
+		return nil, fmt.Errorf("'%s' is not based on the current '%s'. Run: ezs sync", branchName, parent.Name)
+	}
+
+	parentWorktree := m.worktreeForBranch(parent)
+	for _, path := range []string{parentWorktree, m.worktreeForBranch(branch)} {
+		if path == "" {
+			continue
+		}
+		if dirty, err := git.New(path).HasChanges(); err != nil {
+			return nil, fmt.Errorf("failed to check %s for changes: %w", path, err)
+		} else if dirty {
+			return nil, fmt.Errorf("worktree %s has uncommitted changes; commit or stash them first", path)
+		}
+	}
+
+	head, err := m.git.GetBranchCommit(branchName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve '%s': %w", branchName, err)
+	}
+
+	// The parent is an ancestor of the branch, so this is a fast-forward
+	if parentWorktree != "" {
+		if err := git.New(parentWorktree).ResetHard(head); err != nil {
+			return nil, fmt.Errorf("failed to fast-forward '%s': %w", parent.Name, err)
+		}
+	} else if err := m.git.SetBranchRef(parent.Name, head); err != nil {
+		return nil, fmt.Errorf("failed to fast-forward '%s': %w", parent.Name, err)
+	}
+
+	result := &FoldResult{PRNumber: branch.PRNumber}
+
+	// Splice the children up before deleting, so DeleteBranch sees a leaf
+	stack := m.stackConfig.Stacks[m.findStackForBranch(branchName)]
+	for _, child := range m.GetChildren(branchName) {
+		subtree := stack.ExtractSubtree(child.Name)
+		stack.AddSubtree(child.Name, subtree, parent.Name)
+		result.Children = append(result.Children, child.Name)
+	}
+	stack.PopulateBranchesWithCache(m.stackConfig.Cache)
+	if err := m.stackConfig.Save(m.repoDir); err != nil {
+		return nil, fmt.Errorf("failed to save stack config: %w", err)
+	}
+
+	if err := m.DeleteBranch(branchName, false); err != nil {
+		return nil, err
+	}
+
+	result.Parent = m.GetBranch(parent.Name)
+	return result, nil
+}
+
+// worktreeForBranch returns the branch's worktree, falling back to git's
+// worktree list when the cache has no path recorded
+func (m *Manager) worktreeForBranch(branch *config.Branch) string {
+	if branch.WorktreePath != "" {
+		return branch.WorktreePath
+	}
+	if wts, err := m.git.ListWorktrees(); err == nil {
+		for _, wt := range wts {
+			if wt.Branch == branch.Name {
+				return wt.Path
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+package stack
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func commitFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	os.WriteFile(filepath.Join(dir, name), []byte(name+"\n"), 0644)
+	exec.Command("git", "-C", dir, "add", ".").Run()
+	exec.Command("git", "-C", dir, "commit", "-m", "add "+name).Run()
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("rev-parse failed: %v", err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestManager_FoldBranch(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	mgr, _ := NewManager(repoDir)
+	mgr.CreateBranch("feature-a", "main", "", "")
+	commitFile(t, filepath.Join(worktreeBaseDir, "feature-a"), "a.txt")
+
+	mgr, _ = NewManager(repoDir)
+	mgr.CreateBranch("feature-b", "feature-a", "", "")
+	bHead := commitFile(t, filepath.Join(worktreeBaseDir, "feature-b"), "b.txt")
+
+	mgr, _ = NewManager(repoDir)
+	mgr.CreateBranch("feature-c", "feature-b", "", "")
+
+	mgr, _ = NewManager(repoDir)
+	result, err := mgr.FoldBranch("feature-b")
+	if err != nil {
+		t.Fatalf("FoldBranch() error = %v", err)
+	}
+	if len(result.Children) != 1 || result.Children[0] != "feature-c" {
+		t.Errorf("result.Children = %v, want [feature-c]", result.Children)
+	}
+
+	mgr, _ = NewManager(repoDir)
+	if mgr.GetBranch("feature-b") != nil {
+		t.Error("feature-b should no longer be tracked")
+	}
+	if c := mgr.GetBranch("feature-c"); c == nil || c.Parent != "feature-a" {
+		t.Errorf("feature-c should be under feature-a, got %+v", c)
+	}
+
+	out, _ := exec.Command("git", "-C", repoDir, "rev-parse", "feature-a").Output()
+	if got := strings.TrimSpace(string(out)); got != bHead {
+		t.Errorf("feature-a = %s, want %s", got, bHead)
+	}
+	if err := exec.Command("git", "-C", repoDir, "rev-parse", "--verify", "refs/heads/feature-b").Run(); err == nil {
+		t.Error("git branch feature-b should have been deleted")
+	}
+	if _, err := os.Stat(filepath.Join(worktreeBaseDir, "feature-b")); !os.IsNotExist(err) {
+		t.Error("feature-b worktree should have been removed")
+	}
+	if _, err := os.Stat(filepath.Join(worktreeBaseDir, "feature-a", "b.txt")); err != nil {
+		t.Error("feature-a worktree should contain b.txt after the fold")
+	}
+}
+
+func TestManager_FoldBranch_NotBasedOnParent(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	mgr, _ := NewManager(repoDir)
+	mgr.CreateBranch("feature-a", "main", "", "")
+
+	mgr, _ = NewManager(repoDir)
+	mgr.CreateBranch("feature-b", "feature-a", "", "")
+
+	// feature-a moves on after feature-b was created
+	commitFile(t, filepath.Join(worktreeBaseDir, "feature-a"), "a.txt")
+
+	mgr, _ = NewManager(repoDir)
+	if _, err := mgr.FoldBranch("feature-b"); err == nil {
+		t.Error("FoldBranch() should fail when the branch is behind its parent")
+	}
+	if mgr.GetBranch("feature-b") == nil {
+		t.Error("feature-b should still be tracked after a failed fold")
+	}
+}
+
+func TestManager_FoldBranch_IntoRoot(t *testing.T) {
+	repoDir, _, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	mgr, _ := NewManager(repoDir)
+	mgr.CreateBranch("feature-a", "main", "", "")
+
+	mgr, _ = NewManager(repoDir)
+	if _, err := mgr.FoldBranch("feature-a"); err == nil {
+		t.Error("FoldBranch() should refuse to fold into the stack root")
+	}
+}
@@ -0,0 +1,212 @@
+package stack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
+)
+
+// linearSegment returns the chain of branches starting at bottom (whose
+// parent is the stack root) for as long as each branch has exactly one child,
+// if the whole chain can be synced with a single 'git rebase --update-refs'
+// of its top branch. That needs every branch in it to sit on top of its
+// parent's head, no merged parents, and no other local branch pointing into
+// the rebased commits (git would move it too). Returns nil otherwise.
+func (m *Manager) linearSegment(stack *config.Stack, bottom *config.Branch, state *config.SyncState, prs *prMergeChecker) []*config.Branch {
+	children := make(map[string][]*config.Branch)
+	for _, b := range stack.Branches {
+		if !b.IsMerged {
+			children[b.Parent] = append(children[b.Parent], b)
+		}
+	}
+
+	chain := []*config.Branch{bottom}
+	for {
+		next := children[chain[len(chain)-1].Name]
+		if len(next) != 1 || state.IsCompleted(next[0].Name) {
+			break
+		}
+		chain = append(chain, next[0])
+	}
+	if len(chain) < 2 {
+		return nil
+	}
+
+	for i, b := range chain[1:] {
+		parent := chain[i]
+		if merged, err := m.git.IsBranchMerged(parent.Name, "origin/"+stack.Root); err != nil || merged || prs.isMerged(parent) {
+			return nil
+		}
+		if onParent, err := m.git.IsBranchMerged(parent.Name, b.Name); err != nil || !onParent {
+			return nil
+		}
+	}
+
+	top := chain[len(chain)-1]
+	commits, err := m.git.GetCommitsBetween("origin/"+stack.Root, top.Name)
+	if err != nil {
+		return nil
+	}
+	rebased := make(map[string]bool)
+	for _, c := range commits {
+		rebased[c.Hash] = true
+	}
+	inChain := make(map[string]bool)
+	for _, b := range chain {
+		inChain[b.Name] = true
+	}
+	heads, err := m.git.ListRefs("refs/heads/")
+	if err != nil {
+		return nil
+	}
+	for ref, commit := range heads {
+		if name := strings.TrimPrefix(ref, "refs/heads/"); !inChain[name] && rebased[commit] {
+			return nil
+		}
+	}
+	return chain
+}
+
+// syncLinearSegment syncs chain, from linearSegment, by rebasing its top
+// branch onto origin/<root> once with --update-refs. Branches below the top
+// that are checked out are detached for the rebase, since git won't move
+// branches checked out in other worktrees, and checked out again afterwards.
+//
+// handled is false when the chain should be synced branch by branch instead:
+// a conflict (the rebase is aborted, so it stops at the right branch), a
+// checked-out branch with uncommitted changes, or any other failure.
+// confirmed reports whether the user already agreed to sync the bottom branch.
+func (m *Manager) syncLinearSegment(stack *config.Stack, chain []*config.Branch, state *config.SyncState, callbacks *SyncCallbacks) (results []RebaseResult, handled, confirmed, stop bool) {
+	bottom, top := chain[0], chain[len(chain)-1]
+	target := "origin/" + stack.Root
+
+	behindBy, err := m.git.GetCommitsBehind(bottom.Name, target)
+	if err != nil || behindBy == 0 {
+		return nil, false, false, false
+	}
+
+	if callbacks != nil && callbacks.BeforeRebase != nil {
+		info := SyncInfo{
+			Branch:    bottom.Name,
+			BehindBy:  behindBy,
+			StackRoot: stack.Root,
+			NeedsSync: true,
+		}
+		for _, b := range chain[1:] {
+			info.Stacked = append(info.Stacked, b.Name)
+		}
+		if !callbacks.BeforeRebase(info) {
+			// Nothing above a skipped branch would be behind its parent
+			for _, b := range chain {
+				state.MarkCompleted(b.Name)
+			}
+			m.saveSyncProgress(state)
+			return nil, true, false, false
+		}
+	}
+
+	// Branches below the top that are checked out somewhere
+	detached := make(map[string]string)
+	for _, b := range chain[:len(chain)-1] {
+		wt := m.worktreeForBranch(b)
+		if wt == "" {
+			continue
+		}
+		if dirty, err := git.New(wt).HasChanges(); err != nil || dirty {
+			return nil, false, true, false
+		}
+		detached[b.Name] = wt
+	}
+
+	// Record the rebase and the branches it detaches, so if ezs dies midway
+	// 'ezs sync --continue' aborts it and checks them out again
+	rb := m.newBranchRebase(top, callbacks != nil && callbacks.Autostash)
+	defer rb.release()
+	for name, wt := range detached {
+		state.StartRebase(config.SyncConflict{Branch: name, WorktreePath: wt, Detached: true})
+	}
+	defer func() {
+		for name := range detached {
+			state.FinishRebase(name)
+		}
+	}()
+	rb.track(state)
+	g, err := rb.checkout()
+	if err != nil {
+		return nil, false, true, false
+	}
+
+	reattach := func() []RebaseResult {
+		var failed []RebaseResult
+		for _, b := range chain {
+			wt, ok := detached[b.Name]
+			if !ok {
+				continue
+			}
+			if err := git.New(wt).CheckoutBranch(b.Name); err != nil {
+				failed = append(failed, RebaseResult{
+					Branch:       b.Name,
+					WorktreePath: wt,
+					Error:        fmt.Errorf("rebased, but failed to check it out again in %s: %w", wt, err),
+				})
+			}
+		}
+		return failed
+	}
+	for _, wt := range detached {
+		if err := git.New(wt).DetachHead(); err != nil {
+			reattach()
+			rb.popStash()
+			return nil, false, true, false
+		}
+	}
+
+	rebaseResult := g.RebaseUpdateRefsNonInteractive(target)
+	if !rebaseResult.Success {
+		if inProgress, _ := g.IsRebaseInProgress(); inProgress {
+			g.RebaseAbort()
+		}
+		reattach()
+		rb.popStash()
+		return nil, false, true, false
+	}
+	failed := reattach()
+	rb.popStash()
+	for _, b := range chain {
+		state.MarkCompleted(b.Name)
+		state.FinishRebase(b.Name)
+	}
+	m.saveSyncProgress(state)
+
+	for i, b := range chain {
+		result := RebaseResult{Branch: b.Name, Success: true, SyncedParent: b.Parent, WorktreePath: m.worktreeForBranch(b)}
+		if i == 0 {
+			result.SyncedParent, result.BehindBy = target, behindBy
+		}
+		for _, f := range failed {
+			if f.Branch == b.Name {
+				result = f
+			}
+		}
+		results = append(results, result)
+		if result.Error != nil {
+			continue
+		}
+		if callbacks != nil && callbacks.AfterRebase != nil {
+			if !callbacks.AfterRebase(result, m.branchGit(b)) {
+				return results, true, true, true
+			}
+		}
+	}
+	return results, true, true, false
+}
+
+// branchGit returns the git for a branch's worktree, or the repo's when it has none
+func (m *Manager) branchGit(b *config.Branch) *git.Git {
+	if wt := m.worktreeForBranch(b); wt != "" {
+		return git.New(wt)
+	}
+	return m.git
+}
@@ -0,0 +1,332 @@
+package stack
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
+	"github.com/KulkarniKaustubh/ezstack/internal/github"
+)
+
+// Merge train outcomes reported per branch
+const (
+	TrainMerged        = "merged"
+	TrainAlreadyMerged = "already merged"
+	TrainConflict      = "conflict"
+	TrainChecksFailed  = "checks failed"
+	TrainChecksPending = "checks pending"
+	TrainFailed        = "failed"
+	TrainNotStarted    = "not started"
+)
+
+// MergeTrainResult describes what the merge train did with one branch
+type MergeTrainResult struct {
+	Branch       string
+	PRNumber     int
+	Status       string // one of the Train* constants
+	Detail       string
+	WorktreePath string
+}
+
+// MergeTrainOptions controls how long the train waits for CI
+type MergeTrainOptions struct {
+	ChecksTimeout time.Duration // how long to wait for pending checks on each PR; 0 stops at the first pending check
+	PollInterval  time.Duration
+	Progress      func(msg string) // optional, called as each step starts
+}
+
+// PlanMergeTrain returns the unmerged branches of a stack in merge order, from
+// the bottom of the stack up to target. With an empty target the stack must be
+// linear and the train runs to its top. Every branch needs an open PR.
+func (m *Manager) PlanMergeTrain(s *config.Stack, target string) ([]*config.Branch, error) {
+	if target == "" {
+		var tops []string
+		for _, b := range s.Branches {
+			if b.IsMerged {
+				continue
+			}
+			hasChild := false
+			for _, c := range s.Branches {
+				if !c.IsMerged && c.Parent == b.Name {
+					hasChild = true
+					break
+				}
+			}
+			if !hasChild {
+				tops = append(tops, b.Name)
+			}
+		}
+		if len(tops) == 0 {
+			return nil, fmt.Errorf("stack %s has no unmerged branches", s.DisplayName())
+		}
+		if len(tops) > 1 {
+			return nil, fmt.Errorf("stack %s branches into %s; choose where to stop with --to <branch>", s.DisplayName(), strings.Join(tops, ", "))
+		}
+		target = tops[0]
+	}
+
+	if !s.HasBranch(target) {
+		return nil, fmt.Errorf("branch '%s' is not in stack %s", target, s.DisplayName())
+	}
+
+	chain := m.unmergedChain(target)
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("'%s' and its ancestors are already merged", target)
+	}
+	for _, b := range chain {
+		if b.PRNumber == 0 {
+			return nil, fmt.Errorf("branch '%s' has no PR. Create them with: ezs pr create --stack", b.Name)
+		}
+	}
+	return chain, nil
+}
+
+// StartMergeTrain records a new merge train for the planned branches
+func (m *Manager) StartMergeTrain(s *config.Stack, branches []*config.Branch, method string, deleteBranch bool) (*config.MergeTrainState, error) {
+	state := &config.MergeTrainState{
+		Stack:        s.Hash,
+		Method:       method,
+		DeleteBranch: deleteBranch,
+	}
+	for _, b := range branches {
+		state.Branches = append(state.Branches, b.Name)
+	}
+	if err := config.SaveMergeTrainState(m.repoDir, state); err != nil {
+		return nil, fmt.Errorf("failed to save merge train state: %w", err)
+	}
+	return state, nil
+}
+
+// PendingMergeTrain returns the interrupted merge train for this repo, or nil if there is none
+func (m *Manager) PendingMergeTrain() (*config.MergeTrainState, error) {
+	return config.LoadMergeTrainState(m.repoDir)
+}
+
+// AbortMergeTrain forgets an interrupted merge train and aborts any rebase it
+// left in progress. PRs the train already merged stay merged.
+func (m *Manager) AbortMergeTrain() error {
+	state, err := m.PendingMergeTrain()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("no merge train in progress")
+	}
+
+	for _, name := range state.Branches {
+		b := m.GetBranch(name)
+		if b == nil || state.IsMerged(name) {
+			continue
+		}
+		if wt := m.worktreeForBranch(b); wt != "" {
+			g := git.New(wt)
+			if inProgress, _ := g.IsRebaseInProgress(); inProgress {
+				if err := g.RebaseAbort(); err != nil {
+					return fmt.Errorf("failed to abort rebase of %s: %w", name, err)
+				}
+			}
+		}
+	}
+
+	return config.ClearMergeTrainState(m.repoDir)
+}
+
+// RunMergeTrain lands the train's branches bottom-up. For each branch the PR
+// is retargeted to the stack root, the branch is synced onto the freshly
+// merged root and force-pushed, and once its checks pass the PR is merged.
+// The train stops at the first branch that conflicts, fails its checks, runs
+// out of time or cannot be merged; the state is kept so a later run resumes
+// there. Returns one result per branch in the train.
+func (m *Manager) RunMergeTrain(gh github.ClientInterface, state *config.MergeTrainState, opts MergeTrainOptions) ([]MergeTrainResult, error) {
+	s := m.GetStackByHashExact(state.Stack)
+	if s == nil {
+		return nil, fmt.Errorf("stack %s no longer exists", state.Stack)
+	}
+
+	progress := func(format string, args ...any) {
+		if opts.Progress != nil {
+			opts.Progress(fmt.Sprintf(format, args...))
+		}
+	}
+
+	var results []MergeTrainResult
+	for i, name := range state.Branches {
+		if state.IsMerged(name) {
+			results = append(results, MergeTrainResult{Branch: name, Status: TrainMerged})
+			continue
+		}
+
+		result := m.mergeTrainStep(gh, s, state, name, i > 0, opts, progress)
+		results = append(results, result)
+		if result.Status != TrainMerged && result.Status != TrainAlreadyMerged {
+			for _, rest := range state.Branches[i+1:] {
+				results = append(results, MergeTrainResult{Branch: rest, Status: TrainNotStarted})
+			}
+			if err := config.SaveMergeTrainState(m.repoDir, state); err != nil {
+				return results, fmt.Errorf("failed to save merge train state: %w", err)
+			}
+			return results, nil
+		}
+
+		state.MarkMerged(name)
+		if err := config.SaveMergeTrainState(m.repoDir, state); err != nil {
+			return results, fmt.Errorf("failed to save merge train state: %w", err)
+		}
+	}
+
+	if err := config.ClearMergeTrainState(m.repoDir); err != nil {
+		return results, fmt.Errorf("failed to clear merge train state: %w", err)
+	}
+	return results, nil
+}
+
+// mergeTrainStep lands a single branch. restack is false for the first branch
+// of the train, which is merged as it is.
+func (m *Manager) mergeTrainStep(gh github.ClientInterface, s *config.Stack, state *config.MergeTrainState, name string, restack bool, opts MergeTrainOptions, progress func(string, ...any)) MergeTrainResult {
+	result := MergeTrainResult{Branch: name}
+	branch := m.GetBranch(name)
+	if branch == nil {
+		result.Status = TrainFailed
+		result.Detail = "branch is no longer in the stack"
+		return result
+	}
+	result.PRNumber = branch.PRNumber
+
+	pr, err := gh.GetPR(branch.PRNumber)
+	if err != nil {
+		result.Status = TrainFailed
+		result.Detail = fmt.Sprintf("failed to get PR #%d: %v", branch.PRNumber, err)
+		return result
+	}
+	if pr.Merged {
+		result.Status = TrainAlreadyMerged
+		return result
+	}
+	if pr.State == "CLOSED" {
+		result.Status = TrainFailed
+		result.Detail = fmt.Sprintf("PR #%d is closed", branch.PRNumber)
+		return result
+	}
+
+	if pr.Base != s.Root {
+		progress("Retargeting PR #%d (%s) onto %s", branch.PRNumber, name, s.Root)
+		if err := gh.UpdatePRBase(branch.PRNumber, s.Root); err != nil {
+			result.Status = TrainFailed
+			result.Detail = fmt.Sprintf("failed to retarget PR #%d: %v", branch.PRNumber, err)
+			return result
+		}
+	}
+
+	// After a force-push the PR is only checked once GitHub has moved it to
+	// the pushed head; until then its checks are those of the old one
+	pushedHead := ""
+	hadChecks := false
+	if restack {
+		worktree := m.worktreeForBranch(branch)
+		if worktree == "" {
+			result.Status = TrainFailed
+			result.Detail = "branch has no worktree to rebase in"
+			return result
+		}
+		branch.WorktreePath = worktree
+		result.WorktreePath = worktree
+		g := git.New(worktree)
+
+		if inProgress, _ := g.IsRebaseInProgress(); inProgress {
+			result.Status = TrainConflict
+			result.Detail = "rebase still in progress: resolve conflicts and run 'git rebase --continue'"
+			return result
+		}
+
+		progress("Rebasing %s onto %s", name, s.Root)
+		if err := m.git.Fetch(); err != nil {
+			result.Status = TrainFailed
+			result.Detail = fmt.Sprintf("failed to fetch: %v", err)
+			return result
+		}
+		synced, err := m.SyncBranch(name, gh)
+		if err != nil {
+			result.Status = TrainFailed
+			result.Detail = err.Error()
+			return result
+		}
+		if synced.HasConflict {
+			result.Status = TrainConflict
+			result.Detail = fmt.Sprintf("resolve conflicts in %s and run 'git rebase --continue'", worktree)
+			return result
+		}
+		if synced.Error != nil {
+			result.Status = TrainFailed
+			result.Detail = synced.Error.Error()
+			return result
+		}
+
+		diverged, localAhead, _, err := m.git.HasDivergedFromOrigin(name)
+		if err != nil {
+			result.Status = TrainFailed
+			result.Detail = fmt.Sprintf("failed to compare with origin/%s: %v", name, err)
+			return result
+		}
+		if diverged || localAhead > 0 {
+			// A PR without checks before the push is merged without them
+			if checks, err := gh.GetPRChecks(branch.PRNumber); err == nil {
+				hadChecks = checks.State != "none" && checks.State != "unknown"
+			}
+			progress("Force pushing %s", name)
+			if err := g.PushForce(); err != nil {
+				result.Status = TrainFailed
+				result.Detail = fmt.Sprintf("failed to push: %v", err)
+				return result
+			}
+			if pushedHead, err = m.git.GetBranchCommit(name); err != nil {
+				result.Status = TrainFailed
+				result.Detail = fmt.Sprintf("failed to read the pushed head of %s: %v", name, err)
+				return result
+			}
+		}
+	}
+
+	deadline := time.Now().Add(opts.ChecksTimeout)
+	for {
+		checks, err := gh.GetPRChecks(branch.PRNumber)
+		if err != nil {
+			checks = &github.CheckStatus{State: "unknown", Summary: err.Error()}
+		}
+		if pushedHead != "" {
+			if pr, err := gh.GetPR(branch.PRNumber); err != nil || pr.HeadSHA != pushedHead {
+				checks = &github.CheckStatus{State: "pending", Summary: "waiting for the PR to pick up the push"}
+			} else if checks.State == "none" && hadChecks {
+				checks = &github.CheckStatus{State: "pending", Summary: "waiting for checks to start"}
+			} else {
+				pushedHead = ""
+			}
+		}
+		if checks.State == "success" || checks.State == "none" {
+			break
+		}
+		if checks.State == "failure" || checks.State == "error" {
+			result.Status = TrainChecksFailed
+			result.Detail = checks.Summary
+			return result
+		}
+		if !time.Now().Add(opts.PollInterval).Before(deadline) {
+			result.Status = TrainChecksPending
+			result.Detail = checks.Summary
+			return result
+		}
+		progress("Waiting for checks on PR #%d (%s)", branch.PRNumber, checks.Summary)
+		time.Sleep(opts.PollInterval)
+	}
+
+	progress("Merging PR #%d (%s) via %s", branch.PRNumber, name, state.Method)
+	if err := gh.MergePR(branch.PRNumber, state.Method, state.DeleteBranch); err != nil {
+		result.Status = TrainFailed
+		result.Detail = fmt.Sprintf("failed to merge PR #%d: %v", branch.PRNumber, err)
+		return result
+	}
+
+	result.Status = TrainMerged
+	return result
+}
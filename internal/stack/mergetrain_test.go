@@ -0,0 +1,209 @@
+package stack
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/github"
+)
+
+// fakeTrainClient squash-merges PRs into the test origin. Methods the merge
+// train does not use are left to the nil embedded interface.
+type fakeTrainClient struct {
+	github.ClientInterface
+	t        *testing.T
+	repoDir  string
+	heads    map[int]string // PR number -> head branch
+	bases    map[int]string
+	merged   map[int]bool
+	failing  map[int]bool     // PRs whose checks fail
+	checks   map[int][]string // check states returned in turn, the last one repeating
+	retarget []int
+}
+
+func (f *fakeTrainClient) GetPR(number int) (*github.PR, error) {
+	state := "OPEN"
+	if f.merged[number] {
+		state = "MERGED"
+	}
+	headSHA, _, _ := strings.Cut(gitOutput(f.t, f.repoDir, "ls-remote", "origin", "refs/heads/"+f.heads[number]), "\t")
+	return &github.PR{Number: number, Head: f.heads[number], Base: f.bases[number], HeadSHA: headSHA, State: state, Merged: f.merged[number]}, nil
+}
+
+func (f *fakeTrainClient) UpdatePRBase(number int, base string) error {
+	f.bases[number] = base
+	f.retarget = append(f.retarget, number)
+	return nil
+}
+
+func (f *fakeTrainClient) GetPRChecks(number int) (*github.CheckStatus, error) {
+	if states := f.checks[number]; len(states) > 0 {
+		if len(states) > 1 {
+			f.checks[number] = states[1:]
+		}
+		return &github.CheckStatus{State: states[0], Summary: states[0]}, nil
+	}
+	if f.failing[number] {
+		return &github.CheckStatus{State: "failure", Summary: "1/1 failed"}, nil
+	}
+	return &github.CheckStatus{State: "success", Summary: "1/1 passed"}, nil
+}
+
+func (f *fakeTrainClient) MergePR(number int, method string, deleteRemoteBranch bool) error {
+	head := f.heads[number]
+	gitOutput(f.t, f.repoDir, "fetch", "-q", "origin")
+	squash := gitOutput(f.t, f.repoDir, "commit-tree", "origin/"+head+"^{tree}", "-p", "origin/main", "-m", "Squash "+head)
+	gitOutput(f.t, f.repoDir, "push", "-q", "origin", squash+":refs/heads/main")
+	f.merged[number] = true
+	return nil
+}
+
+func TestManager_MergeTrain(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+
+	bareDir := filepath.Join(filepath.Dir(repoDir), "bare.git")
+	exec.Command("git", "init", "--bare", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "remote", "add", "origin", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "push", "-u", "origin", "main").Run()
+
+	mgr, _ := NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-a", "main", "", ""); err != nil {
+		t.Fatalf("CreateBranch feature-a failed: %v", err)
+	}
+	commitFile(t, filepath.Join(worktreeBaseDir, "feature-a"), "a.txt")
+	mgr, _ = NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-b", "feature-a", "", ""); err != nil {
+		t.Fatalf("CreateBranch feature-b failed: %v", err)
+	}
+	oldB := commitFile(t, filepath.Join(worktreeBaseDir, "feature-b"), "b.txt")
+	gitOutput(t, repoDir, "push", "-q", "origin", "feature-a", "feature-b")
+
+	cache, _ := config.LoadCacheConfig(repoDir)
+	for i, name := range []string{"feature-a", "feature-b"} {
+		cache.SetBranchCache(name, &config.BranchCache{PRUrl: "https://github.com/org/repo/pull/" + string(rune('1'+i))})
+	}
+	cache.Save(repoDir)
+
+	gh := &fakeTrainClient{
+		t:       t,
+		repoDir: repoDir,
+		heads:   map[int]string{1: "feature-a", 2: "feature-b"},
+		bases:   map[int]string{1: "main", 2: "feature-a"},
+		merged:  map[int]bool{},
+		failing: map[int]bool{2: true},
+	}
+
+	mgr, _ = NewManager(repoDir)
+	s := mgr.GetStackForBranch("feature-b")
+	branches, err := mgr.PlanMergeTrain(s, "")
+	if err != nil {
+		t.Fatalf("PlanMergeTrain() error = %v", err)
+	}
+	if len(branches) != 2 || branches[0].Name != "feature-a" || branches[1].Name != "feature-b" {
+		t.Fatalf("PlanMergeTrain() = %v, want [feature-a feature-b]", branches)
+	}
+
+	state, err := mgr.StartMergeTrain(s, branches, "squash", true)
+	if err != nil {
+		t.Fatalf("StartMergeTrain() error = %v", err)
+	}
+	results, err := mgr.RunMergeTrain(gh, state, MergeTrainOptions{})
+	if err != nil {
+		t.Fatalf("RunMergeTrain() error = %v", err)
+	}
+	if len(results) != 2 || results[0].Status != TrainMerged || results[1].Status != TrainChecksFailed {
+		t.Fatalf("RunMergeTrain() = %+v, want merged then checks failed", results)
+	}
+	if gh.bases[2] != "main" {
+		t.Errorf("PR #2 base = %q, want main", gh.bases[2])
+	}
+	if got := gitOutput(t, repoDir, "rev-parse", "origin/feature-b"); got == oldB {
+		t.Error("feature-b was not rebased and force-pushed onto the merged main")
+	}
+
+	// The stopped train is resumable once the checks pass
+	gh.failing[2] = false
+	mgr, _ = NewManager(repoDir)
+	pending, err := mgr.PendingMergeTrain()
+	if err != nil || pending == nil || !pending.IsMerged("feature-a") {
+		t.Fatalf("PendingMergeTrain() = %+v, %v; want train with feature-a merged", pending, err)
+	}
+	results, err = mgr.RunMergeTrain(gh, pending, MergeTrainOptions{})
+	if err != nil {
+		t.Fatalf("RunMergeTrain() resume error = %v", err)
+	}
+	if len(results) != 2 || results[1].Status != TrainMerged || !gh.merged[2] {
+		t.Fatalf("resumed RunMergeTrain() = %+v, want feature-b merged", results)
+	}
+	if pending, _ := mgr.PendingMergeTrain(); pending != nil {
+		t.Error("merge train state should be cleared after the train finishes")
+	}
+}
+
+// TestManager_MergeTrainWaitsForChecksAfterPush verifies that after a
+// force-push the train doesn't take a PR reporting no checks as green when it
+// had checks before, and waits for the new ones instead
+func TestManager_MergeTrainWaitsForChecksAfterPush(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+
+	bareDir := filepath.Join(filepath.Dir(repoDir), "bare.git")
+	exec.Command("git", "init", "--bare", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "remote", "add", "origin", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "push", "-u", "origin", "main").Run()
+
+	mgr, _ := NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-a", "main", "", ""); err != nil {
+		t.Fatalf("CreateBranch feature-a failed: %v", err)
+	}
+	commitFile(t, filepath.Join(worktreeBaseDir, "feature-a"), "a.txt")
+	mgr, _ = NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-b", "feature-a", "", ""); err != nil {
+		t.Fatalf("CreateBranch feature-b failed: %v", err)
+	}
+	commitFile(t, filepath.Join(worktreeBaseDir, "feature-b"), "b.txt")
+	gitOutput(t, repoDir, "push", "-q", "origin", "feature-a", "feature-b")
+
+	cache, _ := config.LoadCacheConfig(repoDir)
+	for i, name := range []string{"feature-a", "feature-b"} {
+		cache.SetBranchCache(name, &config.BranchCache{PRUrl: "https://github.com/org/repo/pull/" + string(rune('1'+i))})
+	}
+	cache.Save(repoDir)
+
+	// PR #2 reports its old checks before the push, none right after it, then
+	// the new ones
+	gh := &fakeTrainClient{
+		t:       t,
+		repoDir: repoDir,
+		heads:   map[int]string{1: "feature-a", 2: "feature-b"},
+		bases:   map[int]string{1: "main", 2: "feature-a"},
+		merged:  map[int]bool{},
+		checks:  map[int][]string{2: {"success", "none", "pending", "failure"}},
+	}
+
+	mgr, _ = NewManager(repoDir)
+	s := mgr.GetStackForBranch("feature-b")
+	branches, err := mgr.PlanMergeTrain(s, "")
+	if err != nil {
+		t.Fatalf("PlanMergeTrain() error = %v", err)
+	}
+	state, err := mgr.StartMergeTrain(s, branches, "squash", true)
+	if err != nil {
+		t.Fatalf("StartMergeTrain() error = %v", err)
+	}
+	results, err := mgr.RunMergeTrain(gh, state, MergeTrainOptions{ChecksTimeout: time.Second, PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("RunMergeTrain() error = %v", err)
+	}
+	if len(results) != 2 || results[0].Status != TrainMerged || results[1].Status != TrainChecksFailed {
+		t.Fatalf("RunMergeTrain() = %+v, want merged then the new checks failing", results)
+	}
+	if gh.merged[2] {
+		t.Error("PR #2 was merged before its new checks ran")
+	}
+}
@@ -0,0 +1,130 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
+)
+
+// RestackPrediction is the predicted outcome of rebasing one branch during sync
+type RestackPrediction struct {
+	Branch    string
+	StackRoot string
+	Onto      string   // Branch or ref it would be rebased onto
+	Follows   string   // Non-empty if it only needs syncing because this parent moves
+	Clean     bool     // The rebase would apply without conflicts
+	Conflicts []string // Files that would conflict
+	Commit    string   // First commit that would conflict
+	BlockedBy string   // Branch whose predicted conflict stops the sync before this one
+	Err       error    // The outcome couldn't be predicted
+}
+
+// PredictSync predicts, without changing any branch or worktree, how syncing
+// the branches in syncNeeded (from DetectSyncNeeded*) would go. Each stack is
+// restacked in memory the way sync would, with 'git merge-tree', so branches
+// are checked against their parent's predicted new head. Branches above a
+// rebased branch are included, since sync moves them too. A conflict stops a
+// stack's sync, so the branches after it in the stack are reported as blocked.
+func (m *Manager) PredictSync(stacks []*config.Stack, syncNeeded []SyncInfo) []RestackPrediction {
+	needed := make(map[string]bool)
+	merged := make(map[string]bool)
+	for _, info := range syncNeeded {
+		needed[info.Branch] = true
+		if info.MergedParent != "" {
+			merged[info.MergedParent] = true
+		}
+	}
+
+	var predictions []RestackPrediction
+	for _, stack := range stacks {
+		heads := make(map[string]string) // predicted new heads
+		unknown := make(map[string]bool) // branches whose new head isn't known
+		stoppedAt := ""
+
+		for _, branch := range stack.Branches {
+			if branch.IsMerged {
+				continue
+			}
+			// Children of merged parents are moved onto the nearest
+			// non-merged ancestor
+			parent := branch.Parent
+			for merged[parent] {
+				p := m.GetBranch(parent)
+				if p == nil {
+					break
+				}
+				parent = p.Parent
+			}
+			_, parentMoved := heads[parent]
+			if !needed[branch.Name] && !parentMoved && !unknown[parent] {
+				continue
+			}
+
+			pred := RestackPrediction{Branch: branch.Name, StackRoot: stack.Root, Onto: parent}
+			if parent == stack.Root {
+				pred.Onto = "origin/" + stack.Root
+			}
+			if !needed[branch.Name] {
+				pred.Follows = parent
+			}
+			if stoppedAt != "" {
+				pred.BlockedBy = stoppedAt
+				unknown[branch.Name] = true
+				predictions = append(predictions, pred)
+				continue
+			}
+			if unknown[parent] {
+				pred.Err = fmt.Errorf("depends on how '%s' is rebased", parent)
+				unknown[branch.Name] = true
+				predictions = append(predictions, pred)
+				continue
+			}
+
+			newBase := m.getParentRef(parent)
+			if parent == stack.Root {
+				newBase = "origin/" + stack.Root
+			}
+			if head, ok := heads[parent]; ok {
+				newBase = head
+			}
+			// Same upstreams as runSyncPlan: the merge-base with a merged
+			// parent, the parent's pre-sync head, or origin/<root> itself
+			upstream := ""
+			if parent != branch.Parent {
+				oldParentRef := m.getParentRef(branch.Parent)
+				upstream = oldParentRef
+				if mergeBase, err := m.git.GetMergeBase(branch.Name, oldParentRef); err == nil {
+					upstream = mergeBase
+				}
+			} else if parent != stack.Root {
+				oldHead, err := m.git.GetBranchCommit(m.getParentRef(parent))
+				if err != nil {
+					pred.Err = err
+					unknown[branch.Name] = true
+					predictions = append(predictions, pred)
+					continue
+				}
+				upstream = oldHead
+			}
+
+			newHead, err := m.git.Replay(branch.Name, newBase, upstream)
+			var conflict *git.ReplayConflict
+			switch {
+			case errors.As(err, &conflict):
+				pred.Conflicts, pred.Commit = conflict.Files, conflict.Commit
+				unknown[branch.Name] = true
+				stoppedAt = branch.Name
+			case err != nil:
+				pred.Err = err
+				unknown[branch.Name] = true
+			default:
+				pred.Clean = true
+				heads[branch.Name] = newHead
+			}
+			predictions = append(predictions, pred)
+		}
+	}
+	return predictions
+}
@@ -0,0 +1,83 @@
+package stack
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestPredictSync_Clean verifies that branches above a rebased branch are
+// predicted against their parent's predicted head, without moving anything
+func TestPredictSync_Clean(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+	setupLinearStack(t, repoDir, worktreeBaseDir)
+	before := gitOutput(t, repoDir, "for-each-ref", "--format=%(refname) %(objectname)", "refs/heads/")
+
+	mgr, _ := NewManager(filepath.Join(worktreeBaseDir, "feature-a"))
+	syncNeeded, err := mgr.DetectSyncNeeded(nil)
+	if err != nil {
+		t.Fatalf("DetectSyncNeeded returned error: %v", err)
+	}
+	predictions := mgr.PredictSync(mgr.ListStacks(), syncNeeded)
+	if len(predictions) != 3 {
+		t.Fatalf("got %d predictions, want 3: %+v", len(predictions), predictions)
+	}
+
+	want := []struct{ branch, onto, follows string }{
+		{"feature-a", "origin/main", ""},
+		{"feature-b", "feature-a", "feature-a"},
+		{"feature-c", "feature-b", "feature-b"},
+	}
+	for i, w := range want {
+		p := predictions[i]
+		if p.Branch != w.branch || p.Onto != w.onto || p.Follows != w.follows {
+			t.Errorf("prediction %d = %+v, want %s onto %s following %q", i, p, w.branch, w.onto, w.follows)
+		}
+		if !p.Clean {
+			t.Errorf("%s predicted not clean: %+v", p.Branch, p)
+		}
+	}
+
+	if after := gitOutput(t, repoDir, "for-each-ref", "--format=%(refname) %(objectname)", "refs/heads/"); after != before {
+		t.Errorf("PredictSync moved branches:\n%s\nwant\n%s", after, before)
+	}
+}
+
+// TestPredictSync_Conflict verifies that a conflict reports its files and
+// the branches after it are reported as blocked
+func TestPredictSync_Conflict(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+	setupLinearStack(t, repoDir, worktreeBaseDir)
+	os.WriteFile(filepath.Join(repoDir, "feature-b.txt"), []byte("main\n"), 0644)
+	exec.Command("git", "-C", repoDir, "add", ".").Run()
+	exec.Command("git", "-C", repoDir, "commit", "-m", "add feature-b.txt on main").Run()
+	exec.Command("git", "-C", repoDir, "push", "origin", "main").Run()
+
+	mgr, _ := NewManager(filepath.Join(worktreeBaseDir, "feature-a"))
+	syncNeeded, err := mgr.DetectSyncNeeded(nil)
+	if err != nil {
+		t.Fatalf("DetectSyncNeeded returned error: %v", err)
+	}
+	predictions := mgr.PredictSync(mgr.ListStacks(), syncNeeded)
+	if len(predictions) != 3 {
+		t.Fatalf("got %d predictions, want 3: %+v", len(predictions), predictions)
+	}
+
+	if !predictions[0].Clean {
+		t.Errorf("feature-a predicted not clean: %+v", predictions[0])
+	}
+	b := predictions[1]
+	if b.Clean || strings.Join(b.Conflicts, ",") != "feature-b.txt" || b.Commit != gitOutput(t, repoDir, "rev-parse", "feature-b") {
+		t.Errorf("feature-b prediction = %+v, want a conflict in feature-b.txt", b)
+	}
+	if c := predictions[2]; c.Clean || c.BlockedBy != "feature-b" {
+		t.Errorf("feature-c prediction = %+v, want blocked by feature-b", c)
+	}
+	if status := gitOutput(t, filepath.Join(worktreeBaseDir, "feature-b"), "status", "--porcelain"); status != "" {
+		t.Errorf("feature-b worktree changed: %q", status)
+	}
+}
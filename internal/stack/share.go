@@ -0,0 +1,260 @@
+package stack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
+)
+
+// stackRefPrefix is where shared stacks live, one ref per stack hash
+const stackRefPrefix = "refs/ezstack/stacks/"
+
+// stackRefFile is the file holding the stack in each shared stack commit
+const stackRefFile = "stack.json"
+
+// sharedStack is the part of a stack that is exchanged with teammates. Branch
+// metadata such as worktree paths and PR state stays local.
+type sharedStack struct {
+	Name      string            `json:"name,omitempty"`
+	Root      string            `json:"root"`
+	RootPRUrl string            `json:"root_pr_url,omitempty"`
+	Tree      config.BranchTree `json:"tree"`
+}
+
+// StackPullResult summarizes what 'ezs stack pull' changed
+type StackPullResult struct {
+	Added    []string // hashes of stacks that were new locally
+	Updated  []string // hashes of local stacks that gained branches or a name
+	Branches []string // branches created locally from origin
+	Skipped  []string // human-readable reasons incoming data was ignored
+}
+
+// PushStacks records each stack under refs/ezstack/stacks/<hash> and pushes
+// the refs to origin. A stack's ref only gets a new commit when the stack
+// changed, on top of the last version pushed or pulled, so pushes of stacks
+// a teammate changed in the meantime are rejected unless force is set.
+// Returns the hashes of the rejected stacks.
+func (m *Manager) PushStacks(stacks []*config.Stack, force bool) ([]string, error) {
+	refs, err := m.git.ListRefs(stackRefPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var refspecs []string
+	for _, s := range stacks {
+		data, err := json.MarshalIndent(sharedStack{
+			Name:      s.Name,
+			Root:      s.Root,
+			RootPRUrl: s.RootPRUrl,
+			Tree:      s.Tree,
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		ref := stackRefPrefix + s.Hash
+		parent := refs[ref]
+		if parent != "" {
+			if current, err := m.git.ReadFileAt(parent, stackRefFile); err == nil && bytes.Equal(current, data) {
+				refspecs = append(refspecs, pushRefspec(ref, force))
+				continue
+			}
+		}
+		commit, err := m.git.CommitFile(stackRefFile, data, parent, "Update stack "+s.DisplayName())
+		if err != nil {
+			return nil, fmt.Errorf("failed to record stack %s: %w", s.DisplayName(), err)
+		}
+		if err := m.git.UpdateRef(ref, commit); err != nil {
+			return nil, err
+		}
+		refspecs = append(refspecs, pushRefspec(ref, force))
+	}
+	if len(refspecs) == 0 {
+		return nil, nil
+	}
+
+	rejected, err := m.git.PushRefs(refspecs...)
+	if err != nil {
+		return nil, err
+	}
+	var hashes []string
+	for _, ref := range rejected {
+		hashes = append(hashes, strings.TrimPrefix(ref, stackRefPrefix))
+	}
+	return hashes, nil
+}
+
+func pushRefspec(ref string, force bool) string {
+	if force {
+		return "+" + ref + ":" + ref
+	}
+	return ref + ":" + ref
+}
+
+// PullStacks fetches the shared stacks from origin and merges them into the
+// local stacks. Only the given hashes are merged when any are passed. Stacks
+// that are new locally are added as they are. For stacks that already exist,
+// branches missing locally are added under their incoming parent while
+// branches present on both sides keep their local parent, so local
+// restructuring is never undone. Branches that don't exist locally are
+// created from origin without a worktree.
+func (m *Manager) PullStacks(hashes []string) (*StackPullResult, error) {
+	if err := m.Fetch(); err != nil {
+		return nil, err
+	}
+	if err := m.git.FetchRefs("+" + stackRefPrefix + "*:" + stackRefPrefix + "*"); err != nil {
+		return nil, fmt.Errorf("failed to fetch shared stacks: %w", err)
+	}
+	refs, err := m.git.ListRefs(stackRefPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, h := range hashes {
+		wanted[h] = true
+	}
+
+	var names []string
+	for ref := range refs {
+		names = append(names, ref)
+	}
+	sort.Strings(names)
+
+	result := &StackPullResult{}
+	cache := m.stackConfig.Cache
+	for _, ref := range names {
+		hash := strings.TrimPrefix(ref, stackRefPrefix)
+		if len(wanted) > 0 && !wanted[hash] {
+			continue
+		}
+		delete(wanted, hash)
+
+		data, err := m.git.ReadFileAt(refs[ref], stackRefFile)
+		if err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("stack %s: %v", hash, err))
+			continue
+		}
+		var incoming sharedStack
+		if err := json.Unmarshal(data, &incoming); err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("stack %s: invalid %s: %v", hash, stackRefFile, err))
+			continue
+		}
+
+		local := m.stackConfig.Stacks[hash]
+		isNew := local == nil
+		if err := git.ValidateBranchName(incoming.Root); err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("stack %s: root: %v", hash, err))
+			continue
+		}
+		if isNew {
+			local = &config.Stack{
+				Hash:      hash,
+				Name:      incoming.Name,
+				Root:      incoming.Root,
+				RootPRUrl: incoming.RootPRUrl,
+				Tree:      config.BranchTree{},
+			}
+			local.RootPRNumber = config.PRNumberFromURL(local.RootPRUrl)
+		} else if local.Root != incoming.Root {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("stack %s: based on %s here but on %s remotely", local.DisplayName(), local.Root, incoming.Root))
+			continue
+		}
+
+		changed := false
+		if !isNew && local.Name == "" && incoming.Name != "" {
+			local.Name = incoming.Name
+			changed = true
+		}
+		if !isNew && local.RootPRUrl == "" && incoming.RootPRUrl != "" {
+			local.RootPRUrl = incoming.RootPRUrl
+			local.RootPRNumber = config.PRNumberFromURL(local.RootPRUrl)
+			changed = true
+		}
+
+		added := m.mergeSharedTree(local, local.Root, incoming.Tree, result)
+		if added > 0 {
+			changed = true
+		}
+
+		if isNew {
+			if len(local.Tree) == 0 {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("stack %s: none of its branches exist on origin", local.DisplayName()))
+				continue
+			}
+			m.stackConfig.Stacks[hash] = local
+			result.Added = append(result.Added, hash)
+		} else if changed {
+			result.Updated = append(result.Updated, hash)
+		}
+		local.PopulateBranchesWithCache(cache)
+	}
+
+	for hash := range wanted {
+		result.Skipped = append(result.Skipped, fmt.Sprintf("stack %s: not found on origin", hash))
+	}
+
+	if len(result.Added) > 0 || len(result.Updated) > 0 {
+		if err := m.stackConfig.Save(m.repoDir); err != nil {
+			return nil, fmt.Errorf("failed to save stack config: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// mergeSharedTree adds the branches of an incoming subtree that s doesn't
+// have yet under parent, recursing into children. The tree comes from origin,
+// so names that aren't valid branch names are skipped along with their
+// children; every parent below the root is thus a validated name. Returns how
+// many branches were added.
+func (m *Manager) mergeSharedTree(s *config.Stack, parent string, tree config.BranchTree, result *StackPullResult) int {
+	names := make([]string, 0, len(tree))
+	for name := range tree {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	added := 0
+	for _, name := range names {
+		children := tree[name]
+		if err := git.ValidateBranchName(name); err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("branch %s: %v", name, err))
+			continue
+		}
+		if s.HasBranch(name) {
+			added += m.mergeSharedTree(s, name, children, result)
+			continue
+		}
+		if other := m.GetStackForBranch(name); other != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("branch %s: already in stack %s", name, other.DisplayName()))
+			continue
+		}
+		if parent != s.Root && !s.HasBranch(parent) {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("branch %s: parent %s was skipped", name, parent))
+			continue
+		}
+
+		if !m.git.BranchExists(name) {
+			if !m.git.RemoteBranchExists(name) {
+				// Most likely merged and deleted; keep its children under its parent
+				added += m.mergeSharedTree(s, parent, children, result)
+				continue
+			}
+			if err := m.git.SetBranchRef(name, "origin/"+name); err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("branch %s: %v", name, err))
+				continue
+			}
+			result.Branches = append(result.Branches, name)
+		}
+
+		s.AddBranch(name, parent)
+		added++
+		added += m.mergeSharedTree(s, name, children, result)
+	}
+	return added
+}
@@ -0,0 +1,141 @@
+package stack
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManager_PushPullStacks(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+
+	bareDir := filepath.Join(filepath.Dir(repoDir), "bare.git")
+	exec.Command("git", "init", "--bare", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "remote", "add", "origin", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "push", "-u", "origin", "main").Run()
+
+	mgr, _ := NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-a", "main", "", ""); err != nil {
+		t.Fatalf("CreateBranch feature-a failed: %v", err)
+	}
+	commitFile(t, filepath.Join(worktreeBaseDir, "feature-a"), "a.txt")
+	mgr, _ = NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-b", "feature-a", "", ""); err != nil {
+		t.Fatalf("CreateBranch feature-b failed: %v", err)
+	}
+	commitFile(t, filepath.Join(worktreeBaseDir, "feature-b"), "b.txt")
+	gitOutput(t, repoDir, "push", "-q", "origin", "feature-a", "feature-b")
+
+	mgr, _ = NewManager(repoDir)
+	s := mgr.GetStackForBranch("feature-a")
+	mgr.SetStackName(s.Hash, "shared")
+	rejected, err := mgr.PushStacks(mgr.ListStacks(), false)
+	if err != nil || len(rejected) != 0 {
+		t.Fatalf("PushStacks() = %v, %v; want no rejections", rejected, err)
+	}
+
+	// A teammate's clone picks the stack up, with its branches
+	cloneDir := filepath.Join(filepath.Dir(repoDir), "clone")
+	exec.Command("git", "clone", "-q", bareDir, cloneDir).Run()
+	exec.Command("git", "-C", cloneDir, "config", "user.email", "teammate@test.com").Run()
+	exec.Command("git", "-C", cloneDir, "config", "user.name", "Teammate").Run()
+
+	other, err := NewManager(cloneDir)
+	if err != nil {
+		t.Fatalf("NewManager(clone) error = %v", err)
+	}
+	result, err := other.PullStacks(nil)
+	if err != nil {
+		t.Fatalf("PullStacks() error = %v", err)
+	}
+	if len(result.Added) != 1 || result.Added[0] != s.Hash {
+		t.Fatalf("PullStacks().Added = %v, want [%s]", result.Added, s.Hash)
+	}
+	other, _ = NewManager(cloneDir)
+	pulled := other.GetStackByHashExact(s.Hash)
+	if pulled == nil || pulled.Name != "shared" {
+		t.Fatalf("pulled stack = %+v, want stack named shared", pulled)
+	}
+	if b := other.GetBranch("feature-b"); b == nil || b.Parent != "feature-a" {
+		t.Fatalf("pulled feature-b = %+v, want parent feature-a", b)
+	}
+
+	// The original author extends the stack; the teammate's stale push is rejected
+	mgr, _ = NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-c", "feature-b", "", ""); err != nil {
+		t.Fatalf("CreateBranch feature-c failed: %v", err)
+	}
+	commitFile(t, filepath.Join(worktreeBaseDir, "feature-c"), "c.txt")
+	gitOutput(t, repoDir, "push", "-q", "origin", "feature-c")
+	mgr, _ = NewManager(repoDir)
+	if rejected, err := mgr.PushStacks(mgr.ListStacks(), false); err != nil || len(rejected) != 0 {
+		t.Fatalf("PushStacks() = %v, %v; want no rejections", rejected, err)
+	}
+
+	other.SetStackName(s.Hash, "renamed")
+	other, _ = NewManager(cloneDir)
+	rejected, err = other.PushStacks(other.ListStacks(), false)
+	if err != nil || len(rejected) != 1 || rejected[0] != s.Hash {
+		t.Fatalf("stale PushStacks() = %v, %v; want %s rejected", rejected, err, s.Hash)
+	}
+
+	// Pulling merges the new branch and keeps the local name, after which the push goes through
+	result, err = other.PullStacks(nil)
+	if err != nil {
+		t.Fatalf("PullStacks() error = %v", err)
+	}
+	if len(result.Updated) != 1 {
+		t.Fatalf("PullStacks().Updated = %v, want the shared stack", result.Updated)
+	}
+	other, _ = NewManager(cloneDir)
+	if b := other.GetBranch("feature-c"); b == nil || b.Parent != "feature-b" {
+		t.Fatalf("pulled feature-c = %+v, want parent feature-b", b)
+	}
+	if name := other.GetStackByHashExact(s.Hash).Name; name != "renamed" {
+		t.Errorf("stack name = %q after pull, want local name kept", name)
+	}
+	if rejected, err := other.PushStacks(other.ListStacks(), false); err != nil || len(rejected) != 0 {
+		t.Fatalf("PushStacks() after pull = %v, %v; want no rejections", rejected, err)
+	}
+}
+
+// TestManager_PullStacksRejectsInvalidNames verifies that branch names from a
+// shared stack on origin are validated before they are used
+func TestManager_PullStacksRejectsInvalidNames(t *testing.T) {
+	repoDir, _, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+
+	bareDir := filepath.Join(filepath.Dir(repoDir), "bare.git")
+	exec.Command("git", "init", "--bare", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "remote", "add", "origin", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "push", "-u", "origin", "main").Run()
+	gitOutput(t, repoDir, "push", "-q", "origin", "main:feature-a")
+
+	mgr, _ := NewManager(repoDir)
+	data := []byte(`{"root":"main","tree":{"feature-a":{"--upload-pack=evil":{"feature-c":{}}}}}`)
+	commit, err := mgr.git.CommitFile(stackRefFile, data, "", "Shared stack")
+	if err != nil {
+		t.Fatalf("CommitFile() error = %v", err)
+	}
+	gitOutput(t, repoDir, "push", "-q", "origin", commit+":"+stackRefPrefix+"abc1234")
+
+	result, err := mgr.PullStacks(nil)
+	if err != nil {
+		t.Fatalf("PullStacks() error = %v", err)
+	}
+	if len(result.Added) != 1 {
+		t.Fatalf("PullStacks().Added = %v, want the stack with its valid branch", result.Added)
+	}
+	if len(result.Skipped) != 1 || !strings.Contains(result.Skipped[0], "--upload-pack=evil") {
+		t.Errorf("PullStacks().Skipped = %v, want the invalid branch", result.Skipped)
+	}
+	mgr, _ = NewManager(repoDir)
+	if mgr.GetBranch("feature-a") == nil {
+		t.Error("feature-a should have been pulled")
+	}
+	if mgr.GetBranch("--upload-pack=evil") != nil || mgr.GetBranch("feature-c") != nil {
+		t.Error("the invalid branch and its children should have been skipped")
+	}
+}
@@ -0,0 +1,103 @@
+package stack
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+)
+
+// SplitPoint is a new branch created by SplitBranch, ending at Commit
+type SplitPoint struct {
+	Name   string
+	Commit string
+}
+
+// SplitBranch splits a branch into a chain of stacked branches. Each split
+// point becomes a new branch at its commit, inserted between the branch's
+// parent and the branch in the order given. The original branch keeps its
+// name, commits and PR, and becomes the last piece, so its children stay on it
+// and nothing needs to be rebased.
+func (m *Manager) SplitBranch(branchName string, points []SplitPoint, useWorktrees bool) ([]*config.Branch, error) {
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no split points given")
+	}
+
+	stackKey := m.findStackForBranch(branchName)
+	if stackKey == "" {
+		return nil, fmt.Errorf("branch '%s' is not in a stack", branchName)
+	}
+	stack := m.stackConfig.Stacks[stackKey]
+	parent, found := stack.FindBranch(branchName)
+	if !found {
+		return nil, fmt.Errorf("branch '%s' is not in a stack", branchName)
+	}
+
+	for _, p := range points {
+		if m.git.BranchExists(p.Name) {
+			return nil, fmt.Errorf("branch '%s' already exists", p.Name)
+		}
+	}
+
+	worktreeBaseDir := ""
+	if useWorktrees {
+		if m.repoConfig == nil || m.repoConfig.WorktreeBaseDir == "" {
+			return nil, fmt.Errorf("no worktree base directory configured for this repo. Run: ezs config set worktree_base_dir <path>")
+		}
+		worktreeBaseDir = m.repoConfig.WorktreeBaseDir
+	}
+
+	// Create every branch before touching the stack, removing the ones
+	// already created if one fails, so a failed split leaves nothing behind
+	worktrees := make([]string, 0, len(points))
+	removeCreated := func() {
+		for i := len(worktrees) - 1; i >= 0; i-- {
+			name := points[i].Name
+			if worktrees[i] != "" {
+				m.git.RemoveWorktree(worktrees[i], true, name)
+			} else {
+				m.git.DeleteBranch(name, true)
+			}
+		}
+	}
+	for _, p := range points {
+		worktreePath := ""
+		if useWorktrees {
+			worktreePath = filepath.Join(worktreeBaseDir, p.Name)
+			if err := m.git.CreateWorktree(p.Name, worktreePath, p.Commit); err != nil {
+				removeCreated()
+				return nil, fmt.Errorf("failed to create worktree for '%s': %w", p.Name, err)
+			}
+		} else if err := m.git.CreateBranchOnly(p.Name, p.Commit); err != nil {
+			removeCreated()
+			return nil, fmt.Errorf("failed to create branch '%s': %w", p.Name, err)
+		}
+		worktrees = append(worktrees, worktreePath)
+	}
+
+	cache := m.stackConfig.Cache
+	for i, p := range points {
+		stack.AddBranch(p.Name, parent)
+		cache.SetBranchCache(p.Name, &config.BranchCache{
+			WorktreePath: worktrees[i],
+		})
+		parent = p.Name
+	}
+
+	// Move the original branch (and its subtree) onto the last new piece
+	stack.ReparentBranch(branchName, parent)
+	stack.PopulateBranchesWithCache(cache)
+
+	if err := m.stackConfig.Save(m.repoDir); err != nil {
+		removeCreated()
+		return nil, fmt.Errorf("failed to save stack config: %w", err)
+	}
+
+	var created []*config.Branch
+	for _, p := range points {
+		if b := m.GetBranch(p.Name); b != nil {
+			created = append(created, b)
+		}
+	}
+	return created, nil
+}
@@ -0,0 +1,131 @@
+package stack
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManager_SplitBranch(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	mgr, _ := NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature", "main", "", ""); err != nil {
+		t.Fatalf("CreateBranch() error = %v", err)
+	}
+
+	featureDir := filepath.Join(worktreeBaseDir, "feature")
+	var hashes []string
+	for _, name := range []string{"one", "two", "three"} {
+		os.WriteFile(filepath.Join(featureDir, name+".txt"), []byte(name+"\n"), 0644)
+		exec.Command("git", "-C", featureDir, "add", ".").Run()
+		exec.Command("git", "-C", featureDir, "commit", "-m", name).Run()
+		out, err := exec.Command("git", "-C", featureDir, "rev-parse", "HEAD").Output()
+		if err != nil {
+			t.Fatalf("rev-parse failed: %v", err)
+		}
+		hashes = append(hashes, strings.TrimSpace(string(out)))
+	}
+
+	mgr, _ = NewManager(repoDir)
+	mgr.CreateBranch("feature-child", "feature", "", "")
+
+	mgr, _ = NewManager(repoDir)
+	created, err := mgr.SplitBranch("feature", []SplitPoint{
+		{Name: "feature-one", Commit: hashes[0]},
+		{Name: "feature-two", Commit: hashes[1]},
+	}, true)
+	if err != nil {
+		t.Fatalf("SplitBranch() error = %v", err)
+	}
+	if len(created) != 2 {
+		t.Fatalf("SplitBranch() created %d branches, want 2", len(created))
+	}
+
+	// Reload to verify the saved tree
+	mgr, _ = NewManager(repoDir)
+	parents := map[string]string{
+		"feature-one":   "main",
+		"feature-two":   "feature-one",
+		"feature":       "feature-two",
+		"feature-child": "feature",
+	}
+	for name, want := range parents {
+		b := mgr.GetBranch(name)
+		if b == nil {
+			t.Fatalf("branch %q not found after split", name)
+		}
+		if b.Parent != want {
+			t.Errorf("%s.Parent = %q, want %q", name, b.Parent, want)
+		}
+	}
+
+	for i, name := range []string{"feature-one", "feature-two", "feature"} {
+		out, err := exec.Command("git", "-C", repoDir, "rev-parse", name).Output()
+		if err != nil {
+			t.Fatalf("rev-parse %s failed: %v", name, err)
+		}
+		if got := strings.TrimSpace(string(out)); got != hashes[i] {
+			t.Errorf("%s points at %s, want %s", name, got, hashes[i])
+		}
+	}
+
+	if b := mgr.GetBranch("feature-one"); b.WorktreePath != filepath.Join(worktreeBaseDir, "feature-one") {
+		t.Errorf("feature-one.WorktreePath = %q", b.WorktreePath)
+	}
+}
+
+func TestManager_SplitBranch_ExistingName(t *testing.T) {
+	repoDir, _, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	mgr, _ := NewManager(repoDir)
+	mgr.CreateBranch("feature-a", "main", "", "")
+
+	mgr, _ = NewManager(repoDir)
+	mgr.CreateBranch("feature-b", "feature-a", "", "")
+
+	mgr, _ = NewManager(repoDir)
+	_, err := mgr.SplitBranch("feature-b", []SplitPoint{{Name: "feature-a", Commit: "HEAD"}}, false)
+	if err == nil {
+		t.Error("SplitBranch() should fail when a split point name already exists")
+	}
+}
+
+// TestManager_SplitBranch_PartialFailure verifies that when creating a later
+// piece fails, the pieces already created are removed and the stack is unchanged
+func TestManager_SplitBranch_PartialFailure(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	mgr, _ := NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature", "main", "", ""); err != nil {
+		t.Fatalf("CreateBranch() error = %v", err)
+	}
+
+	mgr, _ = NewManager(repoDir)
+	_, err := mgr.SplitBranch("feature", []SplitPoint{
+		{Name: "feature-one", Commit: "main"},
+		{Name: "feature-two", Commit: "no-such-commit"},
+	}, true)
+	if err == nil {
+		t.Fatal("SplitBranch() should fail when a split point can't be created")
+	}
+
+	if mgr.git.BranchExists("feature-one") {
+		t.Error("feature-one should have been deleted")
+	}
+	if _, err := os.Stat(filepath.Join(worktreeBaseDir, "feature-one")); !os.IsNotExist(err) {
+		t.Errorf("feature-one worktree should have been removed, stat error = %v", err)
+	}
+	mgr, _ = NewManager(repoDir)
+	if b := mgr.GetBranch("feature"); b == nil || b.Parent != "main" {
+		t.Errorf("feature = %+v, want it still on main", b)
+	}
+	if mgr.GetBranch("feature-one") != nil {
+		t.Error("feature-one should not be in the stack")
+	}
+}
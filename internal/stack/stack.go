@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/KulkarniKaustubh/ezstack/internal/config"
 	"github.com/KulkarniKaustubh/ezstack/internal/git"
@@ -18,6 +19,9 @@ type Manager struct {
 	stackConfig *config.StackConfig
 	repoDir     string
 	fetched     bool
+
+	// mu guards stackConfig while stacks are synced in parallel
+	mu sync.RWMutex
 }
 
 // Fetch runs git fetch once per Manager lifetime. Subsequent calls are no-ops.
@@ -379,6 +383,8 @@ func (m *Manager) ListStacks() []*config.Stack {
 
 // GetBranch returns a branch by name
 func (m *Manager) GetBranch(name string) *config.Branch {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	for _, stack := range m.stackConfig.Stacks {
 		for _, branch := range stack.Branches {
 			if branch.Name == name {
@@ -600,6 +606,21 @@ func (m *Manager) ReparentBranch(branchName, newParentName string, doRebase bool
 	return m.addBranchWithParent(branchName, newParentName, doRebase)
 }
 
+// MoveChildren reparents each of the given branches (with their subtrees) onto
+// newParent and rebases them onto it. It is used to insert a branch into the
+// middle of a stack. Conflicts are reported in the results, not as errors.
+func (m *Manager) MoveChildren(newParent string, children []string) ([]*ReparentResult, error) {
+	var results []*ReparentResult
+	for _, child := range children {
+		result, err := m.ReparentBranch(child, newParent, true)
+		if err != nil {
+			return results, fmt.Errorf("failed to move '%s' under '%s': %w", child, newParent, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
 // reparentExistingBranch handles reparenting a branch that's already in a stack.
 // Config changes are saved first, then rebase is attempted if requested.
 func (m *Manager) reparentExistingBranch(branch *config.Branch, newParentName string, doRebase bool) (*ReparentResult, error) {
@@ -662,8 +683,13 @@ func (m *Manager) reparentExistingBranch(branch *config.Branch, newParentName st
 	result := &ReparentResult{Branch: m.GetBranch(branch.Name)}
 
 	// Perform git rebase if requested (after config is saved)
-	if doRebase && branch.WorktreePath != "" {
-		g := git.New(branch.WorktreePath)
+	if doRebase {
+		workdir, isTemp, release, err := m.rebaseWorkdir(branch)
+		if err != nil {
+			return result, err
+		}
+		defer release()
+		g := git.New(workdir)
 
 		// Get the merge-base between current branch and old parent
 		oldParentRef := m.getParentRef(oldParent)
@@ -676,9 +702,12 @@ func (m *Manager) reparentExistingBranch(branch *config.Branch, newParentName st
 
 		// Rebase onto new parent
 		rebaseResult := g.RebaseOntoNonInteractive(newParentRef, mergeBase)
-		if rebaseResult.HasConflict {
+		if rebaseResult.HasConflict && isTemp {
+			g.RebaseAbort()
+			return result, tempConflictError(branch.Name)
+		} else if rebaseResult.HasConflict {
 			result.HasConflict = true
-			result.ConflictDir = branch.WorktreePath
+			result.ConflictDir = workdir
 		} else if rebaseResult.Error != nil {
 			return result, fmt.Errorf("rebase failed: %w", rebaseResult.Error)
 		}
@@ -746,17 +775,25 @@ func (m *Manager) addBranchWithParent(branchName, newParentName string, doRebase
 
 	result := &ReparentResult{Branch: m.GetBranch(branchName)}
 
-	// Perform git rebase if requested and we have a worktree (after config is saved)
-	if doRebase && worktreePath != "" {
-		g := git.New(worktreePath)
+	// Perform git rebase if requested (after config is saved)
+	if doRebase {
+		workdir, isTemp, release, err := m.rebaseWorkdir(result.Branch)
+		if err != nil {
+			return result, err
+		}
+		defer release()
+		g := git.New(workdir)
 
 		newParentRef := m.getParentRef(newParentName)
 
 		// Simple rebase onto new parent
 		rebaseResult := g.RebaseNonInteractive(newParentRef)
-		if rebaseResult.HasConflict {
+		if rebaseResult.HasConflict && isTemp {
+			g.RebaseAbort()
+			return result, tempConflictError(branchName)
+		} else if rebaseResult.HasConflict {
 			result.HasConflict = true
-			result.ConflictDir = worktreePath
+			result.ConflictDir = workdir
 		} else if rebaseResult.Error != nil {
 			return result, fmt.Errorf("rebase failed: %w", rebaseResult.Error)
 		}
@@ -463,6 +463,56 @@ func TestManager_ReparentBranch_SameStack(t *testing.T) {
 	}
 }
 
+// TestManager_MoveChildren tests inserting a branch between a parent and its children
+func TestManager_MoveChildren(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	// main -> feature-a -> {feature-b, feature-c}, then insert feature-mid on feature-a
+	mgr, _ := NewManager(repoDir)
+	mgr.CreateBranch("feature-a", "main", "", "")
+	commitFile(t, filepath.Join(worktreeBaseDir, "feature-a"), "a.txt")
+
+	mgr, _ = NewManager(repoDir)
+	mgr.CreateBranch("feature-b", "feature-a", "", "")
+	commitFile(t, filepath.Join(worktreeBaseDir, "feature-b"), "b.txt")
+
+	mgr, _ = NewManager(repoDir)
+	mgr.CreateBranch("feature-c", "feature-a", "", "")
+
+	mgr, _ = NewManager(repoDir)
+	s := mgr.GetStackForBranch("feature-a")
+	if _, err := mgr.CreateBranch("feature-mid", "feature-a", "", s.Hash); err != nil {
+		t.Fatalf("CreateBranch() error = %v", err)
+	}
+	commitFile(t, filepath.Join(worktreeBaseDir, "feature-mid"), "mid.txt")
+
+	results, err := mgr.MoveChildren("feature-mid", []string{"feature-b", "feature-c"})
+	if err != nil {
+		t.Fatalf("MoveChildren() error = %v", err)
+	}
+	for _, r := range results {
+		if r.HasConflict {
+			t.Errorf("unexpected conflict moving %s", r.Branch.Name)
+		}
+	}
+
+	mgr, _ = NewManager(repoDir)
+	for _, name := range []string{"feature-b", "feature-c"} {
+		b := mgr.GetBranch(name)
+		if b == nil || b.Parent != "feature-mid" {
+			t.Errorf("%s should be under feature-mid, got %+v", name, b)
+		}
+		// Rebased onto feature-mid, so its new commit is present
+		if _, err := os.Stat(filepath.Join(worktreeBaseDir, name, "mid.txt")); err != nil {
+			t.Errorf("%s was not rebased onto feature-mid", name)
+		}
+	}
+	if b := mgr.GetBranch("feature-mid"); b == nil || b.Parent != "feature-a" {
+		t.Errorf("feature-mid should be under feature-a, got %+v", b)
+	}
+}
+
 // TestManager_ReparentBranch_ToMain tests reparenting a branch to main
 func TestManager_ReparentBranch_ToMain(t *testing.T) {
 	repoDir, _, cleanup := setupTestEnv(t)
@@ -0,0 +1,61 @@
+package stack
+
+import (
+	"os"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
+)
+
+// BranchState is the local git state of a stack branch, as of the last fetch
+type BranchState struct {
+	AheadOfParent int   // commits on the branch that its parent doesn't have
+	BehindParent  int   // commits on the parent the branch doesn't have
+	BehindRoot    int   // commits on the stack root (origin's when known) the branch doesn't have
+	OnOrigin      bool  // whether origin/<branch> exists
+	AheadOfOrigin int   // commits not pushed yet
+	BehindOrigin  int   // commits on origin/<branch> that aren't local
+	Diverged      bool  // both of the above, e.g. after a rebase
+	Dirty         *bool // uncommitted changes in the branch's worktree, nil without one
+	NeedsSync     bool
+	SyncReason    string // "parent_merged", "behind_parent" or "behind_root" when NeedsSync
+}
+
+// GetBranchState works out how a branch of s relates to its parent, the
+// stack root and origin. It only reads local refs, so it reflects the last
+// fetch; counts that can't be computed (e.g. the branch is gone) are zero.
+func (m *Manager) GetBranchState(s *config.Stack, b *config.Branch) *BranchState {
+	state := &BranchState{}
+
+	if b.WorktreePath != "" {
+		if _, err := os.Stat(b.WorktreePath); err == nil {
+			if dirty, err := git.New(b.WorktreePath).HasChanges(); err == nil {
+				state.Dirty = &dirty
+			}
+		}
+	}
+	if b.IsMerged || !m.git.BranchExists(b.Name) {
+		return state
+	}
+
+	parentRef := m.getParentRef(b.Parent)
+	state.AheadOfParent, _ = m.git.GetCommitsAhead(b.Name, parentRef)
+	state.BehindParent, _ = m.git.GetCommitsBehind(b.Name, parentRef)
+	state.BehindRoot, _ = m.git.GetCommitsBehind(b.Name, m.getParentRef(s.Root))
+
+	state.OnOrigin = m.git.RemoteBranchExists(b.Name)
+	state.Diverged, state.AheadOfOrigin, state.BehindOrigin, _ = m.git.HasDivergedFromOrigin(b.Name)
+
+	if info := m.DetectSyncNeededForBranch(b.Name, nil); info != nil && info.NeedsSync {
+		state.NeedsSync = true
+		switch {
+		case info.MergedParent != "":
+			state.SyncReason = "parent_merged"
+		case info.BehindParent != "":
+			state.SyncReason = "behind_parent"
+		default:
+			state.SyncReason = "behind_root"
+		}
+	}
+	return state
+}
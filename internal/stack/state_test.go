@@ -0,0 +1,58 @@
+package stack
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestManager_GetBranchState(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+
+	bareDir := filepath.Join(filepath.Dir(repoDir), "bare.git")
+	exec.Command("git", "init", "--bare", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "remote", "add", "origin", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "push", "-u", "origin", "main").Run()
+
+	mgr, _ := NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-a", "main", "", ""); err != nil {
+		t.Fatalf("CreateBranch feature-a failed: %v", err)
+	}
+	aDir := filepath.Join(worktreeBaseDir, "feature-a")
+	commitFile(t, aDir, "a.txt")
+	mgr, _ = NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-b", "feature-a", "", ""); err != nil {
+		t.Fatalf("CreateBranch feature-b failed: %v", err)
+	}
+	bDir := filepath.Join(worktreeBaseDir, "feature-b")
+	commitFile(t, bDir, "b.txt")
+	gitOutput(t, repoDir, "push", "-q", "origin", "feature-a", "feature-b")
+
+	// feature-a moves on locally and feature-b gets uncommitted changes
+	commitFile(t, aDir, "a2.txt")
+	os.WriteFile(filepath.Join(bDir, "b.txt"), []byte("changed"), 0644)
+
+	mgr, _ = NewManager(repoDir)
+	s := mgr.GetStackForBranch("feature-a")
+
+	a := mgr.GetBranchState(s, mgr.GetBranch("feature-a"))
+	if a.AheadOfParent != 2 || a.BehindParent != 0 || !a.OnOrigin || a.AheadOfOrigin != 1 || a.Diverged {
+		t.Errorf("feature-a state = %+v, want 2 ahead of main and 1 ahead of origin", a)
+	}
+	if a.Dirty == nil || *a.Dirty || a.NeedsSync {
+		t.Errorf("feature-a state = %+v, want a clean worktree and no sync needed", a)
+	}
+
+	b := mgr.GetBranchState(s, mgr.GetBranch("feature-b"))
+	if b.AheadOfParent != 1 || b.BehindParent != 1 || b.BehindRoot != 0 {
+		t.Errorf("feature-b state = %+v, want 1 ahead and 1 behind feature-a", b)
+	}
+	if b.Dirty == nil || !*b.Dirty {
+		t.Errorf("feature-b state = %+v, want a dirty worktree", b)
+	}
+	if !b.NeedsSync || b.SyncReason != "behind_parent" {
+		t.Errorf("feature-b state = %+v, want a sync for being behind its parent", b)
+	}
+}
@@ -0,0 +1,173 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/github"
+)
+
+// How a branch is pushed by 'ezs submit'
+const (
+	PushNone   = ""
+	PushNew    = "push new branch"
+	PushUpdate = "push"
+	PushForce  = "force push"
+)
+
+// SubmitStep is what 'ezs submit' does for one branch of the stack
+type SubmitStep struct {
+	Branch   *config.Branch
+	Push     string     // one of the Push* constants
+	FoundPR  *github.PR // open PR on the remote that wasn't cached yet
+	CreatePR bool
+	Retarget string // current base of a PR whose base is not the branch's parent
+	Note     string // why part of the branch is left alone
+	Err      error  // set by Submit when a step failed
+}
+
+// SubmitOptions controls how new PRs are created
+type SubmitOptions struct {
+	Draft    bool
+	Title    func(branch string) string // title for new PRs
+	Progress func(msg string)           // optional, called as each action starts
+}
+
+// PlanSubmit works out, for each unmerged branch of the stack in parent to
+// child order, whether it needs pushing, whether its PR has to be created or
+// its base fixed. Nothing is changed.
+func (m *Manager) PlanSubmit(gh github.ClientInterface, s *config.Stack) ([]*SubmitStep, error) {
+	if err := m.Fetch(); err != nil {
+		return nil, err
+	}
+
+	var steps []*SubmitStep
+	for _, b := range config.SortBranchesTopologically(s.Branches) {
+		if b.IsMerged || b.IsRemote {
+			continue
+		}
+		step := &SubmitStep{Branch: b}
+		steps = append(steps, step)
+
+		if !m.git.RemoteBranchExists(b.Name) {
+			step.Push = PushNew
+		} else {
+			diverged, localAhead, remoteAhead, err := m.git.HasDivergedFromOrigin(b.Name)
+			switch {
+			case err != nil:
+				return nil, fmt.Errorf("failed to compare %s with origin: %w", b.Name, err)
+			case diverged:
+				step.Push = PushForce
+			case localAhead > 0:
+				step.Push = PushUpdate
+			case remoteAhead > 0:
+				step.Note = fmt.Sprintf("origin/%s has %d commit(s) this branch doesn't; not pushing", b.Name, remoteAhead)
+			}
+		}
+
+		var pr *github.PR
+		if b.PRNumber > 0 {
+			found, err := gh.GetPR(b.PRNumber)
+			if err != nil {
+				step.Note = fmt.Sprintf("could not get PR #%d: %v", b.PRNumber, err)
+				continue
+			}
+			pr = found
+		} else if found, err := gh.GetPRByBranch(b.Name); err == nil && found != nil && !found.Merged && found.State != "CLOSED" {
+			step.FoundPR = found
+			pr = found
+		} else {
+			ahead, err := m.git.GetCommitsAhead(b.Name, b.Parent)
+			if err != nil {
+				return nil, fmt.Errorf("failed to count commits of %s: %w", b.Name, err)
+			}
+			if ahead == 0 {
+				step.Note = fmt.Sprintf("no commits ahead of %s; no PR created", b.Parent)
+			} else {
+				step.CreatePR = true
+			}
+			continue
+		}
+
+		switch {
+		case pr.Merged:
+			step.Note = fmt.Sprintf("PR #%d is already merged", pr.Number)
+		case pr.State == "CLOSED":
+			step.Note = fmt.Sprintf("PR #%d is closed", pr.Number)
+		case pr.Base != b.Parent:
+			step.Retarget = pr.Base
+		}
+	}
+	return steps, nil
+}
+
+// Submit carries out a plan from PlanSubmit: pushes branches, records PRs
+// found on the remote, creates missing PRs against each branch's parent,
+// retargets PRs with a wrong base and refreshes the stack section of every
+// PR. Failures are recorded on their step and the rest carries on, except
+// for the branches above one that failed to push, which are skipped so no PR
+// is opened against a parent missing on the remote.
+func (m *Manager) Submit(gh github.ClientInterface, s *config.Stack, steps []*SubmitStep, opts SubmitOptions) error {
+	progress := func(format string, args ...any) {
+		if opts.Progress != nil {
+			opts.Progress(fmt.Sprintf(format, args...))
+		}
+	}
+
+	failed := make(map[string]bool) // branches that failed to push, or were skipped
+	for _, step := range steps {
+		b := step.Branch
+		if failed[b.Parent] {
+			step.Err = fmt.Errorf("skipped: '%s' was not pushed", b.Parent)
+			failed[b.Name] = true
+			continue
+		}
+		if step.Push != PushNone {
+			if err := m.git.PushBranch(b.Name, step.Push == PushForce); err != nil {
+				step.Err = fmt.Errorf("failed to push: %w", err)
+				failed[b.Name] = true
+				continue
+			}
+		}
+
+		switch {
+		case step.FoundPR != nil:
+			m.cachePR(b, step.FoundPR.Number, step.FoundPR.URL)
+		case step.CreatePR:
+			title := b.Name
+			if opts.Title != nil {
+				title = opts.Title(b.Name)
+			}
+			progress("Creating PR for %s (base: %s)", b.Name, b.Parent)
+			pr, err := gh.CreatePR(title, "", b.Name, b.Parent, opts.Draft)
+			if err != nil {
+				step.Err = fmt.Errorf("failed to create PR: %w", err)
+				continue
+			}
+			m.cachePR(b, pr.Number, pr.URL)
+		}
+	}
+
+	progress("Fixing PR base branches")
+	if err := gh.EnsureCorrectBaseBranches(s); err != nil {
+		return err
+	}
+	progress("Updating PR stack descriptions")
+	return gh.UpdateStackDescription(s, "")
+}
+
+// cachePR records a branch's PR on the branch and in the repo's branch cache
+func (m *Manager) cachePR(b *config.Branch, number int, url string) {
+	b.PRNumber = number
+	b.PRUrl = url
+	config.UpdateCacheConfig(m.repoDir, func(cache *config.CacheConfig) bool {
+		bc := cache.GetBranchCache(b.Name)
+		if bc == nil {
+			bc = &config.BranchCache{}
+		}
+		bc.PRNumber = number
+		bc.PRUrl = url
+		cache.SetBranchCache(b.Name, bc)
+		return true
+	})
+}
@@ -0,0 +1,200 @@
+package stack
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/github"
+)
+
+// fakeSubmitClient keeps PRs in memory. Methods submit does not use are left
+// to the nil embedded interface.
+type fakeSubmitClient struct {
+	github.ClientInterface
+	prs      map[int]*github.PR
+	created  []string
+	descSeen int
+}
+
+func (f *fakeSubmitClient) GetPR(number int) (*github.PR, error) {
+	pr, ok := f.prs[number]
+	if !ok {
+		return nil, fmt.Errorf("no PR #%d", number)
+	}
+	return pr, nil
+}
+
+func (f *fakeSubmitClient) GetPRByBranch(branch string) (*github.PR, error) {
+	for _, pr := range f.prs {
+		if pr.Head == branch {
+			return pr, nil
+		}
+	}
+	return nil, fmt.Errorf("no PR for %s", branch)
+}
+
+func (f *fakeSubmitClient) CreatePR(title, body, head, base string, draft bool) (*github.PR, error) {
+	n := len(f.prs) + 1
+	pr := &github.PR{Number: n, URL: fmt.Sprintf("https://github.com/org/repo/pull/%d", n), Head: head, Base: base, State: "OPEN", IsDraft: draft}
+	f.prs[n] = pr
+	f.created = append(f.created, head)
+	return pr, nil
+}
+
+func (f *fakeSubmitClient) EnsureCorrectBaseBranches(s *config.Stack) error {
+	for _, b := range s.Branches {
+		if pr, ok := f.prs[b.PRNumber]; ok {
+			pr.Base = b.Parent
+		}
+	}
+	return nil
+}
+
+func (f *fakeSubmitClient) UpdateStackDescription(s *config.Stack, current string) error {
+	f.descSeen++
+	return nil
+}
+
+func TestManager_Submit(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+
+	bareDir := filepath.Join(filepath.Dir(repoDir), "bare.git")
+	exec.Command("git", "init", "--bare", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "remote", "add", "origin", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "push", "-u", "origin", "main").Run()
+
+	mgr, _ := NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-a", "main", "", ""); err != nil {
+		t.Fatalf("CreateBranch feature-a failed: %v", err)
+	}
+	aDir := filepath.Join(worktreeBaseDir, "feature-a")
+	commitFile(t, aDir, "a.txt")
+	mgr, _ = NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-b", "feature-a", "", ""); err != nil {
+		t.Fatalf("CreateBranch feature-b failed: %v", err)
+	}
+	commitFile(t, filepath.Join(worktreeBaseDir, "feature-b"), "b.txt")
+	mgr, _ = NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-c", "feature-b", "", ""); err != nil {
+		t.Fatalf("CreateBranch feature-c failed: %v", err)
+	}
+
+	// feature-a was pushed and then rewritten; feature-b has an open PR the
+	// cache doesn't know about, against the wrong base
+	gitOutput(t, repoDir, "push", "-q", "origin", "feature-a", "feature-b")
+	gitOutput(t, aDir, "commit", "-q", "--amend", "-m", "Rewritten a")
+	gh := &fakeSubmitClient{prs: map[int]*github.PR{
+		1: {Number: 1, URL: "https://github.com/org/repo/pull/1", Head: "feature-b", Base: "main", State: "OPEN"},
+	}}
+
+	mgr, _ = NewManager(repoDir)
+	s := mgr.GetStackForBranch("feature-a")
+	steps, err := mgr.PlanSubmit(gh, s)
+	if err != nil {
+		t.Fatalf("PlanSubmit() error = %v", err)
+	}
+	plan := make(map[string]*SubmitStep)
+	var order []string
+	for _, step := range steps {
+		plan[step.Branch.Name] = step
+		order = append(order, step.Branch.Name)
+	}
+	if fmt.Sprint(order) != "[feature-a feature-b feature-c]" {
+		t.Fatalf("plan order = %v, want parents first", order)
+	}
+	if a := plan["feature-a"]; a.Push != PushForce || !a.CreatePR {
+		t.Errorf("feature-a step = %+v, want force push and a new PR", a)
+	}
+	if b := plan["feature-b"]; b.Push != PushNone || b.FoundPR == nil || b.CreatePR {
+		t.Errorf("feature-b step = %+v, want the existing PR picked up", b)
+	}
+	if c := plan["feature-c"]; c.Push != PushNew || c.CreatePR || c.Note == "" {
+		t.Errorf("feature-c step = %+v, want a push but no PR (no commits)", c)
+	}
+
+	if err := mgr.Submit(gh, s, steps, SubmitOptions{Draft: true}); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	for _, step := range steps {
+		if step.Err != nil {
+			t.Fatalf("step %s failed: %v", step.Branch.Name, step.Err)
+		}
+	}
+	if fmt.Sprint(gh.created) != "[feature-a]" {
+		t.Errorf("created PRs for %v, want [feature-a]", gh.created)
+	}
+	if gh.prs[1].Base != "feature-a" {
+		t.Errorf("PR #1 base = %q, want feature-a", gh.prs[1].Base)
+	}
+	if gh.descSeen != 1 {
+		t.Errorf("stack descriptions updated %d times, want 1", gh.descSeen)
+	}
+	if got, want := gitOutput(t, repoDir, "rev-parse", "origin/feature-a"), gitOutput(t, repoDir, "rev-parse", "feature-a"); got != want {
+		t.Error("origin/feature-a should have the rewritten commit")
+	}
+	if !mgr.git.RemoteBranchExists("feature-c") {
+		t.Error("feature-c should have been pushed")
+	}
+
+	mgr, _ = NewManager(repoDir)
+	if b := mgr.GetBranch("feature-b"); b == nil || b.PRNumber != 1 {
+		t.Errorf("feature-b = %+v, want PR #1 cached", b)
+	}
+	if a := mgr.GetBranch("feature-a"); a == nil || a.PRNumber != 2 {
+		t.Errorf("feature-a = %+v, want PR #2 cached", a)
+	}
+}
+
+// TestManager_SubmitSkipsAfterFailedPush verifies that the branches above one
+// that failed to push are neither pushed nor get a PR
+func TestManager_SubmitSkipsAfterFailedPush(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+
+	bareDir := filepath.Join(filepath.Dir(repoDir), "bare.git")
+	exec.Command("git", "init", "--bare", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "remote", "add", "origin", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "push", "-u", "origin", "main").Run()
+	hook := "#!/bin/sh\nwhile read old new ref; do [ \"$ref\" = refs/heads/feature-a ] && exit 1; done\nexit 0\n"
+	if err := os.WriteFile(filepath.Join(bareDir, "hooks", "pre-receive"), []byte(hook), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	parent := "main"
+	for _, name := range []string{"feature-a", "feature-b"} {
+		mgr, _ := NewManager(repoDir)
+		if _, err := mgr.CreateBranch(name, parent, filepath.Join(worktreeBaseDir, name), ""); err != nil {
+			t.Fatalf("CreateBranch %s failed: %v", name, err)
+		}
+		commitFile(t, filepath.Join(worktreeBaseDir, name), name+".txt")
+		parent = name
+	}
+
+	gh := &fakeSubmitClient{prs: map[int]*github.PR{}}
+	mgr, _ := NewManager(repoDir)
+	s := mgr.GetStackForBranch("feature-a")
+	steps, err := mgr.PlanSubmit(gh, s)
+	if err != nil {
+		t.Fatalf("PlanSubmit() error = %v", err)
+	}
+	if err := mgr.Submit(gh, s, steps, SubmitOptions{}); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	for _, step := range steps {
+		if step.Err == nil {
+			t.Errorf("step %s succeeded, want it to fail or be skipped", step.Branch.Name)
+		}
+	}
+	if len(gh.created) != 0 {
+		t.Errorf("created PRs for %v, want none", gh.created)
+	}
+	if mgr.git.RemoteBranchExists("feature-b") {
+		t.Error("feature-b should not have been pushed")
+	}
+}
@@ -1,8 +1,13 @@
 package stack
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/KulkarniKaustubh/ezstack/internal/config"
 	"github.com/KulkarniKaustubh/ezstack/internal/git"
@@ -59,11 +64,12 @@ type RebaseResult struct {
 // SyncInfo contains information about a branch that needs syncing
 type SyncInfo struct {
 	Branch       string
-	MergedParent string // Non-empty if parent was merged
-	BehindBy     int    // Number of commits behind target
-	BehindParent string // Non-empty if behind a non-main parent
-	StackRoot    string // The root branch of this branch's stack (e.g. "main", "develop")
-	NeedsSync    bool   // True if branch needs to be synced
+	MergedParent string   // Non-empty if parent was merged
+	BehindBy     int      // Number of commits behind target
+	BehindParent string   // Non-empty if behind a non-main parent
+	StackRoot    string   // The root branch of this branch's stack (e.g. "main", "develop")
+	NeedsSync    bool     // True if branch needs to be synced
+	Stacked      []string // Branches above Branch rebased along with it in one go
 }
 
 // MergedBranchInfo contains information about a branch whose PR has been merged
@@ -98,6 +104,9 @@ type SyncCallbacks struct {
 	BeforeRebase BeforeRebaseCallback
 	AfterRebase  AfterRebaseCallback
 	Autostash    bool // Stash uncommitted changes before rebase, pop after
+	// Parallel is how many independent stacks are synced at once when syncing
+	// all stacks. Above 1, the callbacks are called from several goroutines.
+	Parallel int
 }
 
 // getParentRef returns the git ref for a parent branch.
@@ -119,24 +128,24 @@ func (m *Manager) getParentRef(parentName string) string {
 // DetectSyncNeeded checks for branches that need syncing in the CURRENT stack only:
 // - Branches whose parents have been merged to main
 // - Branches whose parent is main but are behind origin/main
-func (m *Manager) DetectSyncNeeded(gh *github.Client) ([]SyncInfo, error) {
+func (m *Manager) DetectSyncNeeded(gh github.ClientInterface) ([]SyncInfo, error) {
 	return m.detectSyncNeededInternal(gh, true, nil)
 }
 
 // DetectSyncNeededAllStacks checks for branches that need syncing across ALL stacks:
 // - Branches whose parents have been merged to main
 // - Branches whose parent is main but are behind origin/main
-func (m *Manager) DetectSyncNeededAllStacks(gh *github.Client) ([]SyncInfo, error) {
+func (m *Manager) DetectSyncNeededAllStacks(gh github.ClientInterface) ([]SyncInfo, error) {
 	return m.detectSyncNeededInternal(gh, false, nil)
 }
 
 // DetectSyncNeededForStacks checks for branches that need syncing in specific stacks
-func (m *Manager) DetectSyncNeededForStacks(gh *github.Client, stacks []*config.Stack) ([]SyncInfo, error) {
+func (m *Manager) DetectSyncNeededForStacks(gh github.ClientInterface, stacks []*config.Stack) ([]SyncInfo, error) {
 	return m.detectSyncNeededInternal(gh, false, stacks)
 }
 
 // detectSyncNeededInternal is the internal implementation that can work on current stack, all stacks, or specific stacks
-func (m *Manager) detectSyncNeededInternal(gh *github.Client, currentStackOnly bool, specificStacks []*config.Stack) ([]SyncInfo, error) {
+func (m *Manager) detectSyncNeededInternal(gh github.ClientInterface, currentStackOnly bool, specificStacks []*config.Stack) ([]SyncInfo, error) {
 	if err := m.Fetch(); err != nil {
 		return nil, err
 	}
@@ -158,6 +167,7 @@ func (m *Manager) detectSyncNeededInternal(gh *github.Client, currentStackOnly b
 		}
 	}
 
+	prs := newPRMergeChecker(gh, stacksToCheck)
 	for _, stack := range stacksToCheck {
 		for _, branch := range stack.Branches {
 			if branch.IsMerged {
@@ -185,14 +195,8 @@ func (m *Manager) detectSyncNeededInternal(gh *github.Client, currentStackOnly b
 				isMerged = true
 			}
 
-			if !isMerged && gh != nil {
-				parentBranch := m.GetBranch(branch.Parent)
-				if parentBranch != nil && parentBranch.PRNumber > 0 {
-					pr, err := gh.GetPR(parentBranch.PRNumber)
-					if err == nil && pr.Merged {
-						isMerged = true
-					}
-				}
+			if !isMerged && prs.isMerged(m.GetBranch(branch.Parent)) {
+				isMerged = true
 			}
 
 			if isMerged {
@@ -221,9 +225,48 @@ func (m *Manager) detectSyncNeededInternal(gh *github.Client, currentStackOnly b
 	return results, nil
 }
 
+// prMergeChecker answers whether a branch's PR was merged from one batched
+// status request for the stacks being checked. Branches the batch didn't
+// resolve to their cached PR fall back to a per-PR lookup.
+type prMergeChecker struct {
+	gh       github.ClientInterface
+	statuses map[string]*github.PRStatus
+}
+
+func newPRMergeChecker(gh github.ClientInterface, stacks []*config.Stack) *prMergeChecker {
+	c := &prMergeChecker{gh: gh}
+	if gh == nil {
+		return c
+	}
+	var names []string
+	for _, stack := range stacks {
+		for _, branch := range stack.Branches {
+			if branch.PRNumber > 0 {
+				names = append(names, branch.Name)
+			}
+		}
+	}
+	if len(names) > 0 {
+		c.statuses, _ = gh.GetPRStatuses(names)
+	}
+	return c
+}
+
+// isMerged reports whether branch has a PR that was merged
+func (c *prMergeChecker) isMerged(branch *config.Branch) bool {
+	if c.gh == nil || branch == nil || branch.PRNumber == 0 {
+		return false
+	}
+	if s := c.statuses[branch.Name]; s != nil && s.PR.Number == branch.PRNumber {
+		return s.PR.Merged
+	}
+	pr, err := c.gh.GetPR(branch.PRNumber)
+	return err == nil && pr.Merged
+}
+
 // DetectSyncNeededForBranch checks if a specific branch needs syncing
 // Returns SyncInfo if the branch needs syncing, nil otherwise
-func (m *Manager) DetectSyncNeededForBranch(branchName string, gh *github.Client) *SyncInfo {
+func (m *Manager) DetectSyncNeededForBranch(branchName string, gh github.ClientInterface) *SyncInfo {
 	branch := m.GetBranch(branchName)
 	if branch == nil || branch.IsMerged {
 		return nil
@@ -294,40 +337,33 @@ func (m *Manager) DetectSyncNeededForBranch(branchName string, gh *github.Client
 // - Branches whose parent was merged (rebase onto main using --onto)
 // - Branches whose parent is not merged but has new commits (rebase onto parent)
 // Callbacks can be used to ask for confirmation before each rebase and push after
-func (m *Manager) SyncStack(gh *github.Client, callbacks *SyncCallbacks) ([]RebaseResult, error) {
+func (m *Manager) SyncStack(gh github.ClientInterface, callbacks *SyncCallbacks) ([]RebaseResult, error) {
 	return m.syncStackInternal(gh, callbacks, true, nil)
 }
 
 // SyncStackAll syncs branches in ALL stacks that need syncing
-func (m *Manager) SyncStackAll(gh *github.Client, callbacks *SyncCallbacks) ([]RebaseResult, error) {
+func (m *Manager) SyncStackAll(gh github.ClientInterface, callbacks *SyncCallbacks) ([]RebaseResult, error) {
 	return m.syncStackInternal(gh, callbacks, false, nil)
 }
 
 // SyncSpecificStacks syncs branches in the given stacks
-func (m *Manager) SyncSpecificStacks(stacks []*config.Stack, gh *github.Client, callbacks *SyncCallbacks) ([]RebaseResult, error) {
+func (m *Manager) SyncSpecificStacks(stacks []*config.Stack, gh github.ClientInterface, callbacks *SyncCallbacks) ([]RebaseResult, error) {
 	return m.syncStackInternal(gh, callbacks, false, stacks)
 }
 
 // syncStackInternal is the internal implementation that can work on current stack, all stacks, or specific stacks
-func (m *Manager) syncStackInternal(gh *github.Client, callbacks *SyncCallbacks, currentStackOnly bool, specificStacks []*config.Stack) ([]RebaseResult, error) {
-	if err := m.Fetch(); err != nil {
+func (m *Manager) syncStackInternal(gh github.ClientInterface, callbacks *SyncCallbacks, currentStackOnly bool, specificStacks []*config.Stack) ([]RebaseResult, error) {
+	pending, err := m.PendingSync()
+	if err != nil {
 		return nil, err
 	}
-
-	var results []RebaseResult
-
-	// saveState persists cache and config; logs warnings on failure.
-	saveState := func(sc *syncCache) {
-		if err := sc.save(); err != nil {
-			fmt.Fprintf(os.Stderr, "  Warning: failed to save cache: %v\n", err)
-		}
-		if err := m.stackConfig.Save(m.repoDir); err != nil {
-			fmt.Fprintf(os.Stderr, "  Warning: failed to save config: %v\n", err)
-		}
+	if pending != nil {
+		return nil, fmt.Errorf("a sync is already in progress: run 'ezs sync --continue' or 'ezs sync --abort'")
 	}
 
-	// Use combined cache from stack config
-	sc := newSyncCache(m.stackConfig, m.repoDir)
+	if err := m.Fetch(); err != nil {
+		return nil, err
+	}
 
 	// Get the stacks to sync
 	var stacksToSync []*config.Stack
@@ -345,82 +381,237 @@ func (m *Manager) syncStackInternal(gh *github.Client, callbacks *SyncCallbacks,
 		}
 	}
 
+	state := &config.SyncState{
+		AllStacks: !currentStackOnly,
+		Autostash: callbacks != nil && callbacks.Autostash,
+		OldHeads:  make(map[string]string),
+	}
+
 	// Record old HEAD commits for branches in selected stacks BEFORE any rebasing
 	// When parent is rebased, we need to know the old parent HEAD to correctly rebase children onto the new parent
-	oldHeads := make(map[string]string)
 	for _, stack := range stacksToSync {
+		state.Stacks = append(state.Stacks, stack.Hash)
 		for _, branch := range stack.Branches {
 			if commit, err := m.git.GetBranchCommit(branch.Name); err == nil {
-				oldHeads[branch.Name] = commit
+				state.OldHeads[branch.Name] = commit
 			}
 		}
 	}
+	// Save the plan before anything moves, so a sync that dies midway can
+	// still be continued or aborted
+	if err := config.SaveSyncState(m.repoDir, state); err != nil {
+		return nil, fmt.Errorf("failed to save sync state: %w", err)
+	}
+	defer m.finishSyncState(state)
+
+	return m.runSyncPlan(gh, callbacks, stacksToSync, state, make(map[string]bool))
+}
+
+// runSyncPlan syncs every branch of the given stacks that the state has not
+// already completed. Conflicts are recorded in the state so the sync can be
+// resumed. halted marks stacks (by hash) whose remaining branches are skipped.
+// When syncing all stacks with callbacks.Parallel above 1, independent stacks
+// are synced concurrently, at most Parallel at a time; the results are
+// returned grouped by stack in the same order as a sequential sync.
+func (m *Manager) runSyncPlan(gh github.ClientInterface, callbacks *SyncCallbacks, stacksToSync []*config.Stack, state *config.SyncState, halted map[string]bool) ([]RebaseResult, error) {
+	workers := 1
+	if callbacks != nil {
+		workers = callbacks.Parallel
+	}
+	groups := independentStacks(stacksToSync)
+	workers = min(workers, len(groups))
+	if !state.AllStacks || workers <= 1 {
+		return m.syncStacksInOrder(gh, callbacks, stacksToSync, state, halted)
+	}
+
+	groupResults := make([][]RebaseResult, len(groups))
+	errs := make([]error, len(groups))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, group := range groups {
+		// Each group only halts its own stacks
+		groupHalted := make(map[string]bool)
+		for _, s := range group {
+			groupHalted[s.Hash] = halted[s.Hash]
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			groupResults[i], errs[i] = m.syncStacksInOrder(gh, callbacks, group, state, groupHalted)
+		}()
+	}
+	wg.Wait()
+
+	var results []RebaseResult
+	for _, r := range groupResults {
+		results = append(results, r...)
+	}
+	return results, errors.Join(errs...)
+}
+
+// independentStacks splits stacks into groups that can be synced at the same
+// time. A stack rooted on a branch of another stack is synced after it, in the
+// same group; stacks on a shared root like main share no branches and are
+// independent.
+func independentStacks(stacks []*config.Stack) [][]*config.Stack {
+	owner := make(map[string]int) // branch -> index of its stack
+	for i, s := range stacks {
+		for _, b := range s.Branches {
+			owner[b.Name] = i
+		}
+	}
 
-	allStacks := !currentStackOnly
+	// bottom follows a stack's root down to the stack that isn't rooted on
+	// another one; depth counts the steps. Cycles stop after len(stacks) steps.
+	bottom := func(i int) (int, int) {
+		depth := 0
+		for j, ok := owner[stacks[i].Root]; ok && depth < len(stacks); j, ok = owner[stacks[j].Root] {
+			i, depth = j, depth+1
+		}
+		return i, depth
+	}
+
+	type entry struct {
+		stack *config.Stack
+		depth int
+	}
+	var entries [][]entry
+	groupOf := make(map[int]int) // bottom stack -> group
+	for i, s := range stacks {
+		b, depth := bottom(i)
+		g, ok := groupOf[b]
+		if !ok {
+			g = len(entries)
+			groupOf[b] = g
+			entries = append(entries, nil)
+		}
+		entries[g] = append(entries[g], entry{s, depth})
+	}
+
+	groups := make([][]*config.Stack, len(entries))
+	for g, group := range entries {
+		sort.SliceStable(group, func(i, j int) bool { return group[i].depth < group[j].depth })
+		for _, e := range group {
+			groups[g] = append(groups[g], e.stack)
+		}
+	}
+	return groups
+}
+
+// syncStacksInOrder runs the sync plan for stacks one after the other
+func (m *Manager) syncStacksInOrder(gh github.ClientInterface, callbacks *SyncCallbacks, stacksToSync []*config.Stack, state *config.SyncState, halted map[string]bool) ([]RebaseResult, error) {
+	var results []RebaseResult
+
+	// saveState persists cache and config; logs warnings on failure.
+	saveState := func(sc *syncCache) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if err := sc.save(); err != nil {
+			fmt.Fprintf(os.Stderr, "  Warning: failed to save cache: %v\n", err)
+		}
+		if err := m.stackConfig.Save(m.repoDir); err != nil {
+			fmt.Fprintf(os.Stderr, "  Warning: failed to save config: %v\n", err)
+		}
+	}
+
+	// Use combined cache from stack config
+	sc := newSyncCache(m.stackConfig, m.repoDir)
+
+	oldHeads := state.OldHeads
+	allStacks := state.AllStacks
+	prs := newPRMergeChecker(gh, stacksToSync)
+
+	// Branches without a worktree are rebased in a temporary one, removed
+	// when the loop moves on to the next branch
+	releaseTemp := func() {}
+	defer func() { releaseTemp() }()
+
+	// Linear runs of branches are rebased together with --update-refs (git
+	// 2.38+) unless in-memory rebasing is on, which already avoids checkouts.
+	// confirmed holds bottom branches the user already agreed to sync when
+	// that fell back to syncing branch by branch.
+	inMemory := m.config != nil && m.config.GetInMemoryRebase(m.repoDir)
+	updateRefs := !inMemory && m.git.VersionAtLeast(2, 38)
+	confirmed := make(map[string]bool)
 
 	// Sync branches in selected stacks
 	for _, stack := range stacksToSync {
-		stackHasConflict := false // Track if this stack hit a conflict
 		for _, branch := range stack.Branches {
+			releaseTemp()
+			releaseTemp = func() {}
+
 			// Skip already-merged branches (they don't need syncing)
 			if branch.IsMerged {
 				continue
 			}
 
 			// If this stack already hit a conflict and we're syncing all stacks, skip rest of this stack
-			if stackHasConflict && allStacks {
+			if halted[stack.Hash] && allStacks {
 				continue
 			}
 
-			// Skip branches without a worktree path (can't rebase without a working directory)
-			if branch.WorktreePath == "" {
-				if callbacks != nil && callbacks.BeforeRebase != nil {
-					fmt.Fprintf(os.Stderr, "  Skipping %s: no worktree path (use 'ezs goto %s' to set up)\n", branch.Name, branch.Name)
-				}
+			// Skip branches handled before the sync was interrupted
+			if state.IsCompleted(branch.Name) {
 				continue
 			}
 
-			result := RebaseResult{Branch: branch.Name, WorktreePath: branch.WorktreePath}
-			g := git.New(branch.WorktreePath)
-
-			// Autostash: stash uncommitted changes before rebase
-			didStash := false
-			if callbacks != nil && callbacks.Autostash {
-				if hasChanges, _ := g.HasChanges(); hasChanges {
-					if err := g.StashPush(); err == nil {
-						didStash = true
+			if updateRefs && branch.Parent == stack.Root {
+				if chain := m.linearSegment(stack, branch, state, prs); chain != nil {
+					segment, handled, ok, stop := m.syncLinearSegment(stack, chain, state, callbacks)
+					results = append(results, segment...)
+					if stop {
+						if !allStacks {
+							return results, nil
+						}
+						halted[stack.Hash] = true
 					}
-				}
-			}
-			// popStash restores stashed changes after rebase completes.
-			// Not called on conflict — user resolves first, then runs 'git stash pop'.
-			popStash := func() {
-				if didStash {
-					if err := g.StashPop(); err != nil {
-						fmt.Fprintf(os.Stderr, "  Warning: failed to pop stash for %s: %v\n", branch.Name, err)
+					if handled {
+						continue
 					}
+					confirmed[branch.Name] = ok
 				}
 			}
-			// conflictMsg appends stash info to the conflict error when applicable
-			conflictMsg := func() string {
-				msg := fmt.Sprintf("resolve conflicts in: %s", branch.WorktreePath)
-				if didStash {
+			// The branch counts as completed once its rebase is released; until
+			// then it is also recorded as running, in case ezs dies mid-rebase
+			state.MarkCompleted(branch.Name)
+			rb := m.newBranchRebase(branch, callbacks != nil && callbacks.Autostash)
+			rb.track(state)
+			releaseTemp = rb.release
+
+			result := RebaseResult{Branch: branch.Name, WorktreePath: rb.worktree}
+			// conflict records a rebase conflict so the sync can be continued
+			// once it is resolved. A temporary worktree can't be left
+			// mid-rebase, so there the rebase is aborted instead.
+			conflict := func() {
+				if rb.temp {
+					result.HasConflict = true
+					abortTempConflict(rb.g, &result)
+					results = append(results, result)
+					return
+				}
+				msg := fmt.Sprintf("resolve conflicts in: %s", rb.dir)
+				if rb.stashed {
 					msg += " (uncommitted changes stashed — run 'git stash pop' after resolving)"
 				}
-				return msg
+				result.HasConflict = true
+				result.Error = fmt.Errorf("%s", msg)
+				results = append(results, result)
+				m.recordSyncConflict(state, result, rb.stashed)
 			}
 
 			if branch.Parent == stack.Root {
 				behindBy, err := m.git.GetCommitsBehind(branch.Name, "origin/"+stack.Root)
 				if err != nil || behindBy == 0 {
-					popStash()
+					rb.popStash()
 					continue
 				}
 
 				result.BehindBy = behindBy
 				result.SyncedParent = "origin/" + stack.Root
 
-				if callbacks != nil && callbacks.BeforeRebase != nil {
+				if callbacks != nil && callbacks.BeforeRebase != nil && !confirmed[branch.Name] {
 					syncInfo := SyncInfo{
 						Branch:    branch.Name,
 						BehindBy:  behindBy,
@@ -428,40 +619,38 @@ func (m *Manager) syncStackInternal(gh *github.Client, callbacks *SyncCallbacks,
 						NeedsSync: true,
 					}
 					if !callbacks.BeforeRebase(syncInfo) {
-						popStash()
+						rb.popStash()
 						continue
 					}
 				}
 
-				rebaseResult := g.RebaseNonInteractive("origin/" + stack.Root)
+				rebaseResult := rb.rebase("origin/"+stack.Root, "")
 				if rebaseResult.HasConflict {
-					result.HasConflict = true
-					result.Error = fmt.Errorf("%s", conflictMsg())
-					results = append(results, result)
+					conflict()
 					if !allStacks {
 						return results, nil
 					}
-					stackHasConflict = true
+					halted[stack.Hash] = true
 					continue
 				} else if rebaseResult.Error != nil {
-					popStash()
+					rb.popStash()
 					result.Error = rebaseResult.Error
 					results = append(results, result)
 					if !allStacks {
 						return results, nil
 					}
-					stackHasConflict = true
+					halted[stack.Hash] = true
 					continue
 				}
-				popStash()
+				rb.popStash()
 				result.Success = true
 				results = append(results, result)
 				if callbacks != nil && callbacks.AfterRebase != nil {
-					if !callbacks.AfterRebase(result, g) {
+					if !callbacks.AfterRebase(result, rb.repo()) {
 						if !allStacks {
 							return results, nil
 						}
-						stackHasConflict = true
+						halted[stack.Hash] = true
 						continue
 					}
 				}
@@ -476,14 +665,8 @@ func (m *Manager) syncStackInternal(gh *github.Client, callbacks *SyncCallbacks,
 				isMerged = true
 			}
 
-			if !isMerged && gh != nil {
-				parentBranch := m.GetBranch(branch.Parent)
-				if parentBranch != nil && parentBranch.PRNumber > 0 {
-					pr, err := gh.GetPR(parentBranch.PRNumber)
-					if err == nil && pr.Merged {
-						isMerged = true
-					}
-				}
+			if !isMerged && prs.isMerged(m.GetBranch(branch.Parent)) {
+				isMerged = true
 			}
 
 			if isMerged {
@@ -493,12 +676,13 @@ func (m *Manager) syncStackInternal(gh *github.Client, callbacks *SyncCallbacks,
 				oldParent := branch.Parent
 				oldParentRef := m.getParentRef(oldParent) // Use origin/<name> for remote parents
 
-				sc.markMerged(oldParent)
-
 				// Repopulate branches so walkTree recalculates effective parents
 				// (children of merged branches will now have their Parent field
 				// pointing to the nearest non-merged ancestor)
+				m.mu.Lock()
+				sc.markMerged(oldParent)
 				stack.PopulateBranchesWithCache(sc.cache)
+				m.mu.Unlock()
 
 				// Re-fetch the branch since Branches slice was rebuilt
 				var updatedBranch *config.Branch
@@ -509,7 +693,7 @@ func (m *Manager) syncStackInternal(gh *github.Client, callbacks *SyncCallbacks,
 					}
 				}
 				if updatedBranch == nil {
-					popStash()
+					rb.popStash()
 					continue
 				}
 
@@ -525,7 +709,7 @@ func (m *Manager) syncStackInternal(gh *github.Client, callbacks *SyncCallbacks,
 						NeedsSync:    true,
 					}
 					if !callbacks.BeforeRebase(syncInfo) {
-						popStash()
+						rb.popStash()
 						continue
 					}
 				}
@@ -541,38 +725,36 @@ func (m *Manager) syncStackInternal(gh *github.Client, callbacks *SyncCallbacks,
 					rebaseTarget = "origin/" + stack.Root
 				}
 
-				rebaseResult := g.RebaseOntoNonInteractive(rebaseTarget, mergeBase)
+				rebaseResult := rb.rebase(rebaseTarget, mergeBase)
 				if rebaseResult.HasConflict {
-					result.HasConflict = true
-					result.Error = fmt.Errorf("%s", conflictMsg())
-					results = append(results, result)
+					conflict()
 					saveState(sc)
 					if !allStacks {
 						return results, nil
 					}
-					stackHasConflict = true
+					halted[stack.Hash] = true
 					continue
 				} else if rebaseResult.Error != nil {
-					popStash()
+					rb.popStash()
 					result.Error = rebaseResult.Error
 					results = append(results, result)
 					saveState(sc)
 					if !allStacks {
 						return results, nil
 					}
-					stackHasConflict = true
+					halted[stack.Hash] = true
 					continue
 				}
-				popStash()
+				rb.popStash()
 				result.Success = true
 				results = append(results, result)
 				if callbacks != nil && callbacks.AfterRebase != nil {
-					if !callbacks.AfterRebase(result, g) {
+					if !callbacks.AfterRebase(result, rb.repo()) {
 						saveState(sc)
 						if !allStacks {
 							return results, nil
 						}
-						stackHasConflict = true
+						halted[stack.Hash] = true
 						continue
 					}
 				}
@@ -584,7 +766,7 @@ func (m *Manager) syncStackInternal(gh *github.Client, callbacks *SyncCallbacks,
 			// (e.g., parent was just rebased onto main in this same sync operation)
 			behindBy, err := m.git.GetCommitsBehind(branch.Name, parentRef)
 			if err != nil || behindBy == 0 {
-				popStash()
+				rb.popStash()
 				continue
 			}
 
@@ -600,7 +782,7 @@ func (m *Manager) syncStackInternal(gh *github.Client, callbacks *SyncCallbacks,
 					NeedsSync:    true,
 				}
 				if !callbacks.BeforeRebase(syncInfo) {
-					popStash()
+					rb.popStash()
 					continue
 				}
 			}
@@ -611,56 +793,54 @@ func (m *Manager) syncStackInternal(gh *github.Client, callbacks *SyncCallbacks,
 				childHead, err := m.git.GetBranchCommit(branch.Name)
 				if err == nil && childHead == oldParentHead {
 					// No commits in child - just reset to new parent HEAD
-					if err := g.ResetHard(parentRef); err != nil {
-						popStash()
+					if err := rb.reset(parentRef); err != nil {
+						rb.popStash()
 						result.Error = fmt.Errorf("failed to fast-forward: %w", err)
 						results = append(results, result)
 						continue
 					}
-					popStash()
+					rb.popStash()
 					result.Success = true
 					results = append(results, result)
 					if callbacks != nil && callbacks.AfterRebase != nil {
-						if !callbacks.AfterRebase(result, g) {
+						if !callbacks.AfterRebase(result, rb.repo()) {
 							if !allStacks {
 								return results, nil
 							}
-							stackHasConflict = true
+							halted[stack.Hash] = true
 							continue
 						}
 					}
 					continue
 				}
 
-				rebaseResult := g.RebaseOntoNonInteractive(parentRef, oldParentHead)
+				rebaseResult := rb.rebase(parentRef, oldParentHead)
 				if rebaseResult.HasConflict {
-					result.HasConflict = true
-					result.Error = fmt.Errorf("%s", conflictMsg())
-					results = append(results, result)
+					conflict()
 					if !allStacks {
 						return results, nil
 					}
-					stackHasConflict = true
+					halted[stack.Hash] = true
 					continue
 				} else if rebaseResult.Error != nil {
-					popStash()
+					rb.popStash()
 					result.Error = rebaseResult.Error
 					results = append(results, result)
 					if !allStacks {
 						return results, nil
 					}
-					stackHasConflict = true
+					halted[stack.Hash] = true
 					continue
 				}
-				popStash()
+				rb.popStash()
 				result.Success = true
 				results = append(results, result)
 				if callbacks != nil && callbacks.AfterRebase != nil {
-					if !callbacks.AfterRebase(result, g) {
+					if !callbacks.AfterRebase(result, rb.repo()) {
 						if !allStacks {
 							return results, nil
 						}
-						stackHasConflict = true
+						halted[stack.Hash] = true
 						continue
 					}
 				}
@@ -668,41 +848,42 @@ func (m *Manager) syncStackInternal(gh *github.Client, callbacks *SyncCallbacks,
 			}
 
 			// Fallback: no old HEAD recorded, try simple rebase
-			rebaseResult := g.RebaseNonInteractive(parentRef)
+			rebaseResult := rb.rebase(parentRef, "")
 			if rebaseResult.HasConflict {
-				result.HasConflict = true
-				result.Error = fmt.Errorf("%s", conflictMsg())
-				results = append(results, result)
+				conflict()
 				if !allStacks {
 					return results, nil
 				}
-				stackHasConflict = true
+				halted[stack.Hash] = true
 				continue
 			} else if rebaseResult.Error != nil {
-				popStash()
+				rb.popStash()
 				result.Error = rebaseResult.Error
 				results = append(results, result)
 				if !allStacks {
 					return results, nil
 				}
-				stackHasConflict = true
+				halted[stack.Hash] = true
 				continue
 			}
-			popStash()
+			rb.popStash()
 			result.Success = true
 			results = append(results, result)
 			if callbacks != nil && callbacks.AfterRebase != nil {
-				if !callbacks.AfterRebase(result, g) {
+				if !callbacks.AfterRebase(result, rb.repo()) {
 					if !allStacks {
 						return results, nil
 					}
-					stackHasConflict = true
+					halted[stack.Hash] = true
 					continue
 				}
 			}
 		}
 	}
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	// Save cache (tracks merged branches)
 	if err := sc.save(); err != nil {
 		return results, fmt.Errorf("failed to save cache: %w", err)
@@ -716,11 +897,251 @@ func (m *Manager) syncStackInternal(gh *github.Client, callbacks *SyncCallbacks,
 	return results, nil
 }
 
+// recordSyncConflict adds a conflicted branch to the sync state and persists it
+// immediately, so the sync can be continued even if ezs exits before finishing.
+func (m *Manager) recordSyncConflict(state *config.SyncState, result RebaseResult, stashed bool) {
+	state.AddConflict(config.SyncConflict{
+		Branch:       result.Branch,
+		WorktreePath: result.WorktreePath,
+		SyncedParent: result.SyncedParent,
+		Stashed:      stashed,
+	})
+	m.saveSyncProgress(state)
+}
+
+// saveSyncProgress persists the sync state as branches are processed, so a
+// sync that dies midway can still be continued or aborted
+func (m *Manager) saveSyncProgress(state *config.SyncState) {
+	if err := config.SaveSyncState(m.repoDir, state); err != nil {
+		fmt.Fprintf(os.Stderr, "  Warning: failed to save sync state: %v\n", err)
+	}
+}
+
+// finishSyncState keeps the sync state on disk while conflicts or interrupted
+// rebases remain and removes it otherwise
+func (m *Manager) finishSyncState(state *config.SyncState) {
+	var err error
+	if len(state.Conflicts) > 0 || len(state.Running) > 0 {
+		err = config.SaveSyncState(m.repoDir, state)
+	} else {
+		err = config.ClearSyncState(m.repoDir)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Warning: failed to save sync state: %v\n", err)
+	}
+}
+
+// PendingSync returns the interrupted sync for this repo, or nil if there is none
+func (m *Manager) PendingSync() (*config.SyncState, error) {
+	return config.LoadSyncState(m.repoDir)
+}
+
+// ContinueSync resumes a sync that stopped on conflicts or was interrupted.
+// Every conflicted rebase must have been finished with 'git rebase --continue'
+// first. Resolved branches are reported as synced, their autostash is popped,
+// rebases cut short are undone and retried, and the rest of the original plan
+// runs with the recorded pre-sync HEADs.
+func (m *Manager) ContinueSync(gh github.ClientInterface, callbacks *SyncCallbacks) ([]RebaseResult, error) {
+	state, err := m.PendingSync()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("no sync in progress")
+	}
+
+	for _, c := range state.Conflicts {
+		inProgress, err := git.New(c.WorktreePath).IsRebaseInProgress()
+		if err != nil {
+			return nil, fmt.Errorf("failed to check rebase state in %s: %w", c.WorktreePath, err)
+		}
+		if inProgress {
+			return nil, fmt.Errorf("rebase of %s is still in progress: resolve conflicts in %s and run 'git rebase --continue' first", c.Branch, c.WorktreePath)
+		}
+	}
+
+	if err := m.undoRunningRebases(state); err != nil {
+		return nil, err
+	}
+
+	defer m.finishSyncState(state)
+
+	var results []RebaseResult
+	halted := make(map[string]bool)
+
+	conflicts := state.Conflicts
+	state.Conflicts = nil
+	for _, c := range conflicts {
+		g := git.New(c.WorktreePath)
+		if c.Stashed {
+			if err := g.StashPop(); err != nil {
+				fmt.Fprintf(os.Stderr, "  Warning: failed to pop stash for %s: %v\n", c.Branch, err)
+			}
+		}
+
+		// A branch still at its pre-sync commit had its rebase aborted by hand;
+		// leave it out of the completed list so the plan retries it.
+		if head, err := m.git.GetBranchCommit(c.Branch); err == nil && head == state.OldHeads[c.Branch] {
+			continue
+		}
+
+		state.MarkCompleted(c.Branch)
+		result := RebaseResult{Branch: c.Branch, Success: true, SyncedParent: c.SyncedParent, WorktreePath: c.WorktreePath}
+		results = append(results, result)
+		if callbacks != nil && callbacks.AfterRebase != nil {
+			if !callbacks.AfterRebase(result, g) {
+				if !state.AllStacks {
+					return results, nil
+				}
+				if s := m.GetStackForBranch(c.Branch); s != nil {
+					halted[s.Hash] = true
+				}
+			}
+		}
+	}
+
+	// Persist progress so a second interruption doesn't pop the stashes again
+	m.saveSyncProgress(state)
+
+	var stacksToSync []*config.Stack
+	for _, hash := range state.Stacks {
+		if s := m.GetStackByHashExact(hash); s != nil {
+			stacksToSync = append(stacksToSync, s)
+		}
+	}
+
+	planResults, err := m.runSyncPlan(gh, callbacks, stacksToSync, state, halted)
+	return append(results, planResults...), err
+}
+
+// undoRunningRebases cleans up after rebases that were cut short, e.g. because
+// ezs was killed: the rebase is aborted, a detached branch checked out again,
+// stashed changes restored and a temporary worktree removed. Branches left at
+// their pre-sync commit are synced again; those rebased before the
+// interruption stay completed.
+func (m *Manager) undoRunningRebases(state *config.SyncState) error {
+	// Abort rebases before checking out the branches they detached, which
+	// git refuses while an --update-refs rebase is going to move them
+	running := append([]config.SyncConflict{}, state.Running...)
+	sort.SliceStable(running, func(i, j int) bool { return !running[i].Detached && running[j].Detached })
+
+	for _, r := range running {
+		if r.WorktreePath != "" {
+			g := git.New(r.WorktreePath)
+			if inProgress, _ := g.IsRebaseInProgress(); inProgress {
+				if err := g.RebaseAbort(); err != nil {
+					return fmt.Errorf("failed to abort the interrupted rebase of %s in %s: %w", r.Branch, r.WorktreePath, err)
+				}
+			}
+			if r.Detached {
+				if err := g.CheckoutBranch(r.Branch); err != nil {
+					return fmt.Errorf("failed to check %s out again in %s: %w", r.Branch, r.WorktreePath, err)
+				}
+			}
+			if r.Stashed {
+				if err := g.StashPop(); err != nil {
+					fmt.Fprintf(os.Stderr, "  Warning: failed to pop stash for %s: %v\n", r.Branch, err)
+				}
+			}
+			if r.Temp {
+				m.git.RemoveWorktree(r.WorktreePath, false, "")
+				os.RemoveAll(strings.TrimSuffix(r.WorktreePath, string(filepath.Separator)+filepath.FromSlash(r.Branch)))
+			}
+		}
+		if head, err := m.git.GetBranchCommit(r.Branch); err == nil && head == state.OldHeads[r.Branch] {
+			state.Retry(r.Branch)
+		}
+		state.FinishRebase(r.Branch)
+		// Persist each step so a second interruption doesn't pop a stash again
+		m.saveSyncProgress(state)
+	}
+	return nil
+}
+
+// AbortSync abandons an interrupted sync: in-progress rebases are aborted,
+// every branch the sync already rebased is reset to its pre-sync commit and
+// stashed changes are restored. Returns the names of the branches that were reset.
+// Branches marked as merged during the sync stay marked.
+func (m *Manager) AbortSync() ([]string, error) {
+	state, err := m.PendingSync()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("no sync in progress")
+	}
+
+	// Interrupted branches still counted as completed are reset below
+	if err := m.undoRunningRebases(state); err != nil {
+		return nil, err
+	}
+
+	for _, c := range state.Conflicts {
+		g := git.New(c.WorktreePath)
+		if inProgress, _ := g.IsRebaseInProgress(); inProgress {
+			if err := g.RebaseAbort(); err != nil {
+				return nil, fmt.Errorf("failed to abort rebase of %s: %w", c.Branch, err)
+			}
+		}
+	}
+
+	touched := append([]string{}, state.Completed...)
+	for _, c := range state.Conflicts {
+		touched = append(touched, c.Branch)
+	}
+
+	var restored []string
+	for _, name := range touched {
+		oldHead, ok := state.OldHeads[name]
+		if !ok {
+			continue
+		}
+		head, err := m.git.GetBranchCommit(name)
+		if err != nil || head == oldHead {
+			continue
+		}
+		branch := m.GetBranch(name)
+		if branch == nil {
+			continue
+		}
+		worktree := m.worktreeForBranch(branch)
+		if worktree == "" {
+			// Rebased in a temporary worktree; nothing has it checked out
+			if err := m.git.SetBranchRef(name, oldHead); err != nil {
+				return restored, fmt.Errorf("failed to reset %s: %w", name, err)
+			}
+			restored = append(restored, name)
+			continue
+		}
+		g := git.New(worktree)
+		if hasChanges, _ := g.HasChanges(); hasChanges {
+			return restored, fmt.Errorf("cannot reset %s: worktree %s has uncommitted changes", name, worktree)
+		}
+		if err := g.ResetHard(oldHead); err != nil {
+			return restored, fmt.Errorf("failed to reset %s: %w", name, err)
+		}
+		restored = append(restored, name)
+	}
+
+	for _, c := range state.Conflicts {
+		if c.Stashed {
+			if err := git.New(c.WorktreePath).StashPop(); err != nil {
+				fmt.Fprintf(os.Stderr, "  Warning: failed to pop stash for %s: %v\n", c.Branch, err)
+			}
+		}
+	}
+
+	if err := config.ClearSyncState(m.repoDir); err != nil {
+		return restored, fmt.Errorf("failed to clear sync state: %w", err)
+	}
+	return restored, nil
+}
+
 // SyncBranch syncs a specific branch, handling all cases:
 // - Branch is behind origin/main (parent is main)
 // - Parent branch was merged (rebase --onto main)
 // - Branch is behind its parent (rebase onto parent)
-func (m *Manager) SyncBranch(branchName string, gh *github.Client) (*RebaseResult, error) {
+func (m *Manager) SyncBranch(branchName string, gh github.ClientInterface) (*RebaseResult, error) {
 	branch := m.GetBranch(branchName)
 	if branch == nil {
 		return nil, fmt.Errorf("branch '%s' not found", branchName)
@@ -731,8 +1152,15 @@ func (m *Manager) SyncBranch(branchName string, gh *github.Client) (*RebaseResul
 		return nil, fmt.Errorf("branch '%s' not found in any stack", branchName)
 	}
 
-	result := &RebaseResult{Branch: branch.Name, WorktreePath: branch.WorktreePath}
-	g := git.New(branch.WorktreePath)
+	rb := m.newBranchRebase(branch, false)
+	defer rb.release()
+
+	result := &RebaseResult{Branch: branch.Name, WorktreePath: rb.worktree}
+	defer func() {
+		if rb.temp {
+			abortTempConflict(rb.g, result)
+		}
+	}()
 
 	if branch.Parent == stack.Root {
 		behindBy, err := m.git.GetCommitsBehind(branch.Name, "origin/"+stack.Root)
@@ -744,10 +1172,10 @@ func (m *Manager) SyncBranch(branchName string, gh *github.Client) (*RebaseResul
 		result.BehindBy = behindBy
 		result.SyncedParent = "origin/" + stack.Root
 
-		rebaseResult := g.RebaseNonInteractive("origin/" + stack.Root)
+		rebaseResult := rb.rebase("origin/"+stack.Root, "")
 		if rebaseResult.HasConflict {
 			result.HasConflict = true
-			result.Error = fmt.Errorf("resolve conflicts in: %s", branch.WorktreePath)
+			result.Error = fmt.Errorf("resolve conflicts in: %s", rb.dir)
 			return result, nil
 		} else if rebaseResult.Error != nil {
 			result.Error = rebaseResult.Error
@@ -804,10 +1232,10 @@ func (m *Manager) SyncBranch(branchName string, gh *github.Client) (*RebaseResul
 			mergeBase = oldParentRef
 		}
 
-		rebaseResult := g.RebaseOntoNonInteractive("origin/"+stack.Root, mergeBase)
+		rebaseResult := rb.rebase("origin/"+stack.Root, mergeBase)
 		if rebaseResult.HasConflict {
 			result.HasConflict = true
-			result.Error = fmt.Errorf("resolve conflicts in: %s", branch.WorktreePath)
+			result.Error = fmt.Errorf("resolve conflicts in: %s", rb.dir)
 		} else if rebaseResult.Error != nil {
 			result.Error = rebaseResult.Error
 		} else {
@@ -840,7 +1268,7 @@ func (m *Manager) SyncBranch(branchName string, gh *github.Client) (*RebaseResul
 
 	if commitCount == 0 {
 		// No commits in child - just reset to parent (fast-forward)
-		if err := g.ResetHard(parentRef); err != nil {
+		if err := rb.reset(parentRef); err != nil {
 			result.Error = fmt.Errorf("failed to fast-forward: %w", err)
 			return result, nil
 		}
@@ -849,10 +1277,10 @@ func (m *Manager) SyncBranch(branchName string, gh *github.Client) (*RebaseResul
 	}
 
 	// Has commits - rebase normally, let conflicts bubble up
-	rebaseResult := g.RebaseNonInteractive(parentRef)
+	rebaseResult := rb.rebase(parentRef, "")
 	if rebaseResult.HasConflict {
 		result.HasConflict = true
-		result.Error = fmt.Errorf("resolve conflicts in: %s", branch.WorktreePath)
+		result.Error = fmt.Errorf("resolve conflicts in: %s", rb.dir)
 		return result, nil
 	} else if rebaseResult.Error != nil {
 		result.Error = rebaseResult.Error
@@ -887,83 +1315,100 @@ func (m *Manager) RebaseChildren() ([]RebaseResult, error) {
 		return nil, err
 	}
 
-	var results []RebaseResult
-	children := m.GetChildren(currentBranch.Name)
+	results, _ := m.rebaseChildrenOf(currentBranch.Name)
+	return results, nil
+}
 
-	for _, child := range children {
-		result := RebaseResult{Branch: child.Name, WorktreePath: child.WorktreePath}
-		g := git.New(child.WorktreePath)
+// rebaseChildrenOf rebases the children of parent onto it and then, depth
+// first, their own children. It stops at the first conflict or error and
+// reports whether it got through.
+func (m *Manager) rebaseChildrenOf(parent string) ([]RebaseResult, bool) {
+	var results []RebaseResult
 
-		// Count commits in the child branch that are not in the parent
-		// git rev-list --count parent..child
-		commitCount, err := m.git.GetCommitCount(currentBranch.Name, child.Name)
-		if err != nil {
-			result.Error = fmt.Errorf("failed to count commits: %w", err)
-			results = append(results, result)
+	for _, child := range m.GetChildren(parent) {
+		result, ok := m.rebaseChild(child, parent)
+		results = append(results, *result)
+		if !ok {
+			return results, false
+		}
+		if result.Error != nil {
 			continue
 		}
 
-		if commitCount == 0 {
-			// No commits in child - just reset to parent (fast-forward)
-			if err := g.ResetHard(currentBranch.Name); err != nil {
-				result.Error = fmt.Errorf("failed to fast-forward: %w", err)
-				results = append(results, result)
-				continue
-			}
-			result.Success = true
-			results = append(results, result)
-		} else {
-			// Has commits - rebase normally, let conflicts bubble up
-			rebaseResult := g.RebaseNonInteractive(currentBranch.Name)
-			if rebaseResult.HasConflict {
-				result.HasConflict = true
-				result.Error = fmt.Errorf("resolve conflicts in: %s", child.WorktreePath)
-				results = append(results, result)
-				// Stop immediately on conflict - user must resolve before continuing
-				return results, nil
-			} else if rebaseResult.Error != nil {
-				result.Error = rebaseResult.Error
-				results = append(results, result)
-				// Stop on error as well
-				return results, nil
-			}
-			result.Success = true
-			results = append(results, result)
+		childResults, ok := m.rebaseChildrenOf(child.Name)
+		results = append(results, childResults...)
+		if !ok {
+			return results, false
 		}
+	}
 
-		// Recursively rebase this child's children
-		childMgr, err := NewManager(child.WorktreePath)
-		if err != nil {
-			continue
-		}
-		childResults, err := childMgr.RebaseChildren()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "  Warning: failed to rebase children of %s: %v\n", child.Name, err)
+	return results, true
+}
+
+// rebaseChild rebases child onto parent, in a temporary worktree when it has
+// none. ok is false when rebasing has to stop (conflict or failed rebase).
+func (m *Manager) rebaseChild(child *config.Branch, parent string) (result *RebaseResult, ok bool) {
+	result = &RebaseResult{Branch: child.Name}
+
+	// Count commits in the child branch that are not in the parent
+	// git rev-list --count parent..child
+	commitCount, err := m.git.GetCommitCount(parent, child.Name)
+	if err != nil {
+		result.Error = fmt.Errorf("failed to count commits: %w", err)
+		return result, true
+	}
+
+	rb := m.newBranchRebase(child, false)
+	defer rb.release()
+	result.WorktreePath = rb.worktree
+
+	if commitCount == 0 {
+		// No commits in child - just reset to parent (fast-forward)
+		if err := rb.reset(parent); err != nil {
+			result.Error = fmt.Errorf("failed to fast-forward: %w", err)
+			return result, true
 		}
-		results = append(results, childResults...)
+		result.Success = true
+		return result, true
 	}
 
-	return results, nil
+	// Has commits - rebase normally, let conflicts bubble up
+	rebaseResult := rb.rebase(parent, "")
+	if rebaseResult.HasConflict {
+		result.HasConflict = true
+		result.Error = fmt.Errorf("resolve conflicts in: %s", rb.dir)
+		if rb.temp {
+			abortTempConflict(rb.g, result)
+		}
+		// Stop immediately on conflict - user must resolve before continuing
+		return result, false
+	} else if rebaseResult.Error != nil {
+		// Stop on error as well
+		result.Error = rebaseResult.Error
+		return result, false
+	}
+	result.Success = true
+	return result, true
 }
 
 // DetectMergedBranches finds branches in the CURRENT stack whose PRs have been merged to main
 // These are candidates for cleanup (deleting local branch and worktree)
-func (m *Manager) DetectMergedBranches(gh *github.Client) ([]MergedBranchInfo, error) {
+func (m *Manager) DetectMergedBranches(gh github.ClientInterface) ([]MergedBranchInfo, error) {
 	return m.detectMergedBranchesInternal(gh, true, nil)
 }
 
 // DetectMergedBranchesAllStacks finds branches across ALL stacks whose PRs have been merged to main
-func (m *Manager) DetectMergedBranchesAllStacks(gh *github.Client) ([]MergedBranchInfo, error) {
+func (m *Manager) DetectMergedBranchesAllStacks(gh github.ClientInterface) ([]MergedBranchInfo, error) {
 	return m.detectMergedBranchesInternal(gh, false, nil)
 }
 
 // DetectMergedBranchesForStacks finds branches in specific stacks whose PRs have been merged
-func (m *Manager) DetectMergedBranchesForStacks(gh *github.Client, stacks []*config.Stack) ([]MergedBranchInfo, error) {
+func (m *Manager) DetectMergedBranchesForStacks(gh github.ClientInterface, stacks []*config.Stack) ([]MergedBranchInfo, error) {
 	return m.detectMergedBranchesInternal(gh, false, stacks)
 }
 
 // detectMergedBranchesInternal is the internal implementation that can work on current stack, all stacks, or specific stacks
-func (m *Manager) detectMergedBranchesInternal(gh *github.Client, currentStackOnly bool, specificStacks []*config.Stack) ([]MergedBranchInfo, error) {
+func (m *Manager) detectMergedBranchesInternal(gh github.ClientInterface, currentStackOnly bool, specificStacks []*config.Stack) ([]MergedBranchInfo, error) {
 	if gh == nil {
 		return nil, nil
 	}
@@ -988,6 +1433,12 @@ func (m *Manager) detectMergedBranchesInternal(gh *github.Client, currentStackOn
 		}
 	}
 
+	var stackList []*config.Stack
+	for _, stack := range stacksToCheck {
+		stackList = append(stackList, stack)
+	}
+	prs := newPRMergeChecker(gh, stackList)
+
 	// Check branches in selected stacks for merged PRs
 	for stackName, stack := range stacksToCheck {
 		for _, branch := range stack.Branches {
@@ -997,12 +1448,7 @@ func (m *Manager) detectMergedBranchesInternal(gh *github.Client, currentStackOn
 			}
 
 			// Check if the PR is merged
-			pr, err := gh.GetPR(branch.PRNumber)
-			if err != nil {
-				continue
-			}
-
-			if pr.Merged {
+			if prs.isMerged(branch) {
 				// Check if there's actually something to clean up locally
 				// (worktree exists or git branch exists)
 				hasWorktree := false
@@ -1034,8 +1480,7 @@ func (m *Manager) detectMergedBranchesInternal(gh *github.Client, currentStackOn
 						hasUnmergedChildren = true
 						break
 					}
-					childPR, err := gh.GetPR(child.PRNumber)
-					if err != nil || !childPR.Merged {
+					if !prs.isMerged(child) {
 						hasUnmergedChildren = true
 						break
 					}
@@ -4,9 +4,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
 )
 
 // setupSyncTestEnv creates a temporary git repository for sync testing
@@ -549,3 +552,676 @@ func TestDetectSyncNeededForBranch_StackRoot(t *testing.T) {
 		t.Errorf("SyncInfo.BehindParent = %q, want empty (behind root, not parent)", info.BehindParent)
 	}
 }
+
+// setupConflictingStack creates main -> feature-a -> feature-b where feature-a
+// conflicts with a new commit on origin/main. Returns the worktree paths.
+func setupConflictingStack(t *testing.T, repoDir, worktreeBaseDir string) (featureAPath, featureBPath string) {
+	t.Helper()
+
+	mgr, _ := NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-a", "main", filepath.Join(worktreeBaseDir, "feature-a"), ""); err != nil {
+		t.Fatalf("CreateBranch feature-a failed: %v", err)
+	}
+	featureAPath = filepath.Join(worktreeBaseDir, "feature-a")
+	os.WriteFile(filepath.Join(featureAPath, "conflict.txt"), []byte("feature-a content\n"), 0644)
+	exec.Command("git", "-C", featureAPath, "add", ".").Run()
+	exec.Command("git", "-C", featureAPath, "commit", "-m", "Add conflict.txt in feature-a").Run()
+
+	mgr, _ = NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-b", "feature-a", filepath.Join(worktreeBaseDir, "feature-b"), ""); err != nil {
+		t.Fatalf("CreateBranch feature-b failed: %v", err)
+	}
+	featureBPath = filepath.Join(worktreeBaseDir, "feature-b")
+	os.WriteFile(filepath.Join(featureBPath, "feature-b.txt"), []byte("feature-b content\n"), 0644)
+	exec.Command("git", "-C", featureBPath, "add", ".").Run()
+	exec.Command("git", "-C", featureBPath, "commit", "-m", "Add file in feature-b").Run()
+
+	os.WriteFile(filepath.Join(repoDir, "conflict.txt"), []byte("main content - different!\n"), 0644)
+	exec.Command("git", "-C", repoDir, "add", ".").Run()
+	exec.Command("git", "-C", repoDir, "commit", "-m", "Add conflict.txt in main").Run()
+
+	bareDir := filepath.Join(filepath.Dir(repoDir), "bare.git")
+	exec.Command("git", "init", "--bare", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "remote", "add", "origin", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "push", "-u", "origin", "main").Run()
+
+	return featureAPath, featureBPath
+}
+
+// TestContinueSync_ResumesAfterConflict verifies that the remaining branches are
+// synced after the user resolves the conflict and runs ContinueSync
+func TestContinueSync_ResumesAfterConflict(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+
+	featureAPath, featureBPath := setupConflictingStack(t, repoDir, worktreeBaseDir)
+
+	mgr, _ := NewManager(featureAPath)
+	results, err := mgr.SyncStack(nil, nil)
+	if err != nil {
+		t.Fatalf("SyncStack returned error: %v", err)
+	}
+	if len(results) != 1 || !results[0].HasConflict {
+		t.Fatalf("SyncStack results = %+v, want a single conflict", results)
+	}
+
+	pending, err := mgr.PendingSync()
+	if err != nil || pending == nil {
+		t.Fatalf("PendingSync() = %v, %v; want saved state", pending, err)
+	}
+	if len(pending.Conflicts) != 1 || pending.Conflicts[0].Branch != "feature-a" {
+		t.Errorf("pending conflicts = %+v, want feature-a", pending.Conflicts)
+	}
+
+	// A second sync must refuse to start while one is pending
+	if _, err := mgr.SyncStack(nil, nil); err == nil {
+		t.Error("SyncStack should fail while a sync is in progress")
+	}
+
+	// Continuing before the rebase is finished must fail
+	mgr, _ = NewManager(featureAPath)
+	if _, err := mgr.ContinueSync(nil, nil); err == nil {
+		t.Error("ContinueSync should fail while the rebase is still in progress")
+	}
+
+	// Resolve the conflict and finish the rebase
+	os.WriteFile(filepath.Join(featureAPath, "conflict.txt"), []byte("resolved\n"), 0644)
+	exec.Command("git", "-C", featureAPath, "add", ".").Run()
+	cmd := exec.Command("git", "-C", featureAPath, "rebase", "--continue")
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git rebase --continue failed: %v\n%s", err, out)
+	}
+
+	mgr, _ = NewManager(featureAPath)
+	results, err = mgr.ContinueSync(nil, nil)
+	if err != nil {
+		t.Fatalf("ContinueSync returned error: %v", err)
+	}
+
+	synced := make(map[string]bool)
+	for _, r := range results {
+		if r.Success {
+			synced[r.Branch] = true
+		}
+	}
+	if !synced["feature-a"] || !synced["feature-b"] {
+		t.Errorf("ContinueSync results = %+v, want feature-a and feature-b synced", results)
+	}
+
+	// feature-b must now contain the resolved feature-a commit
+	if err := exec.Command("git", "-C", featureBPath, "merge-base", "--is-ancestor", "feature-a", "feature-b").Run(); err != nil {
+		t.Error("feature-b should be rebased onto the resolved feature-a")
+	}
+
+	if pending, _ := mgr.PendingSync(); pending != nil {
+		t.Errorf("PendingSync() = %+v after continue, want nil", pending)
+	}
+}
+
+// TestAbortSync_RestoresBranches verifies that AbortSync aborts the conflicted
+// rebase and resets branches the sync already rebased to their old commits
+func TestAbortSync_RestoresBranches(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+
+	// feature-a and feature-x are siblings on main; feature-a syncs cleanly,
+	// feature-x conflicts with main
+	mgr, _ := NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-a", "main", filepath.Join(worktreeBaseDir, "feature-a"), ""); err != nil {
+		t.Fatalf("CreateBranch feature-a failed: %v", err)
+	}
+	featureAPath := filepath.Join(worktreeBaseDir, "feature-a")
+	os.WriteFile(filepath.Join(featureAPath, "file-a.txt"), []byte("file-a content\n"), 0644)
+	exec.Command("git", "-C", featureAPath, "add", ".").Run()
+	exec.Command("git", "-C", featureAPath, "commit", "-m", "Add file-a").Run()
+
+	mgr, _ = NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-x", "main", filepath.Join(worktreeBaseDir, "feature-x"), ""); err != nil {
+		t.Fatalf("CreateBranch feature-x failed: %v", err)
+	}
+	featureXPath := filepath.Join(worktreeBaseDir, "feature-x")
+	os.WriteFile(filepath.Join(featureXPath, "conflict.txt"), []byte("feature-x content\n"), 0644)
+	exec.Command("git", "-C", featureXPath, "add", ".").Run()
+	exec.Command("git", "-C", featureXPath, "commit", "-m", "Add conflict.txt in feature-x").Run()
+
+	os.WriteFile(filepath.Join(repoDir, "conflict.txt"), []byte("main content - different!\n"), 0644)
+	exec.Command("git", "-C", repoDir, "add", ".").Run()
+	exec.Command("git", "-C", repoDir, "commit", "-m", "Add conflict.txt in main").Run()
+
+	bareDir := filepath.Join(filepath.Dir(repoDir), "bare.git")
+	exec.Command("git", "init", "--bare", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "remote", "add", "origin", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "push", "-u", "origin", "main").Run()
+
+	oldA, _ := exec.Command("git", "-C", repoDir, "rev-parse", "feature-a").Output()
+	oldX, _ := exec.Command("git", "-C", repoDir, "rev-parse", "feature-x").Output()
+
+	mgr, _ = NewManager(featureAPath)
+	results, err := mgr.SyncStack(nil, nil)
+	if err != nil {
+		t.Fatalf("SyncStack returned error: %v", err)
+	}
+	if len(results) != 2 || !results[0].Success || !results[1].HasConflict {
+		t.Fatalf("SyncStack results = %+v, want feature-a synced and feature-x conflicted", results)
+	}
+
+	restored, err := mgr.AbortSync()
+	if err != nil {
+		t.Fatalf("AbortSync returned error: %v", err)
+	}
+	if len(restored) != 1 || restored[0] != "feature-a" {
+		t.Errorf("AbortSync restored %v, want [feature-a]", restored)
+	}
+
+	newA, _ := exec.Command("git", "-C", repoDir, "rev-parse", "feature-a").Output()
+	newX, _ := exec.Command("git", "-C", repoDir, "rev-parse", "feature-x").Output()
+	if string(newA) != string(oldA) {
+		t.Errorf("feature-a = %s, want pre-sync commit %s", newA, oldA)
+	}
+	if string(newX) != string(oldX) {
+		t.Errorf("feature-x = %s, want pre-sync commit %s", newX, oldX)
+	}
+
+	if inProgress, _ := exec.Command("git", "-C", featureXPath, "status").Output(); strings.Contains(string(inProgress), "rebase in progress") {
+		t.Error("rebase in feature-x should have been aborted")
+	}
+	if pending, _ := mgr.PendingSync(); pending != nil {
+		t.Errorf("PendingSync() = %+v after abort, want nil", pending)
+	}
+}
+
+// TestSyncStack_SavesProgress verifies that the sync state is on disk before
+// the first rebase and records each branch as it is synced, so a sync that
+// dies midway can be continued or aborted
+func TestSyncStack_SavesProgress(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+	setupLinearStack(t, repoDir, worktreeBaseDir)
+
+	mgr, _ := NewManager(filepath.Join(worktreeBaseDir, "feature-a"))
+	oldA := gitOutput(t, repoDir, "rev-parse", "feature-a")
+	var synced []string
+	callbacks := &SyncCallbacks{
+		BeforeRebase: func(info SyncInfo) bool {
+			pending, err := mgr.PendingSync()
+			if err != nil || pending == nil || pending.OldHeads["feature-a"] != oldA {
+				t.Errorf("PendingSync() before rebasing %s = %+v, %v; want the saved plan", info.Branch, pending, err)
+			}
+			return true
+		},
+		AfterRebase: func(result RebaseResult, g *git.Git) bool {
+			synced = append(synced, result.Branch)
+			pending, err := mgr.PendingSync()
+			if err != nil || pending == nil || !pending.IsCompleted(result.Branch) {
+				t.Errorf("PendingSync() after syncing %s = %+v, %v; want it recorded as completed", result.Branch, pending, err)
+			}
+			return true
+		},
+	}
+	if _, err := mgr.SyncStack(nil, callbacks); err != nil {
+		t.Fatalf("SyncStack returned error: %v", err)
+	}
+	if len(synced) != 3 {
+		t.Errorf("synced %v, want all three branches", synced)
+	}
+	if pending, _ := mgr.PendingSync(); pending != nil {
+		t.Errorf("PendingSync() = %+v after a clean sync, want nil", pending)
+	}
+}
+
+// TestContinueSync_AfterKilledSync verifies that a sync killed mid-rebase
+// leaves the rebase recorded as running, and that ContinueSync aborts it,
+// restores the autostash and syncs the branch again
+func TestContinueSync_AfterKilledSync(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+
+	mgr, _ := NewManager(repoDir)
+	featureAPath := filepath.Join(worktreeBaseDir, "feature-a")
+	if _, err := mgr.CreateBranch("feature-a", "main", featureAPath, ""); err != nil {
+		t.Fatalf("CreateBranch feature-a failed: %v", err)
+	}
+	commitFile(t, featureAPath, "a.txt")
+	commitFile(t, repoDir, "main.txt")
+	bareDir := filepath.Join(filepath.Dir(repoDir), "bare.git")
+	exec.Command("git", "init", "--bare", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "remote", "add", "origin", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "push", "-u", "origin", "main").Run()
+	os.WriteFile(filepath.Join(featureAPath, "wip.txt"), []byte("uncommitted\n"), 0644)
+
+	// Kill the sync and git as soon as the rebase has checked out its new base
+	hook := filepath.Join(repoDir, ".git", "hooks", "post-checkout")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\nkill -9 $EZS_TEST_SYNC_PID $PPID\n"), 0755); err != nil {
+		t.Fatalf("failed to write hook: %v", err)
+	}
+
+	oldA := gitOutput(t, repoDir, "rev-parse", "feature-a")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSyncHelperProcess$")
+	cmd.Env = append(os.Environ(), "EZS_TEST_SYNC_DIR="+featureAPath)
+	if err := cmd.Run(); err == nil {
+		t.Fatal("sync should have been killed")
+	}
+	os.Remove(hook)
+
+	mgr, _ = NewManager(featureAPath)
+	pending, err := mgr.PendingSync()
+	if err != nil || pending == nil {
+		t.Fatalf("PendingSync() = %v, %v; want saved state", pending, err)
+	}
+	if len(pending.Running) != 1 || pending.Running[0].Branch != "feature-a" || !pending.Running[0].Stashed {
+		t.Errorf("pending running = %+v, want feature-a with its autostash", pending.Running)
+	}
+	if inProgress, _ := git.New(featureAPath).IsRebaseInProgress(); !inProgress {
+		t.Fatal("expected the killed rebase to be left in progress")
+	}
+
+	results, err := mgr.ContinueSync(nil, nil)
+	if err != nil {
+		t.Fatalf("ContinueSync returned error: %v", err)
+	}
+	if len(results) != 1 || !results[0].Success {
+		t.Errorf("ContinueSync results = %+v, want feature-a synced", results)
+	}
+	if gitOutput(t, repoDir, "rev-parse", "feature-a") == oldA {
+		t.Error("feature-a should have been rebased")
+	}
+	if err := exec.Command("git", "-C", repoDir, "merge-base", "--is-ancestor", "origin/main", "feature-a").Run(); err != nil {
+		t.Error("feature-a should be on top of origin/main")
+	}
+	if data, err := os.ReadFile(filepath.Join(featureAPath, "wip.txt")); err != nil || string(data) != "uncommitted\n" {
+		t.Errorf("wip.txt = %q, %v; want the stashed change restored", data, err)
+	}
+	if pending, _ := mgr.PendingSync(); pending != nil {
+		t.Errorf("PendingSync() = %+v after continue, want nil", pending)
+	}
+}
+
+// TestSyncHelperProcess runs the sync TestContinueSync_AfterKilledSync kills.
+// It does nothing when run as a test of its own.
+func TestSyncHelperProcess(t *testing.T) {
+	dir := os.Getenv("EZS_TEST_SYNC_DIR")
+	if dir == "" {
+		return
+	}
+	os.Setenv("EZS_TEST_SYNC_PID", strconv.Itoa(os.Getpid()))
+	mgr, err := NewManager(dir)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	mgr.SyncStack(nil, &SyncCallbacks{Autostash: true})
+}
+
+// setupNoWorktreeStack builds main -> feature-a -> feature-b, where feature-a
+// has no worktree, then moves main (and origin/main) ahead by one commit
+func setupNoWorktreeStack(t *testing.T, repoDir, worktreeBaseDir, mainFile string) {
+	t.Helper()
+
+	mgr, _ := NewManager(repoDir)
+	if _, err := mgr.CreateBranchNoWorktree("feature-a", "main", ""); err != nil {
+		t.Fatalf("CreateBranchNoWorktree() error = %v", err)
+	}
+	exec.Command("git", "-C", repoDir, "checkout", "-q", "feature-a").Run()
+	commitFile(t, repoDir, "a.txt")
+	exec.Command("git", "-C", repoDir, "checkout", "-q", "main").Run()
+
+	mgr, _ = NewManager(repoDir)
+	if _, err := mgr.CreateBranch("feature-b", "feature-a", filepath.Join(worktreeBaseDir, "feature-b"), ""); err != nil {
+		t.Fatalf("CreateBranch feature-b failed: %v", err)
+	}
+	commitFile(t, filepath.Join(worktreeBaseDir, "feature-b"), "b.txt")
+
+	commitFile(t, repoDir, mainFile)
+	bareDir := filepath.Join(filepath.Dir(repoDir), "bare.git")
+	exec.Command("git", "init", "--bare", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "remote", "add", "origin", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "push", "-u", "origin", "main").Run()
+}
+
+// TestSyncStack_BranchWithoutWorktree verifies that a branch without a
+// worktree is rebased in a temporary one instead of being skipped
+func TestSyncStack_BranchWithoutWorktree(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+	setupNoWorktreeStack(t, repoDir, worktreeBaseDir, "main.txt")
+
+	mgr, _ := NewManager(filepath.Join(worktreeBaseDir, "feature-b"))
+	results, err := mgr.SyncStack(nil, nil)
+	if err != nil {
+		t.Fatalf("SyncStack returned error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("SyncStack returned %d results, want 2", len(results))
+	}
+	for _, r := range results {
+		if !r.Success {
+			t.Errorf("%s not synced: %v", r.Branch, r.Error)
+		}
+	}
+
+	main := gitOutput(t, repoDir, "rev-parse", "main")
+	for _, b := range []string{"feature-a", "feature-b"} {
+		if err := exec.Command("git", "-C", repoDir, "merge-base", "--is-ancestor", main, b).Run(); err != nil {
+			t.Errorf("%s is not based on the new main", b)
+		}
+	}
+	if wts := gitOutput(t, repoDir, "worktree", "list"); strings.Contains(wts, "ezstack-wt-") {
+		t.Errorf("temporary worktree left behind:\n%s", wts)
+	}
+}
+
+// TestSyncStack_BranchWithoutWorktreeConflict verifies that a conflict in a
+// temporary worktree is aborted and leaves the branch as it was
+func TestSyncStack_BranchWithoutWorktreeConflict(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+
+	// main adds its own a.txt, which conflicts with feature-a's
+	setupNoWorktreeStack(t, repoDir, worktreeBaseDir, "main.txt")
+	os.WriteFile(filepath.Join(repoDir, "a.txt"), []byte("main\n"), 0644)
+	exec.Command("git", "-C", repoDir, "add", ".").Run()
+	exec.Command("git", "-C", repoDir, "commit", "-m", "add a.txt in main").Run()
+	exec.Command("git", "-C", repoDir, "push", "origin", "main").Run()
+	before := gitOutput(t, repoDir, "rev-parse", "feature-a")
+
+	mgr, _ := NewManager(filepath.Join(worktreeBaseDir, "feature-b"))
+	results, err := mgr.SyncStack(nil, nil)
+	if err != nil {
+		t.Fatalf("SyncStack returned error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("SyncStack returned %d results, want 1 (should stop at feature-a)", len(results))
+	}
+	if results[0].HasConflict || results[0].Error == nil {
+		t.Errorf("feature-a result = %+v, want an error and no pending conflict", results[0])
+	}
+	if after := gitOutput(t, repoDir, "rev-parse", "feature-a"); after != before {
+		t.Errorf("feature-a moved from %s to %s", before, after)
+	}
+	if wts := gitOutput(t, repoDir, "worktree", "list"); strings.Contains(wts, "ezstack-wt-") {
+		t.Errorf("temporary worktree left behind:\n%s", wts)
+	}
+}
+
+// TestReparentBranch_WithoutWorktree verifies that reparenting rebases a
+// branch that has no worktree
+func TestReparentBranch_WithoutWorktree(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+	setupNoWorktreeStack(t, repoDir, worktreeBaseDir, "main.txt")
+
+	mgr, _ := NewManager(repoDir)
+	if _, err := mgr.CreateBranchNoWorktree("feature-c", "feature-a", ""); err != nil {
+		t.Fatalf("CreateBranchNoWorktree() error = %v", err)
+	}
+	exec.Command("git", "-C", repoDir, "checkout", "-q", "feature-c").Run()
+	commitFile(t, repoDir, "c.txt")
+	exec.Command("git", "-C", repoDir, "checkout", "-q", "main").Run()
+
+	mgr, _ = NewManager(repoDir)
+	result, err := mgr.ReparentBranch("feature-c", "feature-b", true)
+	if err != nil {
+		t.Fatalf("ReparentBranch() error = %v", err)
+	}
+	if result.HasConflict {
+		t.Fatalf("ReparentBranch() reported a conflict in %s", result.ConflictDir)
+	}
+	if err := exec.Command("git", "-C", repoDir, "merge-base", "--is-ancestor", "feature-b", "feature-c").Run(); err != nil {
+		t.Error("feature-c was not rebased onto feature-b")
+	}
+	if count := gitOutput(t, repoDir, "rev-list", "--count", "feature-b..feature-c"); count != "1" {
+		t.Errorf("feature-c has %s commits on top of feature-b, want 1", count)
+	}
+}
+
+// enableInMemoryRebase turns on in_memory_rebase for the test repo
+func enableInMemoryRebase(t *testing.T, repoDir string) {
+	t.Helper()
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+	on := true
+	cfg.GetRepoConfig(repoDir).InMemoryRebase = &on
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("config.Save() error = %v", err)
+	}
+}
+
+// TestSyncStack_InMemoryRebase verifies that with in_memory_rebase on, sync
+// moves the branches without rebasing in a worktree and updates the worktree
+// that has a branch checked out
+func TestSyncStack_InMemoryRebase(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+	setupNoWorktreeStack(t, repoDir, worktreeBaseDir, "main.txt")
+	enableInMemoryRebase(t, repoDir)
+
+	bDir := filepath.Join(worktreeBaseDir, "feature-b")
+	mgr, _ := NewManager(bDir)
+	results, err := mgr.SyncStack(nil, nil)
+	if err != nil {
+		t.Fatalf("SyncStack returned error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("SyncStack returned %d results, want 2", len(results))
+	}
+	for _, r := range results {
+		if !r.Success {
+			t.Errorf("%s not synced: %v", r.Branch, r.Error)
+		}
+		if got := gitOutput(t, repoDir, "reflog", "-1", "--format=%gs", r.Branch); got != "ezstack: restack" {
+			t.Errorf("%s was not rebased in memory (last reflog entry %q)", r.Branch, got)
+		}
+	}
+
+	if head := gitOutput(t, bDir, "rev-parse", "HEAD"); head != gitOutput(t, repoDir, "rev-parse", "feature-b") {
+		t.Errorf("feature-b worktree HEAD = %s, want the rebased branch", head)
+	}
+	if _, err := os.Stat(filepath.Join(bDir, "main.txt")); err != nil {
+		t.Error("feature-b worktree files were not updated")
+	}
+	if status := gitOutput(t, bDir, "status", "--porcelain"); status != "" {
+		t.Errorf("feature-b worktree is not clean: %q", status)
+	}
+}
+
+// TestSyncStack_InMemoryRebaseConflict verifies that a conflict falls back
+// to a rebase in the worktree, where it is left for the user to resolve
+func TestSyncStack_InMemoryRebaseConflict(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+	featureAPath, _ := setupConflictingStack(t, repoDir, worktreeBaseDir)
+	enableInMemoryRebase(t, repoDir)
+
+	mgr, _ := NewManager(featureAPath)
+	results, err := mgr.SyncStack(nil, nil)
+	if err != nil {
+		t.Fatalf("SyncStack returned error: %v", err)
+	}
+	if len(results) != 1 || !results[0].HasConflict {
+		t.Fatalf("SyncStack results = %+v, want a conflict in feature-a", results)
+	}
+	if inProgress, _ := git.New(featureAPath).IsRebaseInProgress(); !inProgress {
+		t.Error("no rebase in progress in feature-a's worktree")
+	}
+	exec.Command("git", "-C", featureAPath, "rebase", "--abort").Run()
+}
+
+// setupLinearStack builds main -> feature-a -> feature-b -> feature-c, each
+// in its own worktree, then moves main (and origin/main) ahead
+func setupLinearStack(t *testing.T, repoDir, worktreeBaseDir string) {
+	t.Helper()
+	parent := "main"
+	for _, name := range []string{"feature-a", "feature-b", "feature-c"} {
+		mgr, _ := NewManager(repoDir)
+		if _, err := mgr.CreateBranch(name, parent, filepath.Join(worktreeBaseDir, name), ""); err != nil {
+			t.Fatalf("CreateBranch %s failed: %v", name, err)
+		}
+		commitFile(t, filepath.Join(worktreeBaseDir, name), name+".txt")
+		parent = name
+	}
+
+	commitFile(t, repoDir, "main.txt")
+	bareDir := filepath.Join(filepath.Dir(repoDir), "bare.git")
+	exec.Command("git", "init", "--bare", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "remote", "add", "origin", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "push", "-u", "origin", "main").Run()
+}
+
+// TestSyncStack_LinearUpdateRefs verifies that a linear stack is synced with a
+// single rebase --update-refs and every worktree ends up on its rebased branch
+func TestSyncStack_LinearUpdateRefs(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+	setupLinearStack(t, repoDir, worktreeBaseDir)
+
+	mgr, _ := NewManager(filepath.Join(worktreeBaseDir, "feature-a"))
+	if !mgr.git.VersionAtLeast(2, 38) {
+		t.Skip("git rebase --update-refs needs git 2.38")
+	}
+
+	var prompts []SyncInfo
+	callbacks := &SyncCallbacks{BeforeRebase: func(info SyncInfo) bool {
+		prompts = append(prompts, info)
+		return true
+	}}
+	results, err := mgr.SyncStack(nil, callbacks)
+	if err != nil {
+		t.Fatalf("SyncStack returned error: %v", err)
+	}
+	if len(prompts) != 1 || strings.Join(prompts[0].Stacked, ",") != "feature-b,feature-c" {
+		t.Errorf("prompts = %+v, want one for feature-a covering feature-b and feature-c", prompts)
+	}
+	if len(results) != 3 {
+		t.Fatalf("SyncStack returned %d results, want 3", len(results))
+	}
+
+	parent := gitOutput(t, repoDir, "rev-parse", "main")
+	for _, r := range results {
+		if !r.Success {
+			t.Errorf("%s not synced: %v", r.Branch, r.Error)
+			continue
+		}
+		if got := gitOutput(t, repoDir, "rev-parse", r.Branch+"~1"); got != parent {
+			t.Errorf("%s is not on top of its rebased parent", r.Branch)
+		}
+		parent = gitOutput(t, repoDir, "rev-parse", r.Branch)
+
+		wt := filepath.Join(worktreeBaseDir, r.Branch)
+		if got := gitOutput(t, wt, "symbolic-ref", "--short", "HEAD"); got != r.Branch {
+			t.Errorf("%s worktree is on %q", r.Branch, got)
+		}
+		if status := gitOutput(t, wt, "status", "--porcelain"); status != "" {
+			t.Errorf("%s worktree is not clean: %q", r.Branch, status)
+		}
+	}
+	// --update-refs logs the branches it moves as rewritten during the rebase
+	if got := gitOutput(t, repoDir, "reflog", "-1", "--format=%gs", "feature-a"); got != "rewritten during rebase" {
+		t.Errorf("feature-a was not moved by --update-refs (last reflog entry %q)", got)
+	}
+}
+
+// TestSyncStack_LinearForeignBranch verifies that a stack is synced branch by
+// branch when another local branch points into it, so that branch isn't moved
+func TestSyncStack_LinearForeignBranch(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+	setupLinearStack(t, repoDir, worktreeBaseDir)
+	exec.Command("git", "-C", repoDir, "branch", "backup", "feature-b").Run()
+	backup := gitOutput(t, repoDir, "rev-parse", "backup")
+
+	mgr, _ := NewManager(filepath.Join(worktreeBaseDir, "feature-a"))
+	results, err := mgr.SyncStack(nil, nil)
+	if err != nil {
+		t.Fatalf("SyncStack returned error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("SyncStack returned %d results, want 3", len(results))
+	}
+	if got := gitOutput(t, repoDir, "rev-parse", "backup"); got != backup {
+		t.Error("backup branch was moved by the sync")
+	}
+	if got := gitOutput(t, repoDir, "reflog", "-1", "--format=%gs", "feature-a"); got == "rewritten during rebase" {
+		t.Errorf("feature-a was moved by --update-refs (last reflog entry %q)", got)
+	}
+}
+
+func TestIndependentStacks(t *testing.T) {
+	base := &config.Stack{Hash: "base", Root: "main", Branches: []*config.Branch{{Name: "a"}, {Name: "b"}}}
+	onBase := &config.Stack{Hash: "onbase", Root: "b", Branches: []*config.Branch{{Name: "c"}}}
+	onOnBase := &config.Stack{Hash: "ononbase", Root: "c", Branches: []*config.Branch{{Name: "d"}}}
+	other := &config.Stack{Hash: "other", Root: "main", Branches: []*config.Branch{{Name: "x"}}}
+	develop := &config.Stack{Hash: "develop", Root: "develop", Branches: []*config.Branch{{Name: "y"}}}
+
+	groups := independentStacks([]*config.Stack{onOnBase, other, base, develop, onBase})
+
+	var got []string
+	for _, group := range groups {
+		var hashes []string
+		for _, s := range group {
+			hashes = append(hashes, s.Hash)
+		}
+		got = append(got, strings.Join(hashes, ","))
+	}
+	want := []string{"base,onbase,ononbase", "other", "develop"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("independentStacks() = %v, want %v", got, want)
+	}
+}
+
+// TestSyncStackAll_Parallel verifies that independent stacks synced in
+// parallel all end up on the new main, with each stack's results together and
+// in order
+func TestSyncStackAll_Parallel(t *testing.T) {
+	repoDir, worktreeBaseDir, cleanup := setupSyncTestEnv(t)
+	defer cleanup()
+
+	stacks := [][]string{{"one-a", "one-b"}, {"two-a", "two-b"}, {"three-a", "three-b"}}
+	for _, names := range stacks {
+		parent, target := "main", "new"
+		for _, name := range names {
+			mgr, _ := NewManager(repoDir)
+			if _, err := mgr.CreateBranch(name, parent, filepath.Join(worktreeBaseDir, name), target); err != nil {
+				t.Fatalf("CreateBranch %s failed: %v", name, err)
+			}
+			commitFile(t, filepath.Join(worktreeBaseDir, name), name+".txt")
+			parent, target = name, ""
+		}
+	}
+	commitFile(t, repoDir, "main.txt")
+	bareDir := filepath.Join(filepath.Dir(repoDir), "bare.git")
+	exec.Command("git", "init", "--bare", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "remote", "add", "origin", bareDir).Run()
+	exec.Command("git", "-C", repoDir, "push", "-u", "origin", "main").Run()
+
+	mgr, _ := NewManager(repoDir)
+	if n := len(mgr.ListStacks()); n != 3 {
+		t.Fatalf("got %d stacks, want 3", n)
+	}
+	results, err := mgr.SyncStackAll(nil, &SyncCallbacks{Parallel: 2})
+	if err != nil {
+		t.Fatalf("SyncStackAll returned error: %v", err)
+	}
+	if len(results) != 6 {
+		t.Fatalf("SyncStackAll returned %d results, want 6: %+v", len(results), results)
+	}
+
+	main := gitOutput(t, repoDir, "rev-parse", "main")
+	for i, r := range results {
+		if !r.Success {
+			t.Errorf("%s not synced: %v", r.Branch, r.Error)
+			continue
+		}
+		if err := exec.Command("git", "-C", repoDir, "merge-base", "--is-ancestor", main, r.Branch).Run(); err != nil {
+			t.Errorf("%s is not based on the new main", r.Branch)
+		}
+		// Each stack's bottom branch comes right before its child
+		if i%2 == 1 && strings.TrimSuffix(results[i-1].Branch, "-a") != strings.TrimSuffix(r.Branch, "-b") {
+			t.Errorf("results out of order: %s then %s", results[i-1].Branch, r.Branch)
+		}
+	}
+	if pending, _ := mgr.PendingSync(); pending != nil {
+		t.Errorf("sync state left behind: %+v", pending)
+	}
+}
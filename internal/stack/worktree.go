@@ -0,0 +1,204 @@
+package stack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/git"
+)
+
+// tempWorktree checks a branch out in a temporary worktree. The returned
+// cleanup removes the worktree but keeps the branch.
+func (m *Manager) tempWorktree(branchName string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "ezstack-wt-*")
+	if err != nil {
+		return "", nil, err
+	}
+	path := filepath.Join(dir, branchName)
+	if err := m.git.CreateWorktree(branchName, path, branchName); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("failed to create temporary worktree for '%s': %w", branchName, err)
+	}
+	cleanup := func() {
+		m.git.RemoveWorktree(path, false, "")
+		os.RemoveAll(dir)
+	}
+	return path, cleanup, nil
+}
+
+// rebaseWorkdir returns a directory with the branch checked out so it can be
+// rebased: its worktree, the worktree it is checked out in (e.g. the main one
+// in checkout mode), or else a temporary worktree, in which case temp is true.
+// release removes the temporary worktree and is a no-op otherwise.
+func (m *Manager) rebaseWorkdir(branch *config.Branch) (dir string, temp bool, release func(), err error) {
+	if dir := m.worktreeForBranch(branch); dir != "" {
+		return dir, false, func() {}, nil
+	}
+	dir, release, err = m.tempWorktree(branch.Name)
+	if err != nil {
+		return "", false, func() {}, err
+	}
+	return dir, true, release, nil
+}
+
+// tempConflictError is reported for a rebase that conflicted in a temporary
+// worktree. Such a rebase is aborted, since the worktree is removed afterwards.
+func tempConflictError(branch string) error {
+	return fmt.Errorf("conflict while rebasing '%s', which has no worktree; branch left unchanged (check it out and run 'ezs sync -c' to resolve)", branch)
+}
+
+// abortTempConflict aborts a conflicted rebase in a temporary worktree and
+// turns the conflict into an error
+func abortTempConflict(g *git.Git, r *RebaseResult) {
+	if r.HasConflict {
+		g.RebaseAbort()
+		r.HasConflict = false
+		r.Error = tempConflictError(r.Branch)
+	}
+}
+
+// branchRebase rebases a single branch during sync. With in_memory_rebase
+// enabled it first tries to rebase without a checkout; otherwise, or when that
+// fails (usually on a conflict), it rebases in the branch's worktree, or a
+// temporary one when it has none.
+type branchRebase struct {
+	m         *Manager
+	branch    *config.Branch
+	inMemory  bool
+	autostash bool
+	worktree  string // where the branch is checked out, if anywhere
+	state     *config.SyncState
+
+	// Set once a worktree rebase has been needed
+	g       *git.Git
+	dir     string
+	temp    bool
+	stashed bool
+	cleanup func()
+}
+
+func (m *Manager) newBranchRebase(branch *config.Branch, autostash bool) *branchRebase {
+	return &branchRebase{
+		m:         m,
+		branch:    branch,
+		inMemory:  m.config != nil && m.config.GetInMemoryRebase(m.repoDir),
+		autostash: autostash,
+		worktree:  m.worktreeForBranch(branch),
+		cleanup:   func() {},
+	}
+}
+
+// checkout returns the git for the directory a worktree rebase runs in,
+// creating a temporary worktree and stashing uncommitted changes if needed
+func (r *branchRebase) checkout() (*git.Git, error) {
+	if r.g != nil {
+		return r.g, nil
+	}
+	dir, temp, release, err := r.m.rebaseWorkdir(r.branch)
+	if err != nil {
+		return nil, err
+	}
+	r.g, r.dir, r.temp, r.cleanup = git.New(dir), dir, temp, release
+	if r.autostash && !temp {
+		if hasChanges, _ := r.g.HasChanges(); hasChanges {
+			r.stashed = r.g.StashPush() == nil
+		}
+	}
+	r.saveProgress()
+	return r.g, nil
+}
+
+// track records the rebase in the sync state until it is released, so if ezs
+// dies mid-rebase, 'ezs sync --continue' can undo it and sync the branch again
+func (r *branchRebase) track(state *config.SyncState) {
+	r.state = state
+	r.saveProgress()
+}
+
+func (r *branchRebase) saveProgress() {
+	if r.state == nil {
+		return
+	}
+	dir := r.worktree
+	if r.g != nil {
+		dir = r.dir
+	}
+	r.state.StartRebase(config.SyncConflict{Branch: r.branch.Name, WorktreePath: dir, Stashed: r.stashed, Temp: r.temp})
+	r.m.saveSyncProgress(r.state)
+}
+
+// rebase rebases the commits after upstream onto newBase; an empty upstream
+// rebases like 'git rebase newBase'
+func (r *branchRebase) rebase(newBase, upstream string) git.RebaseResult {
+	if r.inMemory && r.g == nil {
+		if err := r.m.git.RestackBranch(r.branch.Name, newBase, upstream, r.worktree); err == nil {
+			return git.RebaseResult{Success: true}
+		}
+	}
+	g, err := r.checkout()
+	if err != nil {
+		return git.RebaseResult{Error: err}
+	}
+	if upstream == "" {
+		return g.RebaseNonInteractive(newBase)
+	}
+	return g.RebaseOntoNonInteractive(newBase, upstream)
+}
+
+// reset fast-forwards a branch with no commits of its own to ref
+func (r *branchRebase) reset(ref string) error {
+	if r.inMemory && r.g == nil {
+		if err := r.m.git.RestackBranch(r.branch.Name, ref, r.branch.Name, r.worktree); err == nil {
+			return nil
+		}
+	}
+	g, err := r.checkout()
+	if err != nil {
+		return err
+	}
+	return g.ResetHard(ref)
+}
+
+// popStash restores changes stashed by checkout. Not called on conflict; the
+// user resolves first, then runs 'git stash pop'.
+func (r *branchRebase) popStash() {
+	if r.stashed {
+		if err := r.g.StashPop(); err != nil {
+			fmt.Fprintf(os.Stderr, "  Warning: failed to pop stash for %s: %v\n", r.branch.Name, err)
+		}
+		r.stashed = false
+		r.saveProgress()
+	}
+}
+
+// repo returns the git for the branch's worktree, or the repo's when it has none
+func (r *branchRebase) repo() *git.Git {
+	if r.g != nil {
+		return r.g
+	}
+	if r.worktree != "" {
+		return git.New(r.worktree)
+	}
+	return r.m.git
+}
+
+// release removes the temporary worktree, if one was created. A tracked
+// rebase stays recorded if it failed without being aborted.
+func (r *branchRebase) release() {
+	if r.state != nil && !r.interrupted() && r.state.FinishRebase(r.branch.Name) {
+		r.m.saveSyncProgress(r.state)
+	}
+	r.cleanup()
+}
+
+// interrupted reports whether a rebase is left in progress in the branch's
+// worktree
+func (r *branchRebase) interrupted() bool {
+	if r.g == nil || r.temp {
+		return false
+	}
+	inProgress, _ := r.g.IsRebaseInProgress()
+	return inProgress
+}
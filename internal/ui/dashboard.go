@@ -0,0 +1,449 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"golang.org/x/term"
+)
+
+// DashboardData is what the dashboard shows. It is loaded again after every
+// action and on refresh.
+type DashboardData struct {
+	Stacks        []*config.Stack
+	Status        map[string]*BranchStatus // live PR/CI state, nil to show cached state
+	CurrentBranch string
+}
+
+// DashboardAction is a key binding acting on the highlighted branch
+type DashboardAction struct {
+	Key     byte
+	Label   string // shown in the key help, e.g. "sync"
+	Confirm bool   // ask before running
+	Move    bool   // the user first moves the cursor to a new parent and presses Enter
+	// Run does the work, writing its output to log. parent is the picked
+	// parent for Move actions. Returning quit closes the dashboard.
+	Run func(log io.Writer, s *config.Stack, b *config.Branch, parent string) (quit bool, err error)
+}
+
+// Dashboard is a full-screen view of all stacks as trees with a log pane for
+// the output of the actions run from it
+type Dashboard struct {
+	Title   string
+	Load    func(log io.Writer) (*DashboardData, error)
+	Actions []DashboardAction
+
+	mu      sync.Mutex
+	data    *DashboardData
+	rows    []dashboardRow
+	cursor  int
+	offset  int
+	moving  *dashboardRow // branch picked up by a Move action
+	action  *DashboardAction
+	message string
+	log     *dashboardLog
+}
+
+// dashboardRow is a line of the tree pane: a stack header or a branch
+type dashboardRow struct {
+	stack  *config.Stack
+	branch *config.Branch // nil for the stack header
+	prefix string         // tree connectors drawn before the branch name
+}
+
+// dashboardRows lays out the stacks as trees, children sorted by name under
+// their parent. Branches whose parent isn't in the stack hang off the header.
+func dashboardRows(stacks []*config.Stack) []dashboardRow {
+	var rows []dashboardRow
+	for _, s := range stacks {
+		rows = append(rows, dashboardRow{stack: s})
+
+		inStack := make(map[string]bool, len(s.Branches))
+		children := make(map[string][]*config.Branch)
+		for _, b := range s.Branches {
+			inStack[b.Name] = true
+		}
+		var top []*config.Branch
+		for _, b := range s.Branches {
+			if inStack[b.Parent] && b.Parent != b.Name {
+				children[b.Parent] = append(children[b.Parent], b)
+			} else {
+				top = append(top, b)
+			}
+		}
+
+		var walk func(branches []*config.Branch, indent string)
+		walk = func(branches []*config.Branch, indent string) {
+			sort.Slice(branches, func(i, j int) bool { return branches[i].Name < branches[j].Name })
+			for i, b := range branches {
+				connector, next := "├── ", "│   "
+				if i == len(branches)-1 {
+					connector, next = "└── ", "    "
+				}
+				rows = append(rows, dashboardRow{stack: s, branch: b, prefix: indent + connector})
+				walk(children[b.Name], indent+next)
+			}
+		}
+		walk(top, "")
+	}
+	return rows
+}
+
+// Run shows the dashboard until the user quits or an action asks to
+func (d *Dashboard) Run() error {
+	restore, err := makeRawTerminal()
+	if err != nil {
+		return fmt.Errorf("the dashboard needs an interactive terminal: %w", err)
+	}
+	defer restore()
+
+	// Alternate screen, hidden cursor and no line wrapping; undone on exit
+	fmt.Fprint(os.Stderr, "\033[?1049h\033[?25l\033[?7l")
+	defer fmt.Fprint(os.Stderr, "\033[?7h\033[?25h\033[?1049l")
+
+	d.log = &dashboardLog{onWrite: d.draw}
+	d.reload()
+
+	buf := make([]byte, 3)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return nil
+		}
+		var key byte
+		switch {
+		case n == 3 && buf[0] == 27 && buf[1] == 91 && buf[2] == 65: // Up arrow
+			key = 'k'
+		case n == 3 && buf[0] == 27 && buf[1] == 91 && buf[2] == 66: // Down arrow
+			key = 'j'
+		case n == 1:
+			key = buf[0]
+		default:
+			continue
+		}
+		if quit := d.handleKey(key); quit {
+			return nil
+		}
+	}
+}
+
+// handleKey acts on a key press and reports whether to quit
+func (d *Dashboard) handleKey(key byte) bool {
+	if key == 3 { // Ctrl+C always quits
+		return true
+	}
+
+	// Waiting for y/n on a Confirm action
+	if d.action != nil && d.moving == nil {
+		action := d.action
+		d.action = nil
+		d.setMessage("")
+		if key == 'y' || key == 'Y' {
+			return d.run(action, d.rows[d.cursor], "")
+		}
+		d.draw()
+		return false
+	}
+
+	switch key {
+	case 'k':
+		d.moveCursor(-1)
+		return false
+	case 'j':
+		d.moveCursor(1)
+		return false
+	}
+
+	// Picking the new parent for a Move action
+	if d.moving != nil {
+		switch key {
+		case 13, 10: // Enter
+			action, moving := d.action, d.moving
+			target := d.rows[d.cursor]
+			d.action, d.moving = nil, nil
+			d.setMessage("")
+			if target.stack.Hash != moving.stack.Hash {
+				d.log.Printf("%s%s can only be moved within its stack%s", Red, moving.branch.Name, Reset)
+				return false
+			}
+			parent := target.stack.Root
+			if target.branch != nil {
+				parent = target.branch.Name
+			}
+			if parent == moving.branch.Name || parent == moving.branch.Parent {
+				d.draw()
+				return false
+			}
+			return d.run(action, *moving, parent)
+		case 27, 'q': // Esc
+			d.action, d.moving = nil, nil
+			d.setMessage("")
+		}
+		return false
+	}
+
+	switch key {
+	case 'q', 27:
+		return true
+	case 'r':
+		d.reload()
+		return false
+	}
+
+	if len(d.rows) == 0 {
+		return false
+	}
+	for i := range d.Actions {
+		action := &d.Actions[i]
+		if action.Key != key {
+			continue
+		}
+		row := d.rows[d.cursor]
+		if row.branch == nil {
+			d.setMessage(fmt.Sprintf("%sSelect a branch to %s%s", Yellow, action.Label, Reset))
+			return false
+		}
+		switch {
+		case action.Move:
+			d.action, d.moving = action, &row
+			d.setMessage(fmt.Sprintf("%sMove %s onto the highlighted branch: Enter to confirm, Esc to cancel%s", Yellow, row.branch.Name, Reset))
+		case action.Confirm:
+			d.action = action
+			d.setMessage(fmt.Sprintf("%s%s?%s %s %s? [y/N]", Bold, Yellow, Reset, capitalize(action.Label), row.branch.Name))
+		default:
+			return d.run(action, row, "")
+		}
+		return false
+	}
+	return false
+}
+
+// run runs action on the branch of row and reloads the dashboard
+func (d *Dashboard) run(action *DashboardAction, row dashboardRow, parent string) bool {
+	d.setMessage(fmt.Sprintf("%sRunning %s on %s...%s", Gray, action.Label, row.branch.Name, Reset))
+	quit, err := action.Run(d.log, row.stack, row.branch, parent)
+	if err != nil {
+		d.log.Printf("%s%s %v%s", Red, IconError, err, Reset)
+	}
+	if quit {
+		return true
+	}
+	d.setMessage("")
+	d.reload()
+	return false
+}
+
+// reload loads the data again, keeping the cursor on the same branch
+func (d *Dashboard) reload() {
+	var selected string
+	if d.cursor < len(d.rows) {
+		if row := d.rows[d.cursor]; row.branch != nil {
+			selected = row.branch.Name
+		} else {
+			selected = row.stack.Hash
+		}
+	}
+
+	d.setMessage(Gray + "Loading..." + Reset)
+	data, err := d.Load(d.log)
+	if err != nil {
+		d.log.Printf("%s%s %v%s", Red, IconError, err, Reset)
+		d.setMessage("")
+		return
+	}
+
+	d.mu.Lock()
+	d.data = data
+	d.rows = dashboardRows(data.Stacks)
+	d.cursor = 0
+	for i, row := range d.rows {
+		if (row.branch != nil && row.branch.Name == selected) || (row.branch == nil && row.stack.Hash == selected) {
+			d.cursor = i
+			break
+		}
+	}
+	d.message = ""
+	d.mu.Unlock()
+	d.draw()
+}
+
+func (d *Dashboard) moveCursor(delta int) {
+	d.mu.Lock()
+	if c := d.cursor + delta; c >= 0 && c < len(d.rows) {
+		d.cursor = c
+	}
+	d.mu.Unlock()
+	d.draw()
+}
+
+func (d *Dashboard) setMessage(msg string) {
+	d.mu.Lock()
+	d.message = msg
+	d.mu.Unlock()
+	d.draw()
+}
+
+// draw redraws the whole screen. It is also called from the log while an
+// action runs, so it takes the lock.
+func (d *Dashboard) draw() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	width, height, err := term.GetSize(int(os.Stderr.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+	logHeight := max(3, height/4)
+	treeHeight := max(1, height-logHeight-5) // title, blank, log rule, message, keys
+
+	var sb strings.Builder
+	line := func(s string) { sb.WriteString(s + "\033[K\r\n") }
+
+	sb.WriteString("\033[H")
+	line(fmt.Sprintf("%s%s %s %s%s", Bold, Cyan, IconStack, d.Title, Reset))
+	line("")
+
+	// Scroll the tree so the cursor stays visible
+	if d.cursor < d.offset {
+		d.offset = d.cursor
+	} else if d.cursor >= d.offset+treeHeight {
+		d.offset = d.cursor - treeHeight + 1
+	}
+	for i := 0; i < treeHeight; i++ {
+		idx := d.offset + i
+		if idx >= len(d.rows) {
+			if len(d.rows) == 0 && i == 0 && d.data != nil {
+				line(Gray + "  No stacks found. Create one with: ezs new <branch-name>" + Reset)
+				continue
+			}
+			line("")
+			continue
+		}
+		line(d.renderRow(d.rows[idx], idx == d.cursor))
+	}
+
+	rule := " log "
+	line(Gray + "──" + rule + strings.Repeat("─", max(0, width-2-len(rule))) + Reset)
+	for _, l := range d.log.Tail(logHeight) {
+		line(l)
+	}
+
+	line(d.message)
+	keys := []string{"↑/↓ move"}
+	for _, a := range d.Actions {
+		keys = append(keys, fmt.Sprintf("%c %s", a.Key, a.Label))
+	}
+	keys = append(keys, "r refresh", "q quit")
+	sb.WriteString(Gray + strings.Join(keys, " · ") + Reset + "\033[K\033[J")
+
+	fmt.Fprint(os.Stderr, sb.String())
+}
+
+// renderRow formats one line of the tree pane
+func (d *Dashboard) renderRow(row dashboardRow, selected bool) string {
+	pointer := "  "
+	if selected {
+		pointer = Cyan + "▸ " + Reset
+	}
+	if row.branch == nil {
+		return fmt.Sprintf("%s%s%sStack %s%s %s(on %s)%s", pointer, Bold, Cyan, row.stack.DisplayName(), Reset, Gray, row.stack.Root, Reset)
+	}
+
+	b := row.branch
+	var status map[string]*BranchStatus
+	if d.data != nil {
+		status = d.data.Status
+	}
+	name := Bold + b.Name + Reset
+	switch {
+	case d.moving != nil && d.moving.branch.Name == b.Name:
+		name = Yellow + Bold + b.Name + Reset + Yellow + " (moving)" + Reset
+	case selected:
+		name = Cyan + Bold + b.Name + Reset
+	case d.data != nil && b.Name == d.data.CurrentBranch:
+		name = Green + Bold + b.Name + Reset
+	case b.IsMerged:
+		name = Gray + Strikethrough + b.Name + Reset
+	}
+	return fmt.Sprintf("%s%s%s%s  %s%s", pointer, Gray, row.prefix, Reset+name, getPRFormatted(b, status, 0), getStatusIcons(b, status))
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// dashboardLog collects the output shown in the log pane. Commands write
+// colored lines, progress with \r and cursor movement; only the text that
+// would end up on each line and its colors are kept.
+type dashboardLog struct {
+	mu      sync.Mutex
+	lines   []string
+	partial string
+	onWrite func()
+}
+
+const dashboardLogLines = 500
+
+// cursorEscapes matches escape sequences other than colors, which would
+// break the layout of the log pane
+var cursorEscapes = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-ln-z]`)
+
+func (l *dashboardLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	text := l.partial + string(p)
+	parts := strings.Split(text, "\n")
+	l.partial = parts[len(parts)-1]
+	for _, part := range parts[:len(parts)-1] {
+		l.lines = append(l.lines, sanitizeLogLine(part))
+	}
+	if over := len(l.lines) - dashboardLogLines; over > 0 {
+		l.lines = l.lines[over:]
+	}
+	l.mu.Unlock()
+
+	if l.onWrite != nil {
+		l.onWrite()
+	}
+	return len(p), nil
+}
+
+// Printf adds a line to the log
+func (l *dashboardLog) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(l, format+"\n", args...)
+}
+
+// Tail returns the last n lines, including an unfinished one
+func (l *dashboardLog) Tail(n int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lines := l.lines
+	if l.partial != "" {
+		lines = append(lines[:len(lines):len(lines)], sanitizeLogLine(l.partial))
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// sanitizeLogLine keeps what a terminal would show after the last \r
+func sanitizeLogLine(s string) string {
+	s = strings.TrimRight(s, "\r")
+	if idx := strings.LastIndex(s, "\r"); idx != -1 {
+		s = s[idx+1:]
+	}
+	s = cursorEscapes.ReplaceAllString(s, "")
+	if s == "" {
+		return ""
+	}
+	return s + Reset
+}
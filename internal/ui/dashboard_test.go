@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+)
+
+func TestDashboardRows(t *testing.T) {
+	s := &config.Stack{Hash: "abc1234", Root: "main", Branches: []*config.Branch{
+		{Name: "c", Parent: "a"},
+		{Name: "a", Parent: "main"},
+		{Name: "b", Parent: "a"},
+		{Name: "d", Parent: "b"},
+	}}
+
+	var got []string
+	for _, row := range dashboardRows([]*config.Stack{s}) {
+		if row.branch == nil {
+			got = append(got, "["+row.stack.Hash+"]")
+			continue
+		}
+		got = append(got, row.prefix+row.branch.Name)
+	}
+	want := []string{
+		"[abc1234]",
+		"└── a",
+		"    ├── b",
+		"    │   └── d",
+		"    └── c",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("dashboardRows() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDashboardLog(t *testing.T) {
+	l := &dashboardLog{}
+	l.Write([]byte("\x1b[32mdone\x1b[0m\nFetching...\r\x1b[KFetched\nhalf"))
+
+	got := l.Tail(10)
+	want := []string{"\x1b[32mdone\x1b[0m" + Reset, "Fetched" + Reset, "half" + Reset}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Tail() = %q, want %q", got, want)
+	}
+	if got := l.Tail(1); len(got) != 1 || got[0] != "half"+Reset {
+		t.Errorf("Tail(1) = %q, want the unfinished line", got)
+	}
+}
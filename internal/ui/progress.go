@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+// Progress shows one status line per task for tasks running concurrently,
+// e.g. one per stack in a parallel sync, redrawn in place while they run.
+// When stderr isn't a terminal every update is printed as a line instead.
+type Progress struct {
+	out    io.Writer
+	live   bool
+	labels []string
+	width  int
+
+	mu     sync.Mutex
+	status map[string]string
+	done   map[string]bool
+	frame  int
+	drawn  bool
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewProgress creates a progress display with a line for each label, in order
+func NewProgress(labels []string) *Progress {
+	p := &Progress{
+		out:    os.Stderr,
+		live:   term.IsTerminal(int(os.Stderr.Fd())),
+		labels: labels,
+		status: make(map[string]string),
+		done:   make(map[string]bool),
+		stop:   make(chan struct{}),
+	}
+	for _, l := range labels {
+		p.width = max(p.width, runewidth.StringWidth(l))
+		p.status[l] = "waiting"
+	}
+	return p
+}
+
+// Start draws the lines and animates the ones still running. Delayed
+// spinners are hidden until Stop.
+func (p *Progress) Start() {
+	if !p.live {
+		return
+	}
+	progressShown.Add(1)
+	p.mu.Lock()
+	p.draw()
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(80 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.mu.Lock()
+				p.frame++
+				p.draw()
+				p.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// Update sets the status of a running task
+func (p *Progress) Update(label, status string) {
+	p.set(label, status, false)
+}
+
+// Done sets the final status of a task
+func (p *Progress) Done(label, status string) {
+	p.set(label, status, true)
+}
+
+func (p *Progress) set(label, status string, done bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.status[label]; !ok {
+		return
+	}
+	p.status[label] = status
+	p.done[label] = done
+	if p.live {
+		p.draw()
+		return
+	}
+	fmt.Fprintf(p.out, "  %s: %s\n", label, status)
+}
+
+// Stop draws the final state and stops the animation
+func (p *Progress) Stop() {
+	close(p.stop)
+	p.wg.Wait()
+	if p.live {
+		p.mu.Lock()
+		p.draw()
+		p.mu.Unlock()
+		progressShown.Add(-1)
+	}
+}
+
+// draw redraws every line in place; p.mu must be held
+func (p *Progress) draw() {
+	if p.drawn {
+		fmt.Fprintf(p.out, "\033[%dA", len(p.labels))
+	}
+	p.drawn = true
+	fmt.Fprint(p.out, p.render())
+}
+
+// render returns the lines for the current state
+func (p *Progress) render() string {
+	frames := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	var b strings.Builder
+	for _, l := range p.labels {
+		icon := Cyan + frames[p.frame%len(frames)] + Reset
+		if p.done[l] {
+			icon = " "
+		}
+		fmt.Fprintf(&b, "\r\033[K%s %s%s%s  %s\n", icon, Bold, padRight(l, p.width), Reset, p.status[l])
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressRender(t *testing.T) {
+	p := NewProgress([]string{"short", "a longer one"})
+	p.out = &bytes.Buffer{}
+	p.Update("short", "rebasing a...")
+	p.Done("a longer one", "synced")
+	p.Update("unknown", "ignored")
+
+	lines := strings.Split(strings.TrimSuffix(p.render(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("render() = %d lines, want 2: %q", len(lines), lines)
+	}
+	if !strings.Contains(lines[0], "short       "+Reset+"  rebasing a...") {
+		t.Errorf("running line = %q, want the label padded to the longest", lines[0])
+	}
+	if !strings.Contains(lines[1], "\033[K  "+Bold+"a longer one") || !strings.HasSuffix(lines[1], "synced") {
+		t.Errorf("done line = %q, want no spinner", lines[1])
+	}
+}
+
+func TestProgressNotTerminal(t *testing.T) {
+	var out bytes.Buffer
+	p := NewProgress([]string{"one", "two"})
+	p.out, p.live = &out, false
+	p.Start()
+	p.Update("one", "rebasing a...")
+	p.Update("unknown", "ignored")
+	p.Done("two", "synced")
+	p.Stop()
+
+	want := "  one: rebasing a...\n  two: synced\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want one line per update %q", out.String(), want)
+	}
+}
+
+func TestProgressHidesSpinners(t *testing.T) {
+	p := NewProgress([]string{"one"})
+	p.out, p.live = &bytes.Buffer{}, true
+	p.Start()
+
+	ds := NewDelayedSpinner("Creating worktree...")
+	ds.delay = 0
+	ds.Start()
+	time.Sleep(20 * time.Millisecond)
+	ds.Stop()
+	p.Stop()
+
+	if ds.spinner != nil {
+		t.Error("a spinner was shown over the progress lines")
+	}
+	if progressShown.Load() != 0 {
+		t.Errorf("progressShown = %d after Stop, want 0", progressShown.Load())
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/KulkarniKaustubh/ezstack/internal/config"
@@ -379,6 +382,38 @@ func runFzf(input, prompt string) (string, error) {
 	return strings.TrimSpace(stdout.String()), nil
 }
 
+// runFzfMulti executes fzf with multi-select enabled and returns the selected lines
+func runFzfMulti(input, prompt string) ([]string, error) {
+	cmd := exec.Command("fzf",
+		"--multi",
+		"--prompt", prompt+": ",
+		"--height", "40%",
+		"--reverse",
+		"--border",
+		"--ansi",
+	)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stderr = os.Stderr
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 130 {
+			return nil, fmt.Errorf("cancelled")
+		}
+		return nil, err
+	}
+
+	var lines []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
 // runFzfWithPreview executes fzf with preview window for stack visualization
 func runFzfWithPreview(input, prompt string, showPreview bool) (string, error) {
 	args := []string{
@@ -569,10 +604,15 @@ func formatStackString(stack *config.Stack, currentBranch string) string {
 // - showStatus=false: 4 columns - branch name, pr number, parent branch, remote tag
 // - showStatus=true: 5 columns - branch name, pr number, ci status, parent branch, remote tag
 func PrintStack(stack *config.Stack, currentBranch string, showStatus bool, statusMap map[string]*BranchStatus) {
-	fmt.Fprintf(os.Stderr, "\n%s%s Stack %s%s\n\n", Bold, Cyan, stack.DisplayName(), Reset)
+	FprintStack(os.Stderr, stack, currentBranch, showStatus, statusMap)
+}
+
+// FprintStack is PrintStack writing to w
+func FprintStack(w io.Writer, stack *config.Stack, currentBranch string, showStatus bool, statusMap map[string]*BranchStatus) {
+	fmt.Fprintf(w, "\n%s%s Stack %s%s\n\n", Bold, Cyan, stack.DisplayName(), Reset)
 
 	if len(stack.Branches) == 0 {
-		fmt.Fprintf(os.Stderr, "  %s(empty)%s\n\n", Gray, Reset)
+		fmt.Fprintf(w, "  %s(empty)%s\n\n", Gray, Reset)
 		return
 	}
 
@@ -656,13 +696,13 @@ func PrintStack(stack *config.Stack, currentBranch string, showStatus bool, stat
 				// Replace all Reset codes with Reset+Strikethrough to maintain strikethrough
 				prWithStrike := strings.ReplaceAll(prFormatted, Reset, Reset+Strikethrough)
 				statusWithStrike := strings.ReplaceAll(statusColored, Reset, Reset+Strikethrough)
-				fmt.Fprintf(os.Stderr, "%s%s%s%s %s%s%s  %s  %s%s  %s%s\n",
+				fmt.Fprintf(w, "%s%s%s%s %s%s%s  %s  %s%s  %s%s\n",
 					Strikethrough, pointer, color, connector, Bold, paddedName, Reset+Strikethrough,
 					prWithStrike,
 					statusWithStrike, statusPadding,
 					paddedParent, Reset)
 			} else {
-				fmt.Fprintf(os.Stderr, "%s%s%s %s%s%s  %s  %s%s  %s%s\n",
+				fmt.Fprintf(w, "%s%s%s %s%s%s  %s  %s%s  %s%s\n",
 					pointer, color, connector, Bold, paddedName, Reset,
 					prFormatted,
 					statusColored, statusPadding,
@@ -673,19 +713,19 @@ func PrintStack(stack *config.Stack, currentBranch string, showStatus bool, stat
 			if isMerged {
 				// For merged branches, apply strikethrough to entire line
 				prWithStrike := strings.ReplaceAll(prFormatted, Reset, Reset+Strikethrough)
-				fmt.Fprintf(os.Stderr, "%s%s%s%s %s%s%s  %s  %s%s\n",
+				fmt.Fprintf(w, "%s%s%s%s %s%s%s  %s  %s%s\n",
 					Strikethrough, pointer, color, connector, Bold, paddedName, Reset+Strikethrough,
 					prWithStrike,
 					paddedParent, Reset)
 			} else {
-				fmt.Fprintf(os.Stderr, "%s%s%s %s%s%s  %s  %s%s\n",
+				fmt.Fprintf(w, "%s%s%s %s%s%s  %s  %s%s\n",
 					pointer, color, connector, Bold, paddedName, Reset,
 					prFormatted,
 					paddedParent, Reset)
 			}
 		}
 	}
-	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(w)
 }
 
 // getPRText returns the PR text without color codes
@@ -854,20 +894,34 @@ func Confirm(prompt string) bool {
 	return strings.ToLower(response) == "y" || strings.ToLower(response) == "yes"
 }
 
+// errNotTerminal is returned by makeRawTerminal when stdin is not a terminal
+var errNotTerminal = fmt.Errorf("stdin is not a terminal")
+
+// makeRawTerminal puts stdin into raw mode so single key presses can be read,
+// returning a function that restores the previous mode. In raw mode output
+// lines must end in "\r\n".
+func makeRawTerminal() (restore func(), err error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, errNotTerminal
+	}
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	return func() { term.Restore(fd, oldState) }, nil
+}
+
 // confirmTUICore implements the raw-terminal yes/no dialog loop.
 // defaultYes controls which option is highlighted initially.
 // escValue is the value returned when the user presses Escape.
 // Callers must handle YesMode and non-terminal fallback before calling this.
 func confirmTUICore(prompt string, defaultYes bool, escValue bool) bool {
-	if !term.IsTerminal(int(os.Stdin.Fd())) {
-		return Confirm(prompt)
-	}
-
-	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	restore, err := makeRawTerminal()
 	if err != nil {
 		return Confirm(prompt)
 	}
-	defer term.Restore(int(os.Stdin.Fd()), oldState)
+	defer restore()
 
 	selected := 1 // 0 = Yes, 1 = No
 	if defaultYes {
@@ -911,7 +965,7 @@ func confirmTUICore(prompt string, defaultYes bool, escValue bool) bool {
 				return selected == 0
 			case 3: // Ctrl+C
 				fmt.Fprint(os.Stderr, "\033[4B\r\033[K")
-				term.Restore(int(os.Stdin.Fd()), oldState)
+				restore()
 				os.Exit(130)
 			case 27: // ESC byte — handled below as single ESC or part of arrow sequence
 			case 'k', 'K': // vim-style up
@@ -1328,6 +1382,35 @@ func SelectOptionWithSuggested(options []string, prompt string, suggestedIdx int
 	return idx - 1, nil
 }
 
+// SelectMultiple uses fzf to select any number of options (Tab to mark)
+// Returns the 0-based indexes of the selected options in list order
+func SelectMultiple(options []string, prompt string) ([]int, error) {
+	if len(options) == 0 {
+		return nil, fmt.Errorf("no options to select from")
+	}
+
+	var input strings.Builder
+	for i, opt := range options {
+		input.WriteString(fmt.Sprintf("%d. %s\n", i+1, opt))
+	}
+
+	selected, err := runFzfMulti(input.String(), prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexes []int
+	for _, line := range selected {
+		var idx int
+		if _, err := fmt.Sscanf(line, "%d.", &idx); err != nil {
+			return nil, fmt.Errorf("failed to parse selection")
+		}
+		indexes = append(indexes, idx-1)
+	}
+	sort.Ints(indexes)
+	return indexes, nil
+}
+
 // SelectOptionWithBack uses fzf to select from a list of options with a back option.
 // Returns the 0-based index of the selected option, or ErrBack if back was selected.
 // The back option is displayed as an unnumbered "← back" at the end of the list.
@@ -1366,6 +1449,11 @@ func SelectOptionWithBack(options []string, prompt string) (int, error) {
 // SpinnerDelay is the delay before showing a spinner (only show for slow operations)
 const SpinnerDelay = 1500 * time.Millisecond
 
+// progressShown counts live Progress displays. Delayed spinners stay hidden
+// while one is drawn, e.g. for the rebases and temporary worktrees of a
+// parallel sync, since they would write over its lines.
+var progressShown atomic.Int32
+
 // Spinner represents a simple loading spinner
 type Spinner struct {
 	message string
@@ -1444,7 +1532,7 @@ func (ds *DelayedSpinner) Start() {
 	ds.timer = time.AfterFunc(ds.delay, func() {
 		ds.mu.Lock()
 		defer ds.mu.Unlock()
-		if !ds.stopped {
+		if !ds.stopped && progressShown.Load() == 0 {
 			ds.spinner = NewSpinner(ds.message)
 			ds.spinner.Start()
 		}
@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Watch redraws the frame returned by refresh in place until the user presses
+// q, Esc or Ctrl-C. refresh runs right away and then every interval; while it
+// reports no changes the wait doubles up to maxInterval, and the first change
+// brings it back to interval. Pressing r refreshes immediately.
+func Watch(interval, maxInterval time.Duration, refresh func() (frame string, changed bool)) error {
+	restore, err := makeRawTerminal()
+	if err != nil {
+		return fmt.Errorf("watch needs an interactive terminal: %w", err)
+	}
+	defer restore()
+
+	keys := make(chan byte, 1)
+	go func() {
+		buf := make([]byte, 3)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			if n == 1 { // arrow keys and other escape sequences are ignored
+				keys <- buf[0]
+			}
+		}
+	}()
+
+	// Raw mode doesn't translate \n, so every line needs an explicit \r
+	draw := func(frame, footer string) {
+		fmt.Fprint(os.Stderr, "\033[H\033[2J")
+		fmt.Fprint(os.Stderr, strings.ReplaceAll(frame, "\n", "\r\n"))
+		fmt.Fprintf(os.Stderr, "%s%s%s\r\n", Gray, footer, Reset)
+	}
+
+	var frame string
+	wait := interval
+	for first := true; ; first = false {
+		if frame != "" {
+			draw(frame, "Refreshing...")
+		}
+		var changed bool
+		frame, changed = refresh()
+		switch {
+		case first || changed:
+			wait = interval
+		case wait < maxInterval:
+			wait = min(wait*2, maxInterval)
+		}
+
+		footer := fmt.Sprintf("Updated %s · next refresh in %s · r refresh · q quit", time.Now().Format("15:04:05"), wait)
+		draw(frame, footer)
+
+		timer := time.NewTimer(wait)
+	waitLoop:
+		for {
+			select {
+			case <-timer.C:
+				break waitLoop
+			case key, ok := <-keys:
+				switch {
+				case !ok, key == 'q', key == 'Q', key == 3, key == 27: // Ctrl+C, Esc
+					timer.Stop()
+					return nil
+				case key == 'r', key == 'R':
+					timer.Stop()
+					break waitLoop
+				}
+			}
+		}
+	}
+}
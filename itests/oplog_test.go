@@ -0,0 +1,112 @@
+package itests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KulkarniKaustubh/ezstack/cmd/ezs/commands"
+	"github.com/KulkarniKaustubh/ezstack/internal/config"
+	"github.com/KulkarniKaustubh/ezstack/internal/stack"
+	"github.com/KulkarniKaustubh/ezstack/internal/ui"
+)
+
+// chdir switches to dir for the duration of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	orig, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd failed: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Chdir failed: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(orig) })
+}
+
+// TestUndoReparent tests that a recorded reparent is reverted by undo
+func TestUndoReparent(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.Cleanup()
+
+	CreateBranchWithCommit(t, env, "branch-a", "main")
+	CreateBranchWithCommit(t, env, "branch-b", "branch-a")
+	chdir(t, env.RepoDir)
+
+	g := NewGit(env)
+	oldHead, _ := g.GetBranchCommit("branch-b")
+
+	err := commands.RecordOperation("reparent", []string{"branch-b"}, func([]string) error {
+		mgr, err := stack.NewManager(env.RepoDir)
+		if err != nil {
+			return err
+		}
+		_, err = mgr.ReparentBranch("branch-b", "main", true)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("reparent failed: %v", err)
+	}
+	AssertBranchParent(t, env, "branch-b", "main")
+
+	ops, err := config.LoadOpLog(env.RepoDir)
+	if err != nil || len(ops) != 1 {
+		t.Fatalf("LoadOpLog() = %d ops, %v; want 1 op", len(ops), err)
+	}
+	rc, ok := ops[0].Refs["branch-b"]
+	if !ok || rc.Before != oldHead {
+		t.Errorf("recorded refs = %+v, want branch-b moved from %s", ops[0].Refs, oldHead)
+	}
+
+	ui.YesMode = true
+	defer func() { ui.YesMode = false }()
+	if err := commands.Undo(nil); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+
+	AssertBranchParent(t, env, "branch-b", "branch-a")
+	if head, _ := g.GetBranchCommit("branch-b"); head != oldHead {
+		t.Errorf("branch-b = %s after undo, want %s", head, oldHead)
+	}
+	if ops, _ := config.LoadOpLog(env.RepoDir); len(ops) != 0 {
+		t.Errorf("operation log has %d entries after undo, want 0", len(ops))
+	}
+}
+
+// TestUndoDelete tests that undo recreates a deleted branch and its worktree
+func TestUndoDelete(t *testing.T) {
+	env := SetupTestEnv(t)
+	defer env.Cleanup()
+
+	CreateBranchWithCommit(t, env, "to-delete", "main")
+	chdir(t, env.RepoDir)
+
+	g := NewGit(env)
+	oldHead, _ := g.GetBranchCommit("to-delete")
+
+	err := commands.RecordOperation("delete", []string{"to-delete"}, func([]string) error {
+		mgr, err := stack.NewManager(env.RepoDir)
+		if err != nil {
+			return err
+		}
+		return mgr.DeleteBranch("to-delete", true)
+	})
+	if err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	AssertBranchNotExists(t, env, "to-delete")
+
+	ui.YesMode = true
+	defer func() { ui.YesMode = false }()
+	if err := commands.Undo([]string{"1"}); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+
+	AssertBranchExists(t, env, "to-delete")
+	if head, _ := g.GetBranchCommit("to-delete"); head != oldHead {
+		t.Errorf("to-delete = %s after undo, want %s", head, oldHead)
+	}
+	if !dirExists(filepath.Join(env.WorktreeDir, "to-delete")) {
+		t.Error("worktree should be recreated by undo")
+	}
+}
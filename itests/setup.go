@@ -68,8 +68,9 @@ func SetupTestEnv(t *testing.T) *TestEnv {
 	}
 	cfg.Save()
 
-	// Setup stub gh
+	// Setup stub gh; a token would switch PR operations to the GitHub API
 	setupStubGh(t, env)
+	t.Setenv("GITHUB_TOKEN", "")
 
 	return env
 }
@@ -27,7 +27,7 @@ assert_parent() {
     local branch="$1"
     local expected_parent="$2"
     # Check the parent by looking at ezs status output or config
-    local actual_parent=$(grep -A5 "\"name\": \"$branch\"" ~/.ezstack/stacks.json | grep '"parent"' | head -1 | sed 's/.*: "\([^"]*\)".*/\1/')
+    local actual_parent=$(grep -A5 "\"name\": \"$branch\"" "$(git -C "$TEST_DIR" rev-parse --path-format=absolute --git-common-dir)/ezstack/stacks.json" | grep '"parent"' | head -1 | sed 's/.*: "\([^"]*\)".*/\1/')
     if [ "$actual_parent" != "$expected_parent" ]; then
         error "Branch $branch has parent '$actual_parent', expected '$expected_parent'"
     fi